@@ -0,0 +1,502 @@
+// Package application provides the default implementation of core.Application.
+//
+// NewApplication walks the module graph starting at the root module: imported
+// modules are processed first, every module gets its own dependency injection
+// container holding its providers plus the exports of the modules it imports,
+// controllers are mounted on prefix-scoped route groups and OnModuleInit runs
+// once per module, imports before importers. Shutdown reverses the process.
+//
+//	app, err := application.NewApplication(users.NewModule(), core.DefaultConfigOptions())
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	log.Fatal(app.Listen(":3000"))
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+	"github.com/gsoares85/goaegis/pkg/di"
+	"github.com/gsoares85/goaegis/pkg/logger"
+	"github.com/gsoares85/goaegis/pkg/middleware"
+	"github.com/gsoares85/goaegis/pkg/router"
+)
+
+// ErrCircularImport is returned when modules import each other.
+var ErrCircularImport = errors.New("circular module import")
+
+// App is the default implementation of core.Application.
+type App struct {
+	// options holds the application configuration
+	options core.ConfigOptions
+
+	// router dispatches requests to the controllers of every module
+	router *router.Router
+
+	// container is the container of the root module
+	container *di.Container
+
+	// logger is the root logger, named after each module in its container
+	logger core.Logger
+
+	// mu protects modules, initOrder and server
+	mu sync.Mutex
+
+	// modules maps every registered module to its runtime state
+	modules map[core.Module]*moduleRef
+
+	// initOrder lists the modules in the order they were initialized
+	initOrder []*moduleRef
+
+	// server is the HTTP server started by Listen or ListenTLS
+	server *http.Server
+
+	// requests tracks the requests being served, see lifecycle.go
+	requests requestTracker
+
+	// gateways is canceled on shutdown, closing the connections of the gateways
+	gateways context.Context
+
+	// closeGateways cancels gateways
+	closeGateways context.CancelFunc
+
+	// shutdownOnce ensures the shutdown sequence runs once
+	shutdownOnce sync.Once
+
+	// shutdownDone is closed when the shutdown sequence has completed
+	shutdownDone chan struct{}
+
+	// shutdownErr holds the result of the shutdown sequence
+	shutdownErr error
+}
+
+// moduleRef is the runtime state of a registered module.
+type moduleRef struct {
+	// module is the user-defined module
+	module core.Module
+
+	// container resolves the module providers and the exports of its imports
+	container *di.Container
+
+	// exports are the tokens other modules may import from this module
+	exports []interface{}
+}
+
+// Ensure App implements core.Application.
+var _ core.Application = (*App)(nil)
+
+// NewApplication creates an application and registers the root module with it.
+// Bootstrapping errors, such as missing exports, circular imports or a failing
+// OnModuleInit, are returned instead of surfacing on the first request.
+func NewApplication(root core.Module, opts core.ConfigOptions) (*App, error) {
+	app := &App{
+		options:      opts,
+		router:       router.NewRouter(),
+		logger:       newLogger(opts),
+		modules:      make(map[core.Module]*moduleRef),
+		shutdownDone: make(chan struct{}),
+	}
+	app.gateways, app.closeGateways = context.WithCancel(context.Background())
+	app.router.SetBodyOptions(core.BodyOptions{
+		MaxBytes:   opts.MaxBodyBytes,
+		StrictJSON: opts.StrictJSON,
+	})
+	app.router.SetJSONCodec(jsonCodec(opts))
+	proxies, err := trustedProxies(opts)
+	if err != nil {
+		return nil, err
+	}
+	app.router.SetTrustedProxies(proxies)
+	app.router.SetProxyHeaders(opts.ProxyHeaders)
+	app.router.SetLogger(app.logger)
+	app.router.Use(middleware.Recover(middleware.RecoverOptions{
+		Logger:      app.logger.Named("Recovery"),
+		ExposeStack: opts.Environment == "development",
+	}))
+	if opts.EnableCors {
+		cors, err := middleware.NewCORS(opts.CORS)
+		if err != nil {
+			return nil, err
+		}
+		app.router.Use(cors)
+	}
+
+	if root == nil {
+		app.container = di.NewContainer()
+		return app, nil
+	}
+
+	if err := app.RegisterModule(root); err != nil {
+		return nil, err
+	}
+	app.container = app.modules[root].container
+	return app, nil
+}
+
+// RegisterModule registers a module, and the modules it imports, with the application.
+// Modules that were already registered, directly or as an import, are skipped.
+func (a *App) RegisterModule(module core.Module) error {
+	if module == nil {
+		return errors.New("module is nil")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var created []*moduleRef
+	if _, err := a.build(module, nil, &created); err != nil {
+		return err
+	}
+
+	for _, ref := range created {
+		if err := a.mount(ref); err != nil {
+			return err
+		}
+	}
+
+	for _, ref := range created {
+		if err := ref.module.OnModuleInit(); err != nil {
+			return fmt.Errorf("module %s: OnModuleInit failed: %w", moduleName(ref.module), err)
+		}
+		a.initOrder = append(a.initOrder, ref)
+	}
+	return nil
+}
+
+// Use registers a global middleware that will be applied to all routes.
+func (a *App) Use(middleware core.Middleware) core.Application {
+	a.router.Use(middleware)
+	return a
+}
+
+// Listen starts the HTTP server on the specified address.
+// An empty address falls back to the Host and Port of the configuration.
+// Once Shutdown is called, Listen waits for the shutdown sequence to complete
+// and returns its result.
+func (a *App) Listen(addr string) error {
+	server := a.newServer(addr)
+	if server == nil {
+		return a.waitShutdown()
+	}
+	return a.serveResult(server.ListenAndServe())
+}
+
+// ListenTLS starts the HTTPS server with the provided certificate and key files.
+func (a *App) ListenTLS(addr string, certFile, keyFile string) error {
+	server := a.newServer(addr)
+	if server == nil {
+		return a.waitShutdown()
+	}
+	return a.serveResult(server.ListenAndServeTLS(certFile, keyFile))
+}
+
+// GetContainer returns the container of the root module.
+func (a *App) GetContainer() core.Container {
+	return a.container
+}
+
+// GetRouter returns the application's router.
+func (a *App) GetRouter() core.Router {
+	return a.router
+}
+
+// GetLogger returns the root logger of the application.
+func (a *App) GetLogger() core.Logger {
+	return a.logger
+}
+
+// ServeHTTP implements the http.Handler interface, so the application can be
+// mounted on an existing server or exercised with httptest.
+// Requests arriving after Shutdown has started receive a 503 response.
+func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !a.requests.enter() {
+		w.Header().Set("Connection", "close")
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	defer a.requests.leave()
+
+	a.router.ServeHTTP(w, r)
+}
+
+// build creates the runtime state of module after building its imports.
+// visiting holds the import path leading to module, used to detect cycles,
+// and created receives the new modules in dependency order. The caller must hold a.mu.
+func (a *App) build(module core.Module, visiting []core.Module, created *[]*moduleRef) (*moduleRef, error) {
+	if !reflect.TypeOf(module).Comparable() {
+		return nil, fmt.Errorf("module %s is not comparable; register a pointer instead", moduleName(module))
+	}
+	if ref, ok := a.modules[module]; ok {
+		return ref, nil
+	}
+
+	for i, m := range visiting {
+		if m == module {
+			names := make([]string, 0, len(visiting)-i+1)
+			for _, v := range visiting[i:] {
+				names = append(names, moduleName(v))
+			}
+			names = append(names, moduleName(module))
+			return nil, fmt.Errorf("%w: %s", ErrCircularImport, strings.Join(names, " -> "))
+		}
+	}
+	visiting = append(visiting, module)
+
+	imports := make([]*moduleRef, 0, len(module.GetImports()))
+	for _, imported := range module.GetImports() {
+		if imported == nil {
+			return nil, fmt.Errorf("module %s imports a nil module", moduleName(module))
+		}
+		ref, err := a.build(imported, visiting, created)
+		if err != nil {
+			return nil, err
+		}
+		imports = append(imports, ref)
+	}
+
+	ref := &moduleRef{
+		module:    module,
+		container: di.NewContainer(),
+	}
+
+	for _, provider := range module.GetProviders() {
+		if err := ref.container.Register(provider); err != nil {
+			return nil, fmt.Errorf("module %s: %w", moduleName(module), err)
+		}
+	}
+
+	for _, imported := range imports {
+		if err := ref.container.Import(imported.container, imported.exports...); err != nil {
+			return nil, fmt.Errorf("module %s: %w", moduleName(module), err)
+		}
+	}
+
+	if token := di.Token[core.Logger](); !ref.container.Has(token) {
+		moduleLogger := a.logger.Named(loggerName(module))
+		err := ref.container.Register(core.ProviderMetadata{
+			Token:   token,
+			Factory: func(core.Container) (interface{}, error) { return moduleLogger, nil },
+			Scope:   core.SingletonScope,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("module %s: %w", moduleName(module), err)
+		}
+	}
+
+	exports, err := a.exportTokens(module.GetExports())
+	if err != nil {
+		return nil, fmt.Errorf("module %s: %w", moduleName(module), err)
+	}
+	for _, token := range exports {
+		if !ref.container.Has(token) {
+			return nil, fmt.Errorf("module %s exports %s, which it neither provides nor imports",
+				moduleName(module), di.FormatToken(token))
+		}
+	}
+	ref.exports = exports
+
+	a.modules[module] = ref
+	*created = append(*created, ref)
+	return ref, nil
+}
+
+// mount registers the routes of every controller of ref, each on a route group
+// scoped to the controller prefix and carrying the module and controller
+// middleware, plus the guards of a core.GuardedController, the filters of a
+// core.FilteredController and the interceptors of a core.InterceptedController.
+// The gateways of a core.GatewayModule are then mounted with the module
+// middleware and the guards of a core.GuardedGateway.
+func (a *App) mount(ref *moduleRef) error {
+	moduleMiddleware := ref.module.GetMiddleware()
+
+	for _, controller := range ref.module.GetControllers() {
+		if controller == nil {
+			return fmt.Errorf("module %s declares a nil controller", moduleName(ref.module))
+		}
+
+		middleware := make([]core.Middleware, 0, len(moduleMiddleware)+len(controller.GetMiddleware()))
+		middleware = append(middleware, moduleMiddleware...)
+		middleware = append(middleware, controller.GetMiddleware()...)
+
+		group := a.router.Group(controller.GetPrefix(), middleware...)
+		if guarded, ok := controller.(core.GuardedController); ok {
+			group.UseGuards(guarded.GetGuards()...)
+		}
+		if filtered, ok := controller.(core.FilteredController); ok {
+			group.UseFilters(filtered.GetFilters()...)
+		}
+		if intercepted, ok := controller.(core.InterceptedController); ok {
+			group.UseInterceptors(intercepted.GetInterceptors()...)
+		}
+		if err := controller.RegisterRoutes(group); err != nil {
+			return fmt.Errorf("module %s: controller %T: %w", moduleName(ref.module), controller, err)
+		}
+	}
+
+	gatewayModule, ok := ref.module.(core.GatewayModule)
+	if !ok {
+		return nil
+	}
+	for _, gateway := range gatewayModule.GetGateways() {
+		if gateway == nil {
+			return fmt.Errorf("module %s declares a nil gateway", moduleName(ref.module))
+		}
+
+		var opts core.WebSocketOptions
+		if configured, ok := gateway.(core.ConfiguredGateway); ok {
+			opts = configured.GetWebSocketOptions()
+		}
+		handler, err := core.GatewayHandler(gateway, opts)
+		if err != nil {
+			return fmt.Errorf("module %s: gateway %T: %w", moduleName(ref.module), gateway, err)
+		}
+
+		group := a.router.Group("", append(moduleMiddleware[:len(moduleMiddleware):len(moduleMiddleware)], a.closeOnShutdown)...)
+		if guarded, ok := gateway.(core.GuardedGateway); ok {
+			group.UseGuards(guarded.GetGuards()...)
+		}
+		group.GET(gateway.GetPath(), handler)
+	}
+	return nil
+}
+
+// closeOnShutdown runs a gateway handler with a context canceled on
+// shutdown, which closes its connection with core.CloseGoingAway.
+func (a *App) closeOnShutdown(ctx core.Context, next core.HandlerFunc) error {
+	shutdown, cancel := context.WithCancel(ctx.Context())
+	defer cancel()
+	stop := context.AfterFunc(a.gateways, cancel)
+	defer stop()
+	return next(ctx.WithContext(shutdown))
+}
+
+// exportTokens normalizes the value returned by Module.GetExports into provider tokens.
+// Exports may be tokens, Providers, or imported Modules whose exports are re-exported,
+// either alone or in a slice. The caller must hold a.mu.
+func (a *App) exportTokens(exports interface{}) ([]interface{}, error) {
+	switch e := exports.(type) {
+	case nil:
+		return nil, nil
+	case core.Provider:
+		return []interface{}{e.GetToken()}, nil
+	case core.Module:
+		ref, ok := a.modules[e]
+		if !ok {
+			return nil, fmt.Errorf("cannot re-export module %s that is not imported", moduleName(e))
+		}
+		return ref.exports, nil
+	}
+
+	value := reflect.ValueOf(exports)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return []interface{}{exports}, nil
+	}
+
+	var tokens []interface{}
+	for i := 0; i < value.Len(); i++ {
+		item := value.Index(i).Interface()
+		if item == nil {
+			continue
+		}
+		itemTokens, err := a.exportTokens(item)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, itemTokens...)
+	}
+	return tokens, nil
+}
+
+// newServer creates the HTTP server for addr from the application options.
+// It returns nil if the application is already shutting down.
+func (a *App) newServer(addr string) *http.Server {
+	if addr == "" {
+		addr = fmt.Sprintf("%s:%d", a.options.Host, a.options.Port)
+	}
+
+	server := &http.Server{
+		Addr:           addr,
+		Handler:        a,
+		ReadTimeout:    time.Duration(a.options.ReadTimeout) * time.Second,
+		WriteTimeout:   time.Duration(a.options.WriteTimeout) * time.Second,
+		MaxHeaderBytes: a.options.MaxHeaderBytes,
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.requests.isClosing() {
+		return nil
+	}
+	a.server = server
+	return server
+}
+
+// serveResult converts the error returned by a serving server into the result of Listen.
+func (a *App) serveResult(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return a.waitShutdown()
+	}
+	return err
+}
+
+// jsonCodec returns the JSON codec of the application: the configured one,
+// or encoding/json with indented output in the development environment.
+func jsonCodec(opts core.ConfigOptions) core.JSONCodec {
+	if opts.JSONCodec != nil {
+		return opts.JSONCodec
+	}
+	if opts.Environment == "development" {
+		return core.StdJSONCodec{Indent: "  "}
+	}
+	return core.StdJSONCodec{}
+}
+
+// newLogger returns the root logger of the application: the configured one,
+// or a console logger at debug level in the development environment and a
+// JSON logger at info level otherwise.
+func newLogger(opts core.ConfigOptions) core.Logger {
+	if opts.Logger != nil {
+		return opts.Logger
+	}
+	if opts.Environment == "development" {
+		return logger.New(logger.Options{Level: core.LogLevelDebug, Format: logger.FormatConsole})
+	}
+	return logger.New(logger.Options{Level: core.LogLevelInfo, Format: logger.FormatJSON})
+}
+
+// trustedProxies returns the proxies whose forwarding headers are trusted:
+// the configured TrustedProxies, the loopback and private networks if only
+// TrustProxy is set, and none otherwise.
+func trustedProxies(opts core.ConfigOptions) (core.TrustedProxies, error) {
+	switch {
+	case len(opts.TrustedProxies) > 0:
+		return core.ParseTrustedProxies(opts.TrustedProxies...)
+	case opts.TrustProxy:
+		return core.PrivateProxies, nil
+	default:
+		return nil, nil
+	}
+}
+
+// loggerName returns the context of the logger of module: the name of its
+// type, without package or pointer.
+func loggerName(module core.Module) string {
+	t := reflect.TypeOf(module)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// moduleName returns a readable name for module, used in error messages.
+func moduleName(module core.Module) string {
+	return fmt.Sprintf("%T", module)
+}
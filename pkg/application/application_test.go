@@ -0,0 +1,606 @@
+package application
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+	"github.com/gsoares85/goaegis/pkg/di"
+	"github.com/gsoares85/goaegis/pkg/logger"
+	"github.com/gsoares85/goaegis/pkg/middleware"
+)
+
+// testModule is a Module recording its lifecycle hooks.
+type testModule struct {
+	core.ModuleMetadata
+	name   string
+	events *[]string
+}
+
+func (m *testModule) OnModuleInit() error {
+	*m.events = append(*m.events, "init:"+m.name)
+	return nil
+}
+
+func (m *testModule) OnModuleDestroy() error {
+	*m.events = append(*m.events, "destroy:"+m.name)
+	return nil
+}
+
+// testController serves GET / and GET /:id under its prefix.
+type testController struct {
+	prefix     string
+	middleware []core.Middleware
+	service    func() string
+}
+
+func (c *testController) GetPrefix() string {
+	return c.prefix
+}
+
+func (c *testController) GetMiddleware() []core.Middleware {
+	return c.middleware
+}
+
+func (c *testController) RegisterRoutes(router core.Router) error {
+	router.GET("/", func(ctx core.Context) error {
+		return ctx.String(http.StatusOK, "%s", c.service())
+	})
+	router.GET("/:id", func(ctx core.Context) error {
+		return ctx.String(http.StatusOK, "item %s", ctx.Param("id"))
+	})
+	return nil
+}
+
+func value(token string, v interface{}) core.Provider {
+	return core.ProviderMetadata{
+		Token:   token,
+		Factory: func(c core.Container) (interface{}, error) { return v, nil },
+	}
+}
+
+func header(key, val string) core.Middleware {
+	return func(ctx core.Context, next core.HandlerFunc) error {
+		ctx.SetHeader(key, val)
+		return next(ctx)
+	}
+}
+
+func TestNewApplication_ModuleGraph(t *testing.T) {
+	var events []string
+
+	database := &testModule{name: "database", events: &events}
+	database.Providers = []core.Provider{value("dsn", "postgres://"), value("db", "conn")}
+	database.Exports = []interface{}{"db"}
+
+	shared := &testModule{name: "shared", events: &events}
+	shared.Imports = []core.Module{database}
+	shared.Exports = []interface{}{database}
+
+	users := &testModule{name: "users", events: &events}
+	users.Imports = []core.Module{database, shared}
+	users.Providers = []core.Provider{core.ProviderMetadata{
+		Token: "users",
+		Factory: func(c core.Container) (interface{}, error) {
+			db, err := c.Resolve("db")
+			if err != nil {
+				return nil, err
+			}
+			return "users over " + db.(string), nil
+		},
+	}}
+	users.Exports = []interface{}{"users"}
+
+	app, err := NewApplication(users, core.DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+
+	expected := []string{"init:database", "init:shared", "init:users"}
+	if strings.Join(events, ",") != strings.Join(expected, ",") {
+		t.Errorf("init order = %v, want %v", events, expected)
+	}
+
+	svc, err := app.GetContainer().Resolve("users")
+	if err != nil || svc != "users over conn" {
+		t.Errorf("Resolve(users) = %v, %v, want %q", svc, err, "users over conn")
+	}
+
+	if _, err := app.GetContainer().Resolve("dsn"); !errors.Is(err, di.ErrProviderNotFound) {
+		t.Errorf("Resolve(dsn) error = %v, want unexported provider to be hidden", err)
+	}
+}
+
+func TestNewApplication_InvalidExport(t *testing.T) {
+	var events []string
+	m := &testModule{name: "m", events: &events}
+	m.Exports = []interface{}{"missing"}
+
+	if _, err := NewApplication(m, core.DefaultConfigOptions()); err == nil {
+		t.Error("NewApplication() should fail when a module exports an unknown token")
+	}
+	if len(events) != 0 {
+		t.Errorf("OnModuleInit should not run when bootstrap fails, got %v", events)
+	}
+}
+
+func TestNewApplication_CircularImport(t *testing.T) {
+	a := &core.ModuleMetadata{}
+	b := &core.ModuleMetadata{Imports: []core.Module{a}}
+	a.Imports = []core.Module{b}
+
+	_, err := NewApplication(a, core.DefaultConfigOptions())
+	if !errors.Is(err, ErrCircularImport) {
+		t.Errorf("NewApplication() error = %v, want %v", err, ErrCircularImport)
+	}
+}
+
+func TestNewApplication_Routes(t *testing.T) {
+	root := &core.ModuleMetadata{
+		Middleware: []core.Middleware{header("X-Module", "root")},
+		Controllers: []core.Controller{&testController{
+			prefix:     "/users",
+			middleware: []core.Middleware{header("X-Controller", "users")},
+			service:    func() string { return "all users" },
+		}},
+	}
+
+	app, err := NewApplication(root, core.DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+	app.Use(header("X-Global", "yes"))
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "all users" {
+		t.Fatalf("GET /users = %d %q, want 200 %q", w.Code, w.Body.String(), "all users")
+	}
+	for key, want := range map[string]string{"X-Global": "yes", "X-Module": "root", "X-Controller": "users"} {
+		if got := w.Header().Get(key); got != want {
+			t.Errorf("%s header = %q, want %q", key, got, want)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/users/9", nil))
+	if w.Body.String() != "item 9" {
+		t.Errorf("GET /users/9 body = %q, want %q", w.Body.String(), "item 9")
+	}
+}
+
+func TestApp_RegisterModule(t *testing.T) {
+	var events []string
+	shared := &testModule{name: "shared", events: &events}
+	first := &testModule{name: "first", events: &events}
+	first.Imports = []core.Module{shared}
+	second := &testModule{name: "second", events: &events}
+	second.Imports = []core.Module{shared}
+
+	app, err := NewApplication(first, core.DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+	if err := app.RegisterModule(second); err != nil {
+		t.Fatalf("RegisterModule() error = %v", err)
+	}
+
+	expected := []string{"init:shared", "init:first", "init:second"}
+	if strings.Join(events, ",") != strings.Join(expected, ",") {
+		t.Errorf("init order = %v, want %v", events, expected)
+	}
+}
+
+// guardedController protects its routes with a guard.
+type guardedController struct {
+	testController
+	guards []core.Guard
+}
+
+func (c *guardedController) GetGuards() []core.Guard {
+	return c.guards
+}
+
+func TestNewApplication_ControllerGuards(t *testing.T) {
+	deny := core.GuardFunc(func(ctx core.Context) (bool, error) { return false, nil })
+	root := &core.ModuleMetadata{Controllers: []core.Controller{&guardedController{
+		testController: testController{prefix: "/admin", service: func() string { return "stats" }},
+		guards:         []core.Guard{deny},
+	}}}
+
+	app, err := NewApplication(root, core.DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/admin", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Status code = %d, want 403", w.Code)
+	}
+}
+
+// filteredController handles the errors of its routes with a filter.
+type filteredController struct {
+	guardedController
+	filters []core.Filter
+}
+
+func (c *filteredController) GetFilters() []core.Filter {
+	return c.filters
+}
+
+func TestNewApplication_ControllerFilters(t *testing.T) {
+	deny := core.GuardFunc(func(ctx core.Context) (bool, error) { return false, nil })
+	hide := core.FilterFunc(func(err error, ctx core.Context) error {
+		return core.NotFound("Cannot %s %s", ctx.Method(), ctx.Path())
+	})
+	root := &core.ModuleMetadata{Controllers: []core.Controller{&filteredController{
+		guardedController: guardedController{
+			testController: testController{prefix: "/admin", service: func() string { return "stats" }},
+			guards:         []core.Guard{deny},
+		},
+		filters: []core.Filter{hide},
+	}}}
+
+	app, err := NewApplication(root, core.DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, httptest.NewRequest("GET", "/admin", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Status code = %d, want 404", w.Code)
+	}
+}
+
+// uploadController accepts a JSON body on POST /upload.
+type uploadController struct{}
+
+func (c *uploadController) GetPrefix() string {
+	return "/upload"
+}
+
+func (c *uploadController) GetMiddleware() []core.Middleware {
+	return nil
+}
+
+func (c *uploadController) RegisterRoutes(router core.Router) error {
+	router.POST("/", func(ctx core.Context) error {
+		var body map[string]string
+		if err := ctx.Body(&body); err != nil {
+			return err
+		}
+		return ctx.NoContent(http.StatusNoContent)
+	})
+	return nil
+}
+
+func TestNewApplication_BodyOptions(t *testing.T) {
+	opts := core.DefaultConfigOptions()
+	opts.MaxBodyBytes = 32
+	opts.StrictJSON = true
+
+	app, err := NewApplication(&core.ModuleMetadata{Controllers: []core.Controller{&uploadController{}}}, opts)
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"a":"b"}`, http.StatusNoContent},
+		{`{"a":"` + strings.Repeat("b", 64) + `"}`, http.StatusRequestEntityTooLarge},
+		{`{"a":"b"} {}`, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "/upload", strings.NewReader(test.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("POST /upload %q = %d, want %d", test.body, w.Code, test.code)
+		}
+	}
+}
+
+// upperCodec encodes JSON responses in upper case to show the codec is used.
+type upperCodec struct{ core.StdJSONCodec }
+
+func (c upperCodec) Encode(w io.Writer, v interface{}) error {
+	var buf bytes.Buffer
+	if err := c.StdJSONCodec.Encode(&buf, v); err != nil {
+		return err
+	}
+	_, err := w.Write(bytes.ToUpper(buf.Bytes()))
+	return err
+}
+
+func TestNewApplication_JSONCodec(t *testing.T) {
+	production := core.DefaultConfigOptions()
+	production.Environment = "production"
+	custom := core.DefaultConfigOptions()
+	custom.JSONCodec = upperCodec{}
+
+	tests := []struct {
+		name string
+		opts core.ConfigOptions
+		want string
+	}{
+		{"development indents", core.DefaultConfigOptions(), "{\n  \"statusCode\": 404,"},
+		{"production is compact", production, `{"statusCode":404,`},
+		{"custom codec", custom, `{"STATUSCODE":404,`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, err := NewApplication(nil, test.opts)
+			if err != nil {
+				t.Fatalf("NewApplication() error = %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+			if !strings.HasPrefix(w.Body.String(), test.want) {
+				t.Errorf("GET /missing body = %q, want prefix %q", w.Body.String(), test.want)
+			}
+		})
+	}
+}
+
+// echoGateway is a gateway guarded by a token header.
+type echoGateway struct{}
+
+func (g *echoGateway) GetPath() string { return "/ws" }
+
+func (g *echoGateway) RegisterEvents(events core.GatewayEvents) error {
+	events.On("echo", func(socket *core.Socket, data json.RawMessage) (interface{}, error) {
+		return data, nil
+	})
+	return nil
+}
+
+func (g *echoGateway) GetGuards() []core.Guard {
+	return []core.Guard{core.GuardFunc(func(ctx core.Context) (bool, error) {
+		return ctx.GetHeader("X-Token") == "secret", nil
+	})}
+}
+
+func TestNewApplication_Gateways(t *testing.T) {
+	root := &core.ModuleMetadata{
+		Gateways:   []core.Gateway{&echoGateway{}},
+		Middleware: []core.Middleware{header("X-Module", "chat")},
+	}
+	app, err := NewApplication(root, core.DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+	server := httptest.NewServer(app)
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		token string
+		code  int
+	}{
+		{"guard allows", "secret", http.StatusSwitchingProtocols},
+		{"guard denies", "wrong", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", server.URL+"/ws", nil)
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+			req.Header.Set("Sec-WebSocket-Version", "13")
+			req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+			req.Header.Set("X-Token", test.token)
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("GET /ws error = %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != test.code {
+				t.Errorf("GET /ws status = %d, want %d", resp.StatusCode, test.code)
+			}
+			if test.code == http.StatusForbidden && resp.Header.Get("X-Module") != "chat" {
+				t.Errorf("X-Module = %q, want module middleware to run", resp.Header.Get("X-Module"))
+			}
+		})
+	}
+}
+
+func TestNewApplication_TrustedProxies(t *testing.T) {
+	behindProxy := core.DefaultConfigOptions()
+	behindProxy.TrustProxy = true
+	explicit := core.DefaultConfigOptions()
+	explicit.TrustedProxies = []string{"192.0.2.0/24"}
+	forwarded := core.DefaultConfigOptions()
+	forwarded.TrustProxy = true
+	forwarded.ProxyHeaders = core.ForwardedHeader
+	invalid := core.DefaultConfigOptions()
+	invalid.TrustedProxies = []string{"not-an-ip"}
+
+	if _, err := NewApplication(nil, invalid); err == nil {
+		t.Error("NewApplication() should reject an invalid trusted proxy")
+	}
+
+	tests := []struct {
+		name   string
+		opts   core.ConfigOptions
+		remote string
+		want   string
+	}{
+		{"untrusted by default", core.DefaultConfigOptions(), "10.0.0.1:1234", "10.0.0.1"},
+		{"private networks", behindProxy, "10.0.0.1:1234", "203.0.113.9"},
+		{"public peer", behindProxy, "192.0.2.1:1234", "192.0.2.1"},
+		{"explicit list", explicit, "192.0.2.1:1234", "203.0.113.9"},
+		{"Forwarded header", forwarded, "10.0.0.1:1234", "198.51.100.4"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, err := NewApplication(nil, test.opts)
+			if err != nil {
+				t.Fatalf("NewApplication() error = %v", err)
+			}
+			app.GetRouter().GET("/ip", func(ctx core.Context) error {
+				return ctx.String(http.StatusOK, "%s", ctx.ClientIP())
+			})
+
+			req := httptest.NewRequest("GET", "/ip", nil)
+			req.RemoteAddr = test.remote
+			req.Header.Set("X-Forwarded-For", "203.0.113.9")
+			req.Header.Set("Forwarded", "for=198.51.100.4")
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			if w.Body.String() != test.want {
+				t.Errorf("ClientIP() = %q, want %q", w.Body.String(), test.want)
+			}
+		})
+	}
+}
+
+func TestNewApplication_EnableCors(t *testing.T) {
+	opts := core.DefaultConfigOptions()
+	opts.EnableCors = true
+	opts.CORS = core.CORSOptions{AllowOrigins: []string{"https://app.example.com"}}
+
+	app, err := NewApplication(nil, opts)
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+	app.GetRouter().POST("/users", func(ctx core.Context) error {
+		return ctx.NoContent(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest("OPTIONS", "/users", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+	app.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("OPTIONS /users status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, "https://app.example.com")
+	}
+}
+
+func TestNewApplication_CorsCredentials(t *testing.T) {
+	opts := core.DefaultConfigOptions()
+	opts.EnableCors = true
+	opts.CORS = core.CORSOptions{AllowCredentials: true}
+
+	if _, err := NewApplication(nil, opts); err == nil {
+		t.Error("NewApplication() should fail when CORS allows credentials from any origin")
+	}
+}
+
+func TestNewApplication_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	opts := core.DefaultConfigOptions()
+	opts.Logger = logger.New(logger.Options{Output: &buf, Format: logger.FormatJSON})
+
+	custom := logger.New(logger.Options{Output: io.Discard})
+	overridden := &core.ModuleMetadata{Providers: []core.Provider{core.ProviderMetadata{
+		Token:   di.Token[core.Logger](),
+		Factory: func(core.Container) (interface{}, error) { return custom, nil },
+	}}}
+	root := &testModule{name: "root", events: new([]string)}
+
+	app, err := NewApplication(root, opts)
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+	if app.GetLogger() != opts.Logger {
+		t.Errorf("GetLogger() = %v, want the configured logger", app.GetLogger())
+	}
+
+	log, err := di.ResolveAs[core.Logger](app.GetContainer(), di.Token[core.Logger]())
+	if err != nil {
+		t.Fatalf("Resolve(Logger) error = %v", err)
+	}
+	log.Info("ready")
+	if !strings.Contains(buf.String(), `"context":"testModule","msg":"ready"`) {
+		t.Errorf("module logger record = %q, want context %q", buf.String(), "testModule")
+	}
+
+	app2, err := NewApplication(overridden, opts)
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+	if log, _ := app2.GetContainer().Resolve(di.Token[core.Logger]()); log != custom {
+		t.Errorf("Resolve(Logger) = %v, want the module's own logger", log)
+	}
+}
+
+func TestNewApplication_AccessLogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	opts := core.DefaultConfigOptions()
+	opts.Logger = logger.New(logger.Options{Output: &buf, Format: logger.FormatJSON})
+
+	app, err := NewApplication(nil, opts)
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+	app.Use(middleware.AccessLog(middleware.AccessLogOptions{}))
+	app.GetRouter().GET("/users", func(ctx core.Context) error {
+		return ctx.NoContent(http.StatusNoContent)
+	})
+
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+	if !strings.Contains(buf.String(), `"context":"HTTP","msg":"request completed"`) {
+		t.Errorf("access log record = %q, want it on the application logger", buf.String())
+	}
+}
+
+func TestNewApplication_RecoversPanics(t *testing.T) {
+	production := core.DefaultConfigOptions()
+	production.Environment = "production"
+
+	tests := []struct {
+		name    string
+		opts    core.ConfigOptions
+		details bool
+	}{
+		{"development", core.DefaultConfigOptions(), true},
+		{"production", production, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.opts.Logger = logger.New(logger.Options{Output: &buf})
+			app, err := NewApplication(nil, tt.opts)
+			if err != nil {
+				t.Fatalf("NewApplication() error = %v", err)
+			}
+			app.GetRouter().GET("/panic", func(ctx core.Context) error {
+				panic("boom")
+			})
+
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+
+			var response core.ErrorResponse
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+			if w.Code != http.StatusInternalServerError || (response.Details != nil) != tt.details {
+				t.Errorf("GET /panic = %d with details %v, want 500 with details %v", w.Code, response.Details, tt.details)
+			}
+			if !strings.Contains(buf.String(), "[Recovery] panic recovered") {
+				t.Errorf("log = %q, want the recovered panic", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Shutdown gracefully shuts down the application.
+//
+// It stops accepting new connections and requests, closes the connections
+// of the gateways with core.CloseGoingAway, waits for in-flight requests to
+// complete until ctx is done, then destroys the modules in reverse
+// initialization order: for each module, OnModuleDestroy is called and then
+// OnDestroy on its singleton providers implementing core.LifecycleHook, most
+// recently created first. Every hook runs even if the drain timed out or an
+// earlier hook failed; the returned error joins all failures.
+//
+// Shutdown is idempotent: later calls wait for the first one and return its result.
+func (a *App) Shutdown(ctx context.Context) error {
+	a.shutdownOnce.Do(func() {
+		a.shutdownErr = a.shutdown(ctx)
+		close(a.shutdownDone)
+	})
+	return a.waitShutdown()
+}
+
+// EnableShutdownHooks makes the application call Shutdown when the process
+// receives one of signals, SIGINT and SIGTERM if none are given. The drain of
+// in-flight requests is bounded by timeout; a timeout of zero waits indefinitely.
+// Listen returns the result of the shutdown once it completes, so main can exit.
+// The returned function stops listening for the signals.
+//
+// Example:
+//
+//	stop := app.EnableShutdownHooks(30 * time.Second)
+//	defer stop()
+//	if err := app.Listen(":3000"); err != nil {
+//	    log.Fatal(err)
+//	}
+func (a *App) EnableShutdownHooks(timeout time.Duration, signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+
+	stopped := make(chan struct{})
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			signal.Stop(received)
+			close(stopped)
+		})
+	}
+
+	go a.awaitSignal(received, stopped, timeout)
+
+	return stop
+}
+
+// awaitSignal calls Shutdown, bounded by timeout, when a signal is received,
+// unless stopped is closed first.
+func (a *App) awaitSignal(received <-chan os.Signal, stopped <-chan struct{}, timeout time.Duration) {
+	select {
+	case <-received:
+	case <-stopped:
+		return
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	_ = a.Shutdown(ctx)
+}
+
+// shutdown runs the shutdown sequence.
+func (a *App) shutdown(ctx context.Context) error {
+	var errs []error
+
+	a.mu.Lock()
+	a.requests.close()
+	server := a.server
+	a.mu.Unlock()
+
+	// Gateway connections outlive the HTTP server shutdown: close them so
+	// that their handlers return and the drain completes.
+	a.closeGateways()
+
+	if server != nil {
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("http server shutdown: %w", err))
+		}
+	}
+
+	if err := a.requests.drain(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("waiting for in-flight requests: %w", err))
+	}
+
+	a.mu.Lock()
+	initOrder := a.initOrder
+	a.initOrder = nil
+	a.mu.Unlock()
+
+	for i := len(initOrder) - 1; i >= 0; i-- {
+		ref := initOrder[i]
+		if err := ref.module.OnModuleDestroy(); err != nil {
+			errs = append(errs, fmt.Errorf("module %s: OnModuleDestroy failed: %w", moduleName(ref.module), err))
+		}
+		if err := ref.container.Destroy(); err != nil {
+			errs = append(errs, fmt.Errorf("module %s: %w", moduleName(ref.module), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// waitShutdown blocks until the shutdown sequence has completed and returns its result.
+func (a *App) waitShutdown() error {
+	<-a.shutdownDone
+	return a.shutdownErr
+}
+
+// requestTracker counts in-flight requests and rejects new ones once closed.
+type requestTracker struct {
+	mu      sync.Mutex
+	active  int
+	closing bool
+	idle    chan struct{}
+}
+
+// enter registers a new request, returning false if the tracker is closed.
+func (t *requestTracker) enter() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closing {
+		return false
+	}
+	t.active++
+	return true
+}
+
+// leave marks a request as completed.
+func (t *requestTracker) leave() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active--
+	if t.active == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+// close stops the tracker from accepting new requests.
+func (t *requestTracker) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closing = true
+}
+
+// isClosing reports whether close has been called.
+func (t *requestTracker) isClosing() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closing
+}
+
+// drain waits until no request is in flight or ctx is done.
+func (t *requestTracker) drain(ctx context.Context) error {
+	t.mu.Lock()
+	if t.active == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,315 @@
+package application
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+)
+
+// closer is a provider recording its lifecycle hooks.
+type closer struct {
+	name   string
+	events *[]string
+	mu     *sync.Mutex
+}
+
+func (c *closer) OnInit() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.events = append(*c.events, "init:"+c.name)
+	return nil
+}
+
+func (c *closer) OnDestroy() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.events = append(*c.events, "destroy:"+c.name)
+	return nil
+}
+
+// failingModule fails in OnModuleDestroy.
+type failingModule struct {
+	core.ModuleMetadata
+}
+
+func (m *failingModule) OnModuleDestroy() error {
+	return errors.New("cannot close")
+}
+
+func TestApp_ShutdownOrder(t *testing.T) {
+	var events []string
+	var mu sync.Mutex
+
+	database := &testModule{name: "database", events: &events}
+	database.Providers = []core.Provider{core.ProviderMetadata{
+		Token: "pool",
+		Factory: func(c core.Container) (interface{}, error) {
+			return &closer{name: "pool", events: &events, mu: &mu}, nil
+		},
+	}}
+	database.Exports = []interface{}{"pool"}
+
+	users := &testModule{name: "users", events: &events}
+	users.Imports = []core.Module{database}
+
+	app, err := NewApplication(users, core.DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+	if _, err := app.GetContainer().Resolve("pool"); err != nil {
+		t.Fatalf("Resolve(pool) error = %v", err)
+	}
+
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	expected := "init:database,init:users,init:pool,destroy:users,destroy:database,destroy:pool"
+	if got := strings.Join(events, ","); got != expected {
+		t.Errorf("lifecycle order = %s, want %s", got, expected)
+	}
+
+	// Shutdown is idempotent.
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Errorf("second Shutdown() error = %v", err)
+	}
+	if strings.Count(strings.Join(events, ","), "destroy:") != 3 {
+		t.Errorf("destroy hooks should run once, got %v", events)
+	}
+}
+
+func TestApp_ShutdownAggregatesErrors(t *testing.T) {
+	first := &failingModule{}
+	second := &failingModule{ModuleMetadata: core.ModuleMetadata{Imports: []core.Module{first}}}
+
+	app, err := NewApplication(second, core.DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+
+	err = app.Shutdown(context.Background())
+	if err == nil || strings.Count(err.Error(), "cannot close") != 2 {
+		t.Errorf("Shutdown() error = %v, want both module failures", err)
+	}
+}
+
+func TestApp_ShutdownDrainsRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	root := &core.ModuleMetadata{Controllers: []core.Controller{routeController(func(r core.Router) {
+		r.GET("/slow", func(ctx core.Context) error {
+			close(started)
+			<-release
+			return ctx.String(http.StatusOK, "done")
+		})
+	})}}
+
+	app, err := NewApplication(root, core.DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+
+	slow := httptest.NewRecorder()
+	served := make(chan struct{})
+	go func() {
+		app.ServeHTTP(slow, httptest.NewRequest("GET", "/slow", nil))
+		close(served)
+	}()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- app.Shutdown(context.Background())
+	}()
+
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown() returned %v before the in-flight request completed", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	rejected := httptest.NewRecorder()
+	app.ServeHTTP(rejected, httptest.NewRequest("GET", "/slow", nil))
+	if rejected.Code != http.StatusServiceUnavailable {
+		t.Errorf("request during shutdown status = %d, want 503", rejected.Code)
+	}
+
+	close(release)
+	<-served
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+	if slow.Body.String() != "done" {
+		t.Errorf("in-flight response = %q, want %q", slow.Body.String(), "done")
+	}
+}
+
+func TestApp_ShutdownDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+
+	root := &core.ModuleMetadata{Controllers: []core.Controller{routeController(func(r core.Router) {
+		r.GET("/stuck", func(ctx core.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	})}}
+
+	app, err := NewApplication(root, core.DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+	go app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/stuck", nil))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := app.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestApp_ShutdownClosesGateways(t *testing.T) {
+	var events []string
+	var mu sync.Mutex
+	root := &core.ModuleMetadata{
+		Gateways: []core.Gateway{&echoGateway{}},
+		Providers: []core.Provider{core.ProviderMetadata{
+			Token:   "db",
+			Factory: func(core.Container) (interface{}, error) { return &closer{name: "db", events: &events, mu: &mu}, nil },
+		}},
+	}
+	app, err := NewApplication(root, core.DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+	server := httptest.NewServer(app)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	req, _ := http.NewRequest("GET", server.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("X-Token", "secret")
+	if err := req.Write(conn); err != nil {
+		t.Fatalf("writing the upgrade request: %v", err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("upgrade response = %v, %v, want %d", resp, err, http.StatusSwitchingProtocols)
+	}
+	if _, err := app.GetContainer().Resolve("db"); err != nil {
+		t.Fatalf("Resolve(db) error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := app.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown() error = %v, want nil", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	var frame [4]byte
+	if _, err := io.ReadFull(reader, frame[:]); err != nil {
+		t.Fatalf("reading the close frame: %v", err)
+	}
+	if frame[0] != 0x88 || binary.BigEndian.Uint16(frame[2:]) != core.CloseGoingAway {
+		t.Errorf("frame = %x, want a close frame with code %d", frame, core.CloseGoingAway)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || events[1] != "destroy:db" {
+		t.Errorf("events = %v, want the provider destroyed after the socket closed", events)
+	}
+}
+
+func TestApp_ListenReturnsAfterShutdown(t *testing.T) {
+	app, err := NewApplication(&core.ModuleMetadata{}, core.DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen("127.0.0.1:0")
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	select {
+	case err := <-listenErr:
+		if err != nil {
+			t.Errorf("Listen() error = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Listen() did not return after Shutdown")
+	}
+}
+
+func TestApp_ShutdownOnSignal(t *testing.T) {
+	var events []string
+	root := &testModule{name: "root", events: &events}
+
+	app, err := NewApplication(root, core.DefaultConfigOptions())
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+
+	stop := app.EnableShutdownHooks(time.Second)
+	stop()
+	stop()
+
+	received := make(chan os.Signal, 1)
+	received <- os.Interrupt
+	app.awaitSignal(received, make(chan struct{}), time.Second)
+
+	select {
+	case <-app.shutdownDone:
+	default:
+		t.Fatal("signal did not trigger Shutdown")
+	}
+	if events[len(events)-1] != "destroy:root" {
+		t.Errorf("events = %v, want destroy:root last", events)
+	}
+}
+
+// routeController adapts a registration function into a Controller.
+type routeController func(r core.Router)
+
+func (c routeController) GetPrefix() string {
+	return ""
+}
+
+func (c routeController) GetMiddleware() []core.Middleware {
+	return nil
+}
+
+func (c routeController) RegisterRoutes(r core.Router) error {
+	c(r)
+	return nil
+}
@@ -0,0 +1,414 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Media types understood by the built-in binders.
+const (
+	MIMEApplicationJSON    = "application/json"
+	MIMEApplicationXML     = "application/xml"
+	MIMETextXML            = "text/xml"
+	MIMEApplicationForm    = "application/x-www-form-urlencoded"
+	MIMEMultipartForm      = "multipart/form-data"
+	MIMEApplicationMsgPack = "application/msgpack"
+	// MIMEApplicationXMsgPack is the legacy media type of MessagePack
+	MIMEApplicationXMsgPack = "application/x-msgpack"
+)
+
+// Binder decodes a request body of a given media type into v.
+//
+// Binders for additional media types, such as CBOR, are registered with
+// RegisterBinder:
+//
+//	core.RegisterBinder("application/cbor", core.BinderFunc(func(r *http.Request, v interface{}) error {
+//	    return cbor.NewDecoder(r.Body).Decode(v)
+//	}))
+type Binder interface {
+	// Bind decodes the body of r into v.
+	Bind(r *http.Request, v interface{}) error
+}
+
+// BinderFunc is an adapter to allow ordinary functions to be used as binders.
+type BinderFunc func(r *http.Request, v interface{}) error
+
+// Bind calls f(r, v).
+func (f BinderFunc) Bind(r *http.Request, v interface{}) error {
+	return f(r, v)
+}
+
+// multipartMemory is the maximum number of bytes of a multipart body kept in
+// memory, the remainder being stored in temporary files.
+const multipartMemory = 32 << 20 // 32 MB
+
+// BodyOptions controls how the body of a request is read.
+type BodyOptions struct {
+	// MaxBytes limits the size of the body; zero or less disables the limit
+	MaxBytes int64
+	// StrictJSON rejects unknown fields and data following the JSON value
+	StrictJSON bool
+}
+
+// bodyOptionsKey is the request context key holding the BodyOptions of a request.
+type bodyOptionsKey struct{}
+
+// BodyOptionsFrom returns the body options applied to r with
+// AppContext.SetBodyOptions. Custom binders use it to honor strict decoding.
+func BodyOptionsFrom(r *http.Request) BodyOptions {
+	opts, _ := r.Context().Value(bodyOptionsKey{}).(BodyOptions)
+	return opts
+}
+
+// multipartMemoryFor returns the multipart memory budget of a body, capped by its limit.
+func multipartMemoryFor(opts BodyOptions) int64 {
+	if limit := opts.MaxBytes; limit > 0 && limit < multipartMemory {
+		return limit
+	}
+	return multipartMemory
+}
+
+// bodyBinder is the form of the built-in binders. They receive the body
+// options and the JSON codec from the AppContext, so the request does not
+// need to carry them in its context.Context.
+type bodyBinder func(r *http.Request, v interface{}, opts BodyOptions, codec JSONCodec) error
+
+// Bind decodes the body of r into v with the options returned by
+// BodyOptionsFrom and JSONCodecFrom.
+func (b bodyBinder) Bind(r *http.Request, v interface{}) error {
+	return b(r, v, BodyOptionsFrom(r), JSONCodecFrom(r))
+}
+
+// binders maps media types to the binder decoding them.
+var binders = struct {
+	sync.RWMutex
+	m map[string]Binder
+}{m: map[string]Binder{
+	MIMEApplicationJSON:     bodyBinder(bindJSON),
+	MIMEApplicationXML:      bodyBinder(bindXML),
+	MIMETextXML:             bodyBinder(bindXML),
+	MIMEApplicationForm:     bodyBinder(bindForm),
+	MIMEMultipartForm:       bodyBinder(bindMultipart),
+	MIMEApplicationMsgPack:  bodyBinder(bindMsgPack),
+	MIMEApplicationXMsgPack: bodyBinder(bindMsgPack),
+}}
+
+// RegisterBinder registers binder for mediaType, replacing any binder
+// previously registered for it. Media types are matched without parameters
+// and case-insensitively.
+func RegisterBinder(mediaType string, binder Binder) {
+	binders.Lock()
+	defer binders.Unlock()
+	binders.m[strings.ToLower(mediaType)] = binder
+}
+
+// LookupBinder returns the binder registered for the media type of a
+// Content-Type header value.
+func LookupBinder(contentType string) (Binder, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	binders.RLock()
+	defer binders.RUnlock()
+	binder, ok := binders.m[mediaType]
+	if !ok && strings.HasSuffix(mediaType, "+json") {
+		binder, ok = binders.m[MIMEApplicationJSON]
+	}
+	if !ok && strings.HasSuffix(mediaType, "+xml") {
+		binder, ok = binders.m[MIMEApplicationXML]
+	}
+	return binder, ok
+}
+
+// bindJSON decodes a JSON body with the codec of the request. In strict
+// mode unknown fields and data following the JSON value are rejected.
+func bindJSON(r *http.Request, v interface{}, opts BodyOptions, codec JSONCodec) error {
+	return codec.Decode(r.Body, v, opts.StrictJSON)
+}
+
+// bindXML decodes an XML body.
+func bindXML(r *http.Request, v interface{}, _ BodyOptions, _ JSONCodec) error {
+	return xml.NewDecoder(r.Body).Decode(v)
+}
+
+// bindMsgPack decodes a MessagePack body into v. The body is mapped onto v
+// as its JSON equivalent would be with the codec of the request, honoring
+// json tags and strict decoding.
+func bindMsgPack(r *http.Request, v interface{}, opts BodyOptions, codec JSONCodec) error {
+	document, err := readMsgPack(bufio.NewReader(r.Body), 0)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	return codec.Decode(bytes.NewReader(raw), v, opts.StrictJSON)
+}
+
+// bindForm decodes a URL-encoded form body using the `form` struct tags of v.
+func bindForm(r *http.Request, v interface{}, _ BodyOptions, _ JSONCodec) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	return mapForm(v, r.PostForm, nil)
+}
+
+// bindMultipart decodes a multipart form body using the `form` struct tags of v.
+// Fields of type *multipart.FileHeader or []*multipart.FileHeader receive the
+// uploaded files.
+func bindMultipart(r *http.Request, v interface{}, opts BodyOptions, _ JSONCodec) error {
+	if err := r.ParseMultipartForm(multipartMemoryFor(opts)); err != nil {
+		return err
+	}
+	return mapForm(v, r.MultipartForm.Value, r.MultipartForm.File)
+}
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+	unmarshalerType     = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// mapForm stores form values and files into the struct pointed to by v.
+// Fields are matched by their `form` tag, or their name if untagged; a tag
+// of "-" skips the field and embedded structs are flattened.
+func mapForm(v interface{}, values url.Values, files map[string][]*multipart.FileHeader) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("form binding requires a pointer to a struct, got %T", v)
+	}
+	return mapStruct(rv.Elem(), values, files)
+}
+
+// mapStruct stores form values and files into the fields of struct s.
+func mapStruct(s reflect.Value, values url.Values, files map[string][]*multipart.FileHeader) error {
+	t := s.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		field := s.Field(i)
+
+		name, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			if err := mapStruct(field, values, files); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.CanSet() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		switch f.Type {
+		case fileHeaderType:
+			if fhs := files[name]; len(fhs) > 0 {
+				field.Set(reflect.ValueOf(fhs[0]))
+			}
+			continue
+		case fileHeaderSliceType:
+			if fhs := files[name]; len(fhs) > 0 {
+				field.Set(reflect.ValueOf(fhs))
+			}
+			continue
+		}
+
+		vals, ok := values[name]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		if err := setField(field, vals); err != nil {
+			return fmt.Errorf("form field %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setField stores vals into field, converting them to its type.
+func setField(field reflect.Value, vals []string) error {
+	if field.Kind() == reflect.Slice && !field.Addr().Type().Implements(unmarshalerType) {
+		slice := reflect.MakeSlice(field.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setValue(slice.Index(i), val); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return setValue(field, vals[0])
+}
+
+// setValue stores the string val into v, converting it to the type of v.
+func setValue(v reflect.Value, val string) error {
+	if v.Kind() == reflect.Ptr {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValue(ptr.Elem(), val); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(unmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(val, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return errors.New("unsupported field type " + v.Type().String())
+	}
+	return nil
+}
+
+// requestSources lists the struct tags Context.Bind reads from the request
+// besides the body, in the order they are applied.
+var requestSources = []string{"param", "query", "header"}
+
+// bindRequest stores the path parameters, query parameters and headers named
+// by the `param`, `query` and `header` tags of the struct pointed to by v.
+// Conversion failures are collected into ValidationErrors.
+func bindRequest(ctx Context, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind requires a pointer to a struct, got %T", v)
+	}
+
+	var errs ValidationErrors
+	bindFields(ctx, rv.Elem(), &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// bindFields stores request values into the tagged fields of struct s,
+// flattening embedded structs.
+func bindFields(ctx Context, s reflect.Value, errs *ValidationErrors) {
+	t := s.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		field := s.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			bindFields(ctx, field, errs)
+			continue
+		}
+		if !field.CanSet() {
+			continue
+		}
+
+		for _, source := range requestSources {
+			name, _, _ := strings.Cut(f.Tag.Get(source), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			vals := requestValues(ctx, source, name)
+			if len(vals) == 0 {
+				continue
+			}
+			if err := setField(field, vals); err != nil {
+				*errs = append(*errs, ValidationError{
+					Field:   name,
+					Message: name + " must be " + describeType(f.Type),
+					Value:   strings.Join(vals, ","),
+				})
+			}
+		}
+	}
+}
+
+// requestValues returns the values of the request parameter name in source.
+func requestValues(ctx Context, source, name string) []string {
+	switch source {
+	case "param":
+		if val := ctx.Param(name); val != "" {
+			return []string{val}
+		}
+	case "query":
+		return ctx.QueryArray(name)
+	case "header":
+		return ctx.Request().Header.Values(name)
+	}
+	return nil
+}
+
+// describeType returns the expected form of a value of type t for conversion errors.
+func describeType(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return "an RFC 3339 time"
+	case t == reflect.TypeOf(time.Duration(0)):
+		return "a duration"
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
+		return "a list of " + strings.TrimPrefix(strings.TrimPrefix(describeType(t.Elem()), "a "), "an ") + " values"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a non-negative integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	default:
+		return "a valid " + t.String()
+	}
+}
@@ -0,0 +1,386 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindingAudit struct {
+	Source string `form:"source"`
+}
+
+type bindingSignup struct {
+	bindingAudit
+	Name     string        `form:"name" xml:"name"`
+	Age      int           `form:"age" xml:"age"`
+	Admin    bool          `form:"admin"`
+	Score    *float64      `form:"score"`
+	Tags     []string      `form:"tag"`
+	Born     time.Time     `form:"born"`
+	Timeout  time.Duration `form:"timeout"`
+	Nickname string
+	Ignored  string `form:"-"`
+}
+
+func newBindingContext(contentType string, body io.Reader) *AppContext {
+	r := httptest.NewRequest("POST", "/signup", body)
+	r.Header.Set("Content-Type", contentType)
+	return NewContext(httptest.NewRecorder(), r)
+}
+
+func TestBody_Form(t *testing.T) {
+	form := "name=alice&age=30&admin=true&score=9.5&tag=a&tag=b&born=2026-01-02T00:00:00Z" +
+		"&timeout=1m&Nickname=al&Ignored=x&source=partner"
+	ctx := newBindingContext(MIMEApplicationForm+"; charset=utf-8", strings.NewReader(form))
+
+	var signup bindingSignup
+	if err := ctx.Body(&signup); err != nil {
+		t.Fatalf("Body() error = %v", err)
+	}
+
+	if signup.Name != "alice" || signup.Age != 30 || !signup.Admin {
+		t.Errorf("scalars = %+v, want alice, 30, true", signup)
+	}
+	if signup.Score == nil || *signup.Score != 9.5 {
+		t.Errorf("Score = %v, want 9.5", signup.Score)
+	}
+	if len(signup.Tags) != 2 || signup.Tags[1] != "b" {
+		t.Errorf("Tags = %v, want [a b]", signup.Tags)
+	}
+	if signup.Born.Year() != 2026 || signup.Timeout != time.Minute {
+		t.Errorf("Born, Timeout = %v, %v, want 2026 and 1m", signup.Born, signup.Timeout)
+	}
+	if signup.Nickname != "al" || signup.Ignored != "" || signup.Source != "partner" {
+		t.Errorf("Nickname, Ignored, Source = %q, %q, %q, want al, empty, partner", signup.Nickname, signup.Ignored, signup.Source)
+	}
+}
+
+func TestBody_FormInvalidValue(t *testing.T) {
+	ctx := newBindingContext(MIMEApplicationForm, strings.NewReader("age=old"))
+
+	var signup bindingSignup
+	if err := ctx.Body(&signup); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Body() error = %v, want a 400 exception", err)
+	}
+}
+
+func TestBody_Multipart(t *testing.T) {
+	type upload struct {
+		Title   string                  `form:"title"`
+		Avatar  *multipart.FileHeader   `form:"avatar"`
+		Gallery []*multipart.FileHeader `form:"gallery"`
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	_ = mw.WriteField("title", "holiday")
+	for _, f := range []struct{ field, name string }{{"avatar", "me.png"}, {"gallery", "a.png"}, {"gallery", "b.png"}} {
+		part, _ := mw.CreateFormFile(f.field, f.name)
+		_, _ = part.Write([]byte("png"))
+	}
+	_ = mw.Close()
+
+	ctx := newBindingContext(mw.FormDataContentType(), &buf)
+
+	var u upload
+	if err := ctx.Body(&u); err != nil {
+		t.Fatalf("Body() error = %v", err)
+	}
+	if u.Title != "holiday" {
+		t.Errorf("Title = %q, want %q", u.Title, "holiday")
+	}
+	if u.Avatar == nil || u.Avatar.Filename != "me.png" {
+		t.Errorf("Avatar = %v, want me.png", u.Avatar)
+	}
+	if len(u.Gallery) != 2 {
+		t.Errorf("len(Gallery) = %d, want 2", len(u.Gallery))
+	}
+}
+
+func TestBody_XML(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+	}{
+		{"application/xml", MIMEApplicationXML},
+		{"text/xml", MIMETextXML + "; charset=utf-8"},
+		{"xml suffix", "application/vnd.partner+xml"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := newBindingContext(test.contentType, strings.NewReader(`<signup><name>bob</name><age>41</age></signup>`))
+
+			var signup bindingSignup
+			if err := ctx.Body(&signup); err != nil {
+				t.Fatalf("Body() error = %v", err)
+			}
+			if signup.Name != "bob" || signup.Age != 41 {
+				t.Errorf("signup = %+v, want bob, 41", signup)
+			}
+		})
+	}
+}
+
+func TestBody_MsgPack(t *testing.T) {
+	// {"Name": "alice", "Age": 30}
+	body := []byte{0x82, 0xa4, 'N', 'a', 'm', 'e', 0xa5, 'a', 'l', 'i', 'c', 'e', 0xa3, 'A', 'g', 'e', 0x1e}
+
+	for _, contentType := range []string{MIMEApplicationMsgPack, MIMEApplicationXMsgPack} {
+		ctx := newBindingContext(contentType, bytes.NewReader(body))
+		var signup bindingSignup
+		if err := ctx.Body(&signup); err != nil {
+			t.Fatalf("Body(%s) error = %v", contentType, err)
+		}
+		if signup.Name != "alice" || signup.Age != 30 {
+			t.Errorf("Body(%s) = %+v, want alice, 30", contentType, signup)
+		}
+	}
+}
+
+func TestBody_JSONSuffix(t *testing.T) {
+	ctx := newBindingContext("application/problem+json", strings.NewReader(`{"Name":"carol"}`))
+
+	var signup bindingSignup
+	if err := ctx.Body(&signup); err != nil || signup.Name != "carol" {
+		t.Errorf("Body() = %v, %+v, want carol", err, signup)
+	}
+}
+
+func TestBody_UnsupportedMediaType(t *testing.T) {
+	for _, contentType := range []string{"text/csv", "", "not a media type"} {
+		ctx := newBindingContext(contentType, strings.NewReader("a,b"))
+
+		var signup bindingSignup
+		err := ctx.Body(&signup)
+		if !errors.Is(err, ErrUnsupportedMediaType) {
+			t.Errorf("Body() with %q error = %v, want a 415 exception", contentType, err)
+		}
+	}
+}
+
+func TestRegisterBinder(t *testing.T) {
+	const mediaType = "application/x-test-lines"
+	RegisterBinder(mediaType, BinderFunc(func(r *http.Request, v interface{}) error {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		*(v.(*[]string)) = strings.Split(strings.TrimSpace(string(data)), "\n")
+		return nil
+	}))
+
+	ctx := newBindingContext("Application/X-Test-Lines", strings.NewReader("a\nb\n"))
+
+	var lines []string
+	if err := ctx.Body(&lines); err != nil {
+		t.Fatalf("Body() error = %v", err)
+	}
+	if len(lines) != 2 || lines[1] != "b" {
+		t.Errorf("lines = %v, want [a b]", lines)
+	}
+}
+
+type bindingUpdate struct {
+	ID      int        `param:"id" json:"-"`
+	Tenant  string     `header:"X-Tenant" json:"-"`
+	Roles   []string   `header:"X-Role" json:"-"`
+	Page    int        `query:"page" json:"-"`
+	Notify  *bool      `query:"notify" json:"-"`
+	IDs     []int      `query:"ids" json:"-"`
+	Since   *time.Time `query:"since" json:"-"`
+	Name    string     `json:"name"`
+	Default string     `query:"missing" json:"-"`
+}
+
+func newBindContext(method, target, body string) *AppContext {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	r := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		r.Header.Set("Content-Type", MIMEApplicationJSON)
+	}
+	r.Header.Set("X-Tenant", "acme")
+	r.Header.Add("X-Role", "admin")
+	r.Header.Add("X-Role", "ops")
+	ctx := NewContext(httptest.NewRecorder(), r)
+	ctx.SetParam("id", "42")
+	return ctx
+}
+
+func TestContext_Bind(t *testing.T) {
+	ctx := newBindContext("PUT", "/users/42?page=2&notify=true&ids=1&ids=3&since=2026-01-02T03:04:05Z", `{"name":"alice"}`)
+
+	dto := bindingUpdate{Default: "kept"}
+	if err := ctx.Bind(&dto); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+
+	if dto.ID != 42 || dto.Tenant != "acme" || dto.Page != 2 || dto.Name != "alice" {
+		t.Errorf("dto = %+v, want id 42, tenant acme, page 2, name alice", dto)
+	}
+	if len(dto.Roles) != 2 || dto.Roles[1] != "ops" {
+		t.Errorf("Roles = %v, want [admin ops]", dto.Roles)
+	}
+	if dto.Notify == nil || !*dto.Notify {
+		t.Errorf("Notify = %v, want true", dto.Notify)
+	}
+	if len(dto.IDs) != 2 || dto.IDs[1] != 3 {
+		t.Errorf("IDs = %v, want [1 3]", dto.IDs)
+	}
+	if dto.Since == nil || dto.Since.Hour() != 3 {
+		t.Errorf("Since = %v, want 2026-01-02T03:04:05Z", dto.Since)
+	}
+	if dto.Default != "kept" {
+		t.Errorf("Default = %q, want missing parameters to leave the field untouched", dto.Default)
+	}
+}
+
+func TestContext_BindWithoutBody(t *testing.T) {
+	ctx := newBindContext("GET", "/users/42?page=5", "")
+
+	var dto bindingUpdate
+	if err := ctx.Bind(&dto); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if dto.ID != 42 || dto.Page != 5 {
+		t.Errorf("dto = %+v, want id 42 and page 5", dto)
+	}
+}
+
+func TestContext_BindParamsOverrideBody(t *testing.T) {
+	type dto struct {
+		ID   int    `param:"id" json:"id"`
+		Name string `json:"name"`
+	}
+	ctx := newBindContext("PUT", "/users/42", `{"id":7,"name":"bob"}`)
+
+	var d dto
+	if err := ctx.Bind(&d); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if d.ID != 42 || d.Name != "bob" {
+		t.Errorf("dto = %+v, want the path id with the body name", d)
+	}
+}
+
+func TestContext_BindConversionErrors(t *testing.T) {
+	ctx := newBindContext("GET", "/users/42?page=two&notify=maybe&ids=1&ids=x&since=yesterday", "")
+
+	var dto bindingUpdate
+	var validationErrors ValidationErrors
+	if err := ctx.Bind(&dto); !errors.As(err, &validationErrors) {
+		t.Fatalf("Bind() error = %v, want ValidationErrors", err)
+	}
+
+	expected := map[string]string{
+		"page":   "page must be an integer",
+		"notify": "notify must be a boolean",
+		"ids":    "ids must be a list of integer values",
+		"since":  "since must be an RFC 3339 time",
+	}
+	if len(validationErrors) != len(expected) {
+		t.Fatalf("ValidationErrors = %v, want %d failures", validationErrors, len(expected))
+	}
+	for _, e := range validationErrors {
+		if expected[e.Field] != e.Message {
+			t.Errorf("Message for %s = %q, want %q", e.Field, e.Message, expected[e.Field])
+		}
+	}
+}
+
+func TestContext_BindBodyErrors(t *testing.T) {
+	ctx := newBindContext("PUT", "/users/42", `{"name":`)
+
+	var dto bindingUpdate
+	if err := ctx.Bind(&dto); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Bind() error = %v, want a 400 exception", err)
+	}
+}
+
+func TestBody_MaxBytes(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"json", MIMEApplicationJSON, `{"name":"` + strings.Repeat("a", 64) + `"}`},
+		{"form", MIMEApplicationForm, "name=" + strings.Repeat("a", 64)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := newBindingContext(test.contentType, strings.NewReader(test.body))
+			ctx.SetBodyOptions(BodyOptions{MaxBytes: 16})
+
+			var signup bindingSignup
+			err := ctx.Body(&signup)
+			if !errors.Is(err, ErrPayloadTooLarge) {
+				t.Fatalf("Body() error = %v, want a 413 exception", err)
+			}
+		})
+	}
+
+	ctx := newBindingContext(MIMEApplicationJSON, strings.NewReader(`{"Name":"al"}`))
+	ctx.SetBodyOptions(BodyOptions{MaxBytes: 64})
+	var signup bindingSignup
+	if err := ctx.Body(&signup); err != nil || signup.Name != "al" {
+		t.Errorf("Body() = %v, %+v, want a body within the limit to bind", err, signup)
+	}
+}
+
+func TestBody_MaxBytesMultipart(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, _ := mw.CreateFormFile("avatar", "me.png")
+	_, _ = part.Write(bytes.Repeat([]byte("x"), 1024))
+	_ = mw.Close()
+
+	ctx := newBindingContext(mw.FormDataContentType(), &buf)
+	ctx.SetBodyOptions(BodyOptions{MaxBytes: 256})
+
+	if _, err := ctx.MultipartForm(); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf("MultipartForm() error = %v, want a 413 exception", err)
+	}
+}
+
+func TestBody_StrictJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		strict  bool
+		wantErr bool
+	}{
+		{"lenient unknown field", `{"Name":"al","extra":1}`, false, false},
+		{"lenient trailing value", `{"Name":"al"} {"Name":"bo"}`, false, false},
+		{"strict valid", `{"Name":"al"}` + "\n", true, false},
+		{"strict unknown field", `{"Name":"al","extra":1}`, true, true},
+		{"strict trailing value", `{"Name":"al"} {"Name":"bo"}`, true, true},
+		{"strict trailing garbage", `{"Name":"al"} x`, true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := newBindingContext(MIMEApplicationJSON, strings.NewReader(test.body))
+			ctx.SetBodyOptions(BodyOptions{StrictJSON: test.strict})
+
+			var signup bindingSignup
+			err := ctx.Body(&signup)
+			if test.wantErr && !errors.Is(err, ErrBadRequest) {
+				t.Errorf("Body() error = %v, want a 400 exception", err)
+			}
+			if !test.wantErr && err != nil {
+				t.Errorf("Body() error = %v", err)
+			}
+		})
+	}
+}
@@ -3,8 +3,9 @@
 package core
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -48,10 +49,34 @@ type AppContext struct {
 	// written indicates if the response has been written
 	written bool
 
+	// json encodes JSON responses and decodes JSON bodies; nil means a zero StdJSONCodec
+	json JSONCodec
+
+	// body holds the options applied to the request body with SetBodyOptions
+	body BodyOptions
+
+	// requestID is the ID set with SetRequestID, added to the context.Context by Context
+	requestID string
+
+	// proxies lists the proxies whose forwarding headers are trusted
+	proxies TrustedProxies
+
+	// proxyHeaders selects the forwarding headers read from trusted proxies
+	proxyHeaders ProxyHeaders
+
+	// filters handle the errors passed to HandleError, before DefaultFilter
+	filters []Filter
+
+	// logger is the application logger set with SetLogger
+	logger Logger
+
 	// mu protects concurrent access to the context
 	mu sync.RWMutex
 }
 
+// Ensure AppContext implements ResponseContext.
+var _ ResponseContext = (*AppContext)(nil)
+
 // NewContext creates a new AppContext instance.
 // The router typically calls this for each incoming request.
 func NewContext(w http.ResponseWriter, r *http.Request) *AppContext {
@@ -77,6 +102,14 @@ func (c *AppContext) Response() http.ResponseWriter {
 	return c.response
 }
 
+// SetResponse replaces the response writer, typically with one wrapping the
+// current writer to observe or control the response. Wrappers should
+// implement Unwrap() http.ResponseWriter so that flushing and hijacking keep
+// working.
+func (c *AppContext) SetResponse(w http.ResponseWriter) {
+	c.response = w
+}
+
 // Param returns the value of a URL path parameter by name.
 // Returns an empty string if the parameter doesn't exist.
 //
@@ -129,8 +162,13 @@ func (c *AppContext) QueryArray(name string) []string {
 	return c.request.URL.Query()[name]
 }
 
-// Body decodes the request body into the provided interface.
-// Currently, supports JSON decoding.
+// Body decodes the request body into the provided interface using the
+// binder registered for its Content-Type: JSON, XML, URL-encoded and
+// multipart forms are supported out of the box, see RegisterBinder.
+//
+// It returns a 415 exception when no binder handles the content type, a 413
+// exception when the body exceeds its size limit and a 400 exception when the
+// body cannot be decoded.
 //
 // Example:
 //
@@ -140,19 +178,137 @@ func (c *AppContext) QueryArray(name string) []string {
 //	}
 func (c *AppContext) Body(v interface{}) error {
 	if c.request.Body == nil {
-		return fmt.Errorf("request body is empty")
+		return BadRequest("Request body is empty")
 	}
 
 	contentType := c.GetHeader("Content-Type")
-	if strings.Contains(contentType, "application/json") {
-		decoder := json.NewDecoder(c.request.Body)
-		return decoder.Decode(v)
+	binder, ok := LookupBinder(contentType)
+	if !ok {
+		return UnsupportedMediaType("Unsupported content type %q", contentType)
 	}
+	var err error
+	if b, ok := binder.(bodyBinder); ok {
+		err = b(c.request, v, c.body, c.jsonCodec())
+	} else {
+		err = binder.Bind(c.requestWithOptions(), v)
+	}
+	if err != nil {
+		return bodyError(err)
+	}
+	return nil
+}
 
-	return fmt.Errorf("unsupported content type: %s", contentType)
+// requestWithOptions returns a copy of the request whose context.Context
+// carries the body options and the JSON codec, for custom binders reading
+// them with BodyOptionsFrom and JSONCodecFrom.
+func (c *AppContext) requestWithOptions() *http.Request {
+	ctx := context.WithValue(c.request.Context(), bodyOptionsKey{}, c.body)
+	return c.request.WithContext(context.WithValue(ctx, jsonCodecKey{}, c.jsonCodec()))
+}
+
+// SetBodyOptions applies body options to the request. The router calls it
+// with the options of the application and the matched route before the
+// handler chain runs.
+func (c *AppContext) SetBodyOptions(opts BodyOptions) {
+	if opts.MaxBytes > 0 && c.request.Body != nil {
+		c.request.Body = http.MaxBytesReader(c.response, c.request.Body, opts.MaxBytes)
+	}
+	c.body = opts
+}
+
+// SetJSONCodec sets the codec encoding JSON responses and decoding JSON
+// request bodies. The router calls it with the codec of the application.
+func (c *AppContext) SetJSONCodec(codec JSONCodec) {
+	c.json = codec
+}
+
+// SetLogger sets the application logger, returned by LoggerFrom. The router
+// calls it with the logger of the application.
+func (c *AppContext) SetLogger(log Logger) {
+	c.logger = log
+}
+
+// LoggerFrom returns the application logger set on ctx with
+// AppContext.SetLogger, or nil. Middleware use it to log through the
+// application logger by default.
+func LoggerFrom(ctx Context) Logger {
+	if c, ok := ctx.(*AppContext); ok {
+		return c.logger
+	}
+	return nil
+}
+
+// jsonCodec returns the codec set with SetJSONCodec, or a zero StdJSONCodec.
+func (c *AppContext) jsonCodec() JSONCodec {
+	if c.json == nil {
+		return StdJSONCodec{}
+	}
+	return c.json
+}
+
+// bodyError converts an error reading the request body into an HTTPException.
+func bodyError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return PayloadTooLarge("Request body exceeds %d bytes", tooLarge.Limit).WithCause(err)
+	}
+	return BadRequest("Invalid request body").WithCause(err)
+}
+
+// Bind fills the struct pointed to by v from the whole request. The body, if
+// any, is decoded first as by Body; fields tagged `param:"id"`,
+// `query:"page"` or `header:"X-Tenant"` are then set from the path
+// parameters, query string and headers, taking precedence over the body.
+// Values are converted to the field type, including pointers, slices,
+// time.Time and time.Duration, and conversion failures are reported as
+// ValidationErrors.
+//
+// Example:
+//
+//	type UpdateUser struct {
+//	    ID     int    `param:"id" json:"-"`
+//	    Tenant string `header:"X-Tenant" json:"-"`
+//	    Notify *bool  `query:"notify" json:"-"`
+//	    Name   string `json:"name"`
+//	}
+//
+//	var dto UpdateUser
+//	if err := c.Bind(&dto); err != nil {
+//	    return err
+//	}
+func (c *AppContext) Bind(v interface{}) error {
+	if c.hasBody() {
+		if err := c.Body(v); err != nil {
+			return err
+		}
+	}
+	return bindRequest(c, v)
 }
 
-// JSON writes a JSON response with the specified status code.
+// hasBody reports whether the request carries a body.
+func (c *AppContext) hasBody() bool {
+	return c.request.Body != nil && c.request.Body != http.NoBody && c.request.ContentLength != 0
+}
+
+// BindAndValidate binds the request body to v and validates it against its
+// `validate` struct tags. Binding failures are reported as by Body and
+// invalid fields as ValidationErrors.
+//
+// Example:
+//
+//	var dto CreateUserDTO
+//	if err := c.BindAndValidate(&dto); err != nil {
+//	    return err
+//	}
+func (c *AppContext) BindAndValidate(v interface{}) error {
+	if err := c.Body(v); err != nil {
+		return err
+	}
+	return Validate(v)
+}
+
+// JSON writes a JSON response with the specified status code, encoded with
+// the codec set by SetJSONCodec.
 // Automatically sets the Content-Type header to application/json.
 //
 // Example:
@@ -165,8 +321,7 @@ func (c *AppContext) JSON(statusCode int, data interface{}) error {
 	c.Status(statusCode)
 	c.writeHeaderOnce()
 
-	encoder := json.NewEncoder(c.response)
-	if err := encoder.Encode(data); err != nil {
+	if err := c.jsonCodec().Encode(c.response, data); err != nil {
 		return fmt.Errorf("failed to encode JSON: %w", err)
 	}
 
@@ -234,6 +389,35 @@ func (c *AppContext) Data(statusCode int, contentType string, data []byte) error
 	return nil
 }
 
+// Negotiate writes data in the format the Accept header prefers among the
+// registered renderers: JSON, XML, YAML, plain text and MessagePack by
+// default. A missing Accept header selects JSON. When no renderer is
+// acceptable nothing is written and a 406 exception is returned.
+//
+// Example:
+//
+//	return c.Negotiate(200, user)
+func (c *AppContext) Negotiate(statusCode int, data interface{}) error {
+	c.response.Header().Add("Vary", "Accept")
+
+	mediaType, renderer, offers, ok := negotiateRenderer(c.GetHeader("Accept"))
+	if !ok {
+		return NotAcceptable("Acceptable media types: %s", strings.Join(offers, ", ")).WithDetails(offers)
+	}
+	if _, ok := renderer.(jsonRenderer); ok {
+		return c.JSON(statusCode, data)
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.Render(&buf, data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", mediaType, err)
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		mediaType += "; charset=utf-8"
+	}
+	return c.Data(statusCode, mediaType, buf.Bytes())
+}
+
 // NoContent sends a response with no body content.
 // Commonly used for DELETE operations or 204 responses.
 //
@@ -344,40 +528,6 @@ func (c *AppContext) URL() *url.URL {
 	return c.request.URL
 }
 
-// Host returns the host from the request.
-//
-// Example:
-//
-//	c.Host() might return "example.com:8080"
-func (c *AppContext) Host() string {
-	return c.request.Host
-}
-
-// ClientIP attempts to get the real client IP address.
-// It checks X-Forwarded-For, X-Real-IP headers and falls back to RemoteAddr.
-func (c *AppContext) ClientIP() string {
-	// Check X-Forwarded-For header
-	if ip := c.GetHeader("X-Forwarded-For"); ip != "" {
-		// Take the first IP in the list
-		if idx := strings.Index(ip, ","); idx != -1 {
-			return strings.TrimSpace(ip[:idx])
-		}
-		return strings.TrimSpace(ip)
-	}
-
-	// Check X-Real-IP header
-	if ip := c.GetHeader("X-Real-IP"); ip != "" {
-		return strings.TrimSpace(ip)
-	}
-
-	// Fall back to RemoteAddr
-	if idx := strings.LastIndex(c.request.RemoteAddr, ":"); idx != -1 {
-		return c.request.RemoteAddr[:idx]
-	}
-
-	return c.request.RemoteAddr
-}
-
 // UserAgent returns the User-Agent header value.
 func (c *AppContext) UserAgent() string {
 	return c.GetHeader("User-Agent")
@@ -404,8 +554,8 @@ func (c *AppContext) FormFile(name string) (multipart.File, *multipart.FileHeade
 
 // MultipartForm returns the parsed multipart form, including file uploads.
 func (c *AppContext) MultipartForm() (*multipart.Form, error) {
-	if err := c.request.ParseMultipartForm(32 << 20); err != nil { // 32 MB
-		return nil, err
+	if err := c.request.ParseMultipartForm(multipartMemoryFor(c.body)); err != nil {
+		return nil, bodyError(err)
 	}
 	return c.request.MultipartForm, nil
 }
@@ -434,9 +584,10 @@ func (c *AppContext) SetCookie(cookie *http.Cookie) Context {
 	return c
 }
 
-// IsWebSocket checks if the request is a WebSocket upgrade request.
+// IsWebSocket checks if the request is a WebSocket upgrade request, whose
+// Upgrade header lists the websocket protocol.
 func (c *AppContext) IsWebSocket() bool {
-	return c.GetHeader("Upgrade") == "websocket"
+	return headerHasToken(c.request.Header, "Upgrade", "websocket")
 }
 
 // IsAjax checks if the request is an AJAX request.
@@ -445,7 +596,9 @@ func (c *AppContext) IsAjax() bool {
 	return c.GetHeader("X-Requested-With") == "XMLHttpRequest"
 }
 
-// Accepts checks if the client accepts the specified content type.
+// Accepts reports whether the Accept header gives the specified content type
+// a non-zero quality, honoring wildcards and q-values. A request without an
+// Accept header accepts any type.
 //
 // Example:
 //
@@ -454,7 +607,11 @@ func (c *AppContext) IsAjax() bool {
 //	}
 func (c *AppContext) Accepts(contentType string) bool {
 	accept := c.GetHeader("Accept")
-	return strings.Contains(accept, contentType) || strings.Contains(accept, "*/*")
+	if strings.TrimSpace(accept) == "" {
+		return true
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return acceptQuality(ParseAccept(accept), strings.TrimSpace(mediaType)) > 0
 }
 
 // Next executes the next handler in the middleware chain.
@@ -494,6 +651,13 @@ func (c *AppContext) Reset(w http.ResponseWriter, r *http.Request) {
 	c.headerWritten = false
 	c.written = false
 	c.index = -1
+	c.json = nil
+	c.body = BodyOptions{}
+	c.requestID = ""
+	c.proxies = nil
+	c.proxyHeaders = XForwardedHeaders
+	c.filters = nil
+	c.logger = nil
 
 	// Clear maps
 	for k := range c.params {
@@ -512,9 +676,14 @@ func (c *AppContext) IsWritten() bool {
 	return c.written
 }
 
-// Context returns the request's context.Context for cancellation and deadlines.
+// Context returns the request's context.Context for cancellation and
+// deadlines. It carries the request ID set with SetRequestID.
 func (c *AppContext) Context() context.Context {
-	return c.request.Context()
+	ctx := c.request.Context()
+	if c.requestID != "" && RequestIDFromContext(ctx) != c.requestID {
+		return ContextWithRequestID(ctx, c.requestID)
+	}
+	return ctx
 }
 
 // WithContext returns a shallow copy of the AppContext with a new context.Context.
@@ -549,6 +718,13 @@ func (c *AppContext) WithContext(ctx context.Context) Context {
 		params:        paramsCopy,
 		values:        valuesCopy,
 		handlers:      handlersCopy,
+		json:          c.json,
+		body:          c.body,
+		requestID:     c.requestID,
+		proxies:       c.proxies,
+		proxyHeaders:  c.proxyHeaders,
+		filters:       c.filters,
+		logger:        c.logger,
 	}
 }
 
@@ -561,30 +737,52 @@ func (c *AppContext) Write(data []byte) (int, error) {
 }
 
 // Stream sends a streaming response.
-// The step function is called repeatedly until it returns io.EOF or an error.
+// The step function is called repeatedly until it returns io.EOF or an
+// error, or the request is cancelled. The response is flushed after each
+// call so the client receives every chunk as soon as it is written.
 //
 // Example:
 //
-//	return c.Stream(200, "text/event-stream", func(w io.Writer) error {
-//	    for i := 0; i < 10; i++ {
-//	        fmt.Fprintf(w, "data: %d\n\n", i)
-//	        time.Sleep(time.Second)
+//	i := 0
+//	return c.Stream(200, "text/plain", func(w io.Writer) error {
+//	    if i == 10 {
+//	        return io.EOF
 //	    }
-//	    return io.EOF
+//	    fmt.Fprintf(w, "tick %d\n", i)
+//	    i++
+//	    time.Sleep(time.Second)
+//	    return nil
 //	})
 func (c *AppContext) Stream(statusCode int, contentType string, step func(io.Writer) error) error {
 	c.SetHeader("Content-Type", contentType)
-	c.SetHeader("Transfer-Encoding", "chunked")
 	c.Status(statusCode)
 
 	c.writeHeaderOnce()
 	c.written = true
 
-	if err := step(c.response); err != nil && err != io.EOF {
-		return fmt.Errorf("streaming error: %w", err)
+	done := c.Context().Done()
+	for {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+
+		err := step(c.response)
+		c.flush()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("streaming error: %w", err)
+		}
 	}
+}
 
-	return nil
+// flush sends buffered response data to the client, if the response writer
+// or one it wraps supports flushing.
+func (c *AppContext) flush() {
+	_ = http.NewResponseController(c.response).Flush()
 }
 
 // Err returns any error stored in the request context.
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -158,8 +159,8 @@ func TestContext_Body(t *testing.T) {
 		ctx := NewContext(w, r)
 
 		var user User
-		if err := ctx.Body(&user); err == nil {
-			t.Error("Body() should return error for unsupported content type")
+		if err := ctx.Body(&user); !errors.Is(err, ErrUnsupportedMediaType) {
+			t.Errorf("Body() error = %v, want a 415 exception for unsupported content type", err)
 		}
 	})
 
@@ -487,32 +488,94 @@ func TestContext_Host(t *testing.T) {
 }
 
 func TestContext_ClientIP(t *testing.T) {
+	// httptest requests come from 192.0.2.1:1234.
+	trusted := MustParseTrustedProxies("192.0.2.0/24", "10.0.0.0/8")
+
 	tests := []struct {
 		name       string
+		proxies    TrustedProxies
+		headers    ProxyHeaders
 		setupFunc  func(*http.Request)
 		expectedIP string
 	}{
 		{
-			name: "X-Forwarded-For single IP",
+			name:    "X-Forwarded-For single IP",
+			proxies: trusted,
 			setupFunc: func(r *http.Request) {
 				r.Header.Set("X-Forwarded-For", "203.0.113.1")
 			},
 			expectedIP: "203.0.113.1",
 		},
 		{
-			name: "X-Forwarded-For multiple IPs",
+			name:    "X-Forwarded-For multiple IPs",
+			proxies: trusted,
 			setupFunc: func(r *http.Request) {
 				r.Header.Set("X-Forwarded-For", "203.0.113.1, 198.51.100.1")
 			},
+			expectedIP: "198.51.100.1",
+		},
+		{
+			name:    "X-Forwarded-For skips trusted hops",
+			proxies: trusted,
+			setupFunc: func(r *http.Request) {
+				r.Header.Add("X-Forwarded-For", "1.2.3.4, 203.0.113.1")
+				r.Header.Add("X-Forwarded-For", "10.0.0.2")
+			},
 			expectedIP: "203.0.113.1",
 		},
 		{
-			name: "X-Real-IP",
+			name:    "X-Forwarded-For from untrusted peer",
+			proxies: nil,
+			setupFunc: func(r *http.Request) {
+				r.Header.Set("X-Forwarded-For", "203.0.113.1")
+			},
+			expectedIP: "192.0.2.1",
+		},
+		{
+			name:    "X-Forwarded-For invalid hop",
+			proxies: trusted,
+			setupFunc: func(r *http.Request) {
+				r.Header.Set("X-Forwarded-For", "203.0.113.1, garbage, 10.0.0.3")
+			},
+			expectedIP: "10.0.0.3",
+		},
+		{
+			name:    "X-Real-IP",
+			proxies: trusted,
 			setupFunc: func(r *http.Request) {
 				r.Header.Set("X-Real-IP", "198.51.100.1")
 			},
 			expectedIP: "198.51.100.1",
 		},
+		{
+			name:    "X-Forwarded-For ignores Forwarded",
+			proxies: trusted,
+			setupFunc: func(r *http.Request) {
+				r.RemoteAddr = "10.0.0.1:1234"
+				r.Header.Set("X-Forwarded-For", "203.0.113.9")
+				r.Header.Set("Forwarded", "for=1.2.3.4;host=evil.example;proto=https")
+			},
+			expectedIP: "203.0.113.9",
+		},
+		{
+			name:    "Forwarded",
+			proxies: trusted,
+			headers: ForwardedHeader,
+			setupFunc: func(r *http.Request) {
+				r.Header.Set("Forwarded", `for="[2001:db8::17]:4711";proto=https, for=10.0.0.9`)
+				r.Header.Set("X-Forwarded-For", "198.51.100.1")
+			},
+			expectedIP: "2001:db8::17",
+		},
+		{
+			name:    "Forwarded obfuscated client",
+			proxies: trusted,
+			headers: ForwardedHeader,
+			setupFunc: func(r *http.Request) {
+				r.Header.Set("Forwarded", "for=_hidden, for=10.0.0.9")
+			},
+			expectedIP: "10.0.0.9",
+		},
 		{
 			name: "RemoteAddr fallback",
 			setupFunc: func(r *http.Request) {
@@ -520,6 +583,20 @@ func TestContext_ClientIP(t *testing.T) {
 			},
 			expectedIP: "192.0.2.1",
 		},
+		{
+			name: "IPv6 RemoteAddr",
+			setupFunc: func(r *http.Request) {
+				r.RemoteAddr = "[2001:db8::1]:8080"
+			},
+			expectedIP: "2001:db8::1",
+		},
+		{
+			name: "IPv4-mapped RemoteAddr",
+			setupFunc: func(r *http.Request) {
+				r.RemoteAddr = "[::ffff:192.0.2.7]:8080"
+			},
+			expectedIP: "192.0.2.7",
+		},
 	}
 
 	for _, tt := range tests {
@@ -528,6 +605,8 @@ func TestContext_ClientIP(t *testing.T) {
 			r := httptest.NewRequest("GET", "/test", nil)
 			tt.setupFunc(r)
 			ctx := NewContext(w, r)
+			ctx.SetTrustedProxies(tt.proxies)
+			ctx.SetProxyHeaders(tt.headers)
 
 			if got := ctx.ClientIP(); got != tt.expectedIP {
 				t.Errorf("ClientIP() = %v, want %v", got, tt.expectedIP)
@@ -536,6 +615,50 @@ func TestContext_ClientIP(t *testing.T) {
 	}
 }
 
+func TestContext_SchemeAndHost(t *testing.T) {
+	trusted := MustParseTrustedProxies("192.0.2.1")
+	spoofed := "for=1.2.3.4;host=evil.example;proto=https"
+
+	tests := []struct {
+		name    string
+		proxies TrustedProxies
+		headers ProxyHeaders
+		header  http.Header
+		scheme  string
+		host    string
+	}{
+		{"direct", nil, XForwardedHeaders, nil, "http", "example.com"},
+		{"untrusted headers", nil, XForwardedHeaders, http.Header{"X-Forwarded-Proto": {"https"}, "X-Forwarded-Host": {"evil.example"}}, "http", "example.com"},
+		{"X-Forwarded headers", trusted, XForwardedHeaders, http.Header{"X-Forwarded-Proto": {"HTTPS"}, "X-Forwarded-Host": {"api.example"}}, "https", "api.example"},
+		{"X-Forwarded client entries", trusted, XForwardedHeaders, http.Header{"X-Forwarded-For": {"203.0.113.9"}, "X-Forwarded-Proto": {"https, http"}, "X-Forwarded-Host": {"evil.example, api.example"}}, "http", "api.example"},
+		{"X-Forwarded ignores Forwarded", trusted, XForwardedHeaders, http.Header{"X-Forwarded-For": {"203.0.113.9"}, "Forwarded": {spoofed}}, "http", "example.com"},
+		{"Forwarded", trusted, ForwardedHeader, http.Header{"Forwarded": {`for=1.2.3.4;proto=https;host="shop.example:8443"`}, "X-Forwarded-Host": {"api.example"}}, "https", "shop.example:8443"},
+		{"Forwarded client element", trusted, ForwardedHeader, http.Header{"Forwarded": {spoofed + ", for=203.0.113.9;proto=http;host=shop.example"}}, "http", "shop.example"},
+		{"invalid X-Forwarded-Proto", trusted, XForwardedHeaders, http.Header{"X-Forwarded-Proto": {"javascript"}, "X-Forwarded-Host": {"api.example"}}, "http", "api.example"},
+		{"invalid Forwarded proto", trusted, ForwardedHeader, http.Header{"Forwarded": {`for=1.2.3.4;proto="https://evil.example/x";host=shop.example`}}, "http", "shop.example"},
+		{"Forwarded through trusted hops", MustParseTrustedProxies("192.0.2.1", "10.0.0.0/8"), ForwardedHeader, http.Header{"Forwarded": {"for=203.0.113.9;proto=https;host=shop.example, for=10.0.0.2;proto=http;host=internal"}}, "https", "shop.example"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://example.com/test", nil)
+			for key, values := range tt.header {
+				r.Header[key] = values
+			}
+			ctx := NewContext(httptest.NewRecorder(), r)
+			ctx.SetTrustedProxies(tt.proxies)
+			ctx.SetProxyHeaders(tt.headers)
+
+			if got := ctx.Scheme(); got != tt.scheme {
+				t.Errorf("Scheme() = %v, want %v", got, tt.scheme)
+			}
+			if got := ctx.Host(); got != tt.host {
+				t.Errorf("Host() = %v, want %v", got, tt.host)
+			}
+		})
+	}
+}
+
 func TestContext_UserAgent(t *testing.T) {
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest("GET", "/test", nil)
@@ -750,6 +873,11 @@ func TestContext_Accepts(t *testing.T) {
 		{"Wildcard accepted", "*/*", "text/html", true},
 		{"Not accepted", "application/json", "text/html", false},
 		{"Multiple types", "text/html, application/json", "application/json", true},
+		{"No header", "", "text/html", true},
+		{"Type wildcard", "text/*", "text/html", true},
+		{"Refused with q=0", "text/html;q=0, */*", "text/html", false},
+		{"Not a substring match", "application/json-seq", "application/json", false},
+		{"Parameters ignored", "application/json", "application/json; charset=utf-8", true},
 	}
 
 	for _, tt := range tests {
@@ -914,11 +1042,13 @@ func TestContext_Stream(t *testing.T) {
 	r := httptest.NewRequest("GET", "/stream", nil)
 	ctx := NewContext(w, r)
 
+	i := 0
 	step := func(w io.Writer) error {
-		var err error
-		for i := 0; i < 4; i++ {
-			_, err = w.Write([]byte(fmt.Sprintf("chunk%d,", i)))
+		if i == 4 {
+			return io.EOF
 		}
+		_, err := w.Write([]byte(fmt.Sprintf("chunk%d,", i)))
+		i++
 		return err
 	}
 
@@ -939,6 +1069,35 @@ func TestContext_Stream(t *testing.T) {
 	if !ctx.IsWritten() {
 		t.Error("IsWritten() should be true after Stream()")
 	}
+	if !w.Flushed {
+		t.Error("Stream() should flush the response")
+	}
+}
+
+func TestContext_StreamStops(t *testing.T) {
+	t.Run("step error", func(t *testing.T) {
+		ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/stream", nil))
+		boom := errors.New("boom")
+		if err := ctx.Stream(200, "text/plain", func(io.Writer) error { return boom }); !errors.Is(err, boom) {
+			t.Errorf("Stream() error = %v, want %v", err, boom)
+		}
+	})
+
+	t.Run("cancelled request", func(t *testing.T) {
+		reqCtx, cancel := context.WithCancel(context.Background())
+		r := httptest.NewRequest("GET", "/stream", nil).WithContext(reqCtx)
+		ctx := NewContext(httptest.NewRecorder(), r)
+
+		calls := 0
+		err := ctx.Stream(200, "text/plain", func(io.Writer) error {
+			calls++
+			cancel()
+			return nil
+		})
+		if err != nil || calls != 1 {
+			t.Errorf("Stream() = %v after %d calls, want nil after 1 call", err, calls)
+		}
+	})
 }
 
 func TestContext_Err(t *testing.T) {
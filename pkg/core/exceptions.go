@@ -0,0 +1,219 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HTTPException is an error carrying the HTTP status code it should be reported with.
+// Handlers return it to produce a specific error response through the exception filters.
+//
+// Exceptions match the sentinel of their status with errors.Is and can wrap
+// the error that caused them, which stays reachable through errors.Is and
+// errors.As but is never exposed to clients.
+//
+// Example:
+//
+//	user, err := repo.Find(id)
+//	if errors.Is(err, sql.ErrNoRows) {
+//	    return core.NotFound("user %s not found", id).WithCode("USER_NOT_FOUND")
+//	}
+//	if err != nil {
+//	    return core.Internal("loading user %s", id).WithCause(err)
+//	}
+//
+// Callers can then test the error class:
+//
+//	if errors.Is(err, core.ErrNotFound) { ... }
+type HTTPException struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+	// Message is the client-facing error message
+	Message string
+	// Code is a stable, machine-readable error code (e.g. "NOT_FOUND")
+	Code string
+	// Details is optional structured data describing the error
+	Details interface{}
+
+	cause error
+}
+
+// Sentinel exceptions for use with errors.Is. They match any HTTPException
+// with the same status code.
+var (
+	ErrBadRequest           = NewHTTPException(http.StatusBadRequest, "")
+	ErrUnauthorized         = NewHTTPException(http.StatusUnauthorized, "")
+	ErrForbidden            = NewHTTPException(http.StatusForbidden, "")
+	ErrNotFound             = NewHTTPException(http.StatusNotFound, "")
+	ErrMethodNotAllowed     = NewHTTPException(http.StatusMethodNotAllowed, "")
+	ErrNotAcceptable        = NewHTTPException(http.StatusNotAcceptable, "")
+	ErrConflict             = NewHTTPException(http.StatusConflict, "")
+	ErrPayloadTooLarge      = NewHTTPException(http.StatusRequestEntityTooLarge, "")
+	ErrUnsupportedMediaType = NewHTTPException(http.StatusUnsupportedMediaType, "")
+	ErrUnprocessableEntity  = NewHTTPException(http.StatusUnprocessableEntity, "")
+	ErrTooManyRequests      = NewHTTPException(http.StatusTooManyRequests, "")
+	ErrInternal             = NewHTTPException(http.StatusInternalServerError, "")
+	ErrServiceUnavailable   = NewHTTPException(http.StatusServiceUnavailable, "")
+	ErrGatewayTimeout       = NewHTTPException(http.StatusGatewayTimeout, "")
+)
+
+// NewHTTPException creates an HTTPException with the given status code and message.
+// An empty message defaults to the status text, and the code is derived from
+// the status text (e.g. "UNPROCESSABLE_ENTITY").
+func NewHTTPException(statusCode int, message string) *HTTPException {
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	return &HTTPException{
+		StatusCode: statusCode,
+		Message:    message,
+		Code:       codeFor(statusCode),
+	}
+}
+
+// Error implements the error interface. The message of the cause, if any, is appended.
+func (e *HTTPException) Error() string {
+	msg := fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	if e.cause != nil {
+		msg += ": " + e.cause.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the error that caused the exception, if any.
+func (e *HTTPException) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is an HTTPException with the same status code.
+// A target carrying a code other than the default one of its status must
+// match that code as well.
+func (e *HTTPException) Is(target error) bool {
+	t, ok := target.(*HTTPException)
+	if !ok || t.StatusCode != e.StatusCode {
+		return false
+	}
+	return t.Code == codeFor(t.StatusCode) || t.Code == e.Code
+}
+
+// WithCode returns a copy of the exception with the given error code.
+func (e *HTTPException) WithCode(code string) *HTTPException {
+	c := *e
+	c.Code = code
+	return &c
+}
+
+// WithDetails returns a copy of the exception with the given details.
+func (e *HTTPException) WithDetails(details interface{}) *HTTPException {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// WithCause returns a copy of the exception wrapping cause.
+func (e *HTTPException) WithCause(cause error) *HTTPException {
+	c := *e
+	c.cause = cause
+	return &c
+}
+
+// BadRequest creates a 400 Bad Request exception.
+func BadRequest(format string, args ...interface{}) *HTTPException {
+	return NewHTTPException(http.StatusBadRequest, fmt.Sprintf(format, args...))
+}
+
+// Unauthorized creates a 401 Unauthorized exception.
+func Unauthorized(format string, args ...interface{}) *HTTPException {
+	return NewHTTPException(http.StatusUnauthorized, fmt.Sprintf(format, args...))
+}
+
+// Forbidden creates a 403 Forbidden exception.
+func Forbidden(format string, args ...interface{}) *HTTPException {
+	return NewHTTPException(http.StatusForbidden, fmt.Sprintf(format, args...))
+}
+
+// NotFound creates a 404 Not Found exception.
+func NotFound(format string, args ...interface{}) *HTTPException {
+	return NewHTTPException(http.StatusNotFound, fmt.Sprintf(format, args...))
+}
+
+// MethodNotAllowed creates a 405 Method Not Allowed exception.
+func MethodNotAllowed(format string, args ...interface{}) *HTTPException {
+	return NewHTTPException(http.StatusMethodNotAllowed, fmt.Sprintf(format, args...))
+}
+
+// NotAcceptable creates a 406 Not Acceptable exception.
+func NotAcceptable(format string, args ...interface{}) *HTTPException {
+	return NewHTTPException(http.StatusNotAcceptable, fmt.Sprintf(format, args...))
+}
+
+// Conflict creates a 409 Conflict exception.
+func Conflict(format string, args ...interface{}) *HTTPException {
+	return NewHTTPException(http.StatusConflict, fmt.Sprintf(format, args...))
+}
+
+// PayloadTooLarge creates a 413 Request Entity Too Large exception.
+func PayloadTooLarge(format string, args ...interface{}) *HTTPException {
+	return NewHTTPException(http.StatusRequestEntityTooLarge, fmt.Sprintf(format, args...))
+}
+
+// UnsupportedMediaType creates a 415 Unsupported Media Type exception.
+func UnsupportedMediaType(format string, args ...interface{}) *HTTPException {
+	return NewHTTPException(http.StatusUnsupportedMediaType, fmt.Sprintf(format, args...))
+}
+
+// UnprocessableEntity creates a 422 Unprocessable Entity exception.
+func UnprocessableEntity(format string, args ...interface{}) *HTTPException {
+	return NewHTTPException(http.StatusUnprocessableEntity, fmt.Sprintf(format, args...))
+}
+
+// TooManyRequests creates a 429 Too Many Requests exception.
+func TooManyRequests(format string, args ...interface{}) *HTTPException {
+	return NewHTTPException(http.StatusTooManyRequests, fmt.Sprintf(format, args...))
+}
+
+// Internal creates a 500 Internal Server Error exception. Its message is sent
+// to the client, so the underlying error should be attached with WithCause
+// rather than formatted into the message.
+func Internal(format string, args ...interface{}) *HTTPException {
+	return NewHTTPException(http.StatusInternalServerError, fmt.Sprintf(format, args...))
+}
+
+// ServiceUnavailable creates a 503 Service Unavailable exception.
+func ServiceUnavailable(format string, args ...interface{}) *HTTPException {
+	return NewHTTPException(http.StatusServiceUnavailable, fmt.Sprintf(format, args...))
+}
+
+// GatewayTimeout creates a 504 Gateway Timeout exception.
+func GatewayTimeout(format string, args ...interface{}) *HTTPException {
+	return NewHTTPException(http.StatusGatewayTimeout, fmt.Sprintf(format, args...))
+}
+
+// PanicError is the cause of the Internal exception a recovered panic is
+// converted to. It is never sent to clients, except in development through
+// the details of the exception.
+type PanicError struct {
+	// Value is the value passed to panic
+	Value interface{}
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// codeFor derives an error code from the status text,
+// e.g. 404 becomes "NOT_FOUND".
+func codeFor(statusCode int) string {
+	text := strings.ReplaceAll(http.StatusText(statusCode), "'", "")
+	return strings.ToUpper(strings.ReplaceAll(strings.ReplaceAll(text, "-", "_"), " ", "_"))
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPException_Constructors(t *testing.T) {
+	tests := []struct {
+		name       string
+		exception  *HTTPException
+		statusCode int
+		message    string
+	}{
+		{"BadRequest", BadRequest("invalid id %q", "x"), http.StatusBadRequest, `invalid id "x"`},
+		{"Forbidden", Forbidden("Forbidden resource"), http.StatusForbidden, "Forbidden resource"},
+		{"NotFound", NotFound("user %d not found", 7), http.StatusNotFound, "user 7 not found"},
+		{"MethodNotAllowed", MethodNotAllowed("Cannot PUT /"), http.StatusMethodNotAllowed, "Cannot PUT /"},
+		{"Conflict", Conflict("email taken"), http.StatusConflict, "email taken"},
+		{"Unauthorized", Unauthorized("missing token"), http.StatusUnauthorized, "missing token"},
+		{"UnprocessableEntity", UnprocessableEntity("invalid order"), http.StatusUnprocessableEntity, "invalid order"},
+		{"TooManyRequests", TooManyRequests("slow down"), http.StatusTooManyRequests, "slow down"},
+		{"Internal", Internal("loading user"), http.StatusInternalServerError, "loading user"},
+		{"ServiceUnavailable", ServiceUnavailable("draining"), http.StatusServiceUnavailable, "draining"},
+		{"GatewayTimeout", GatewayTimeout("upstream slow"), http.StatusGatewayTimeout, "upstream slow"},
+		{"empty message", NewHTTPException(http.StatusNotFound, ""), http.StatusNotFound, "Not Found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.exception.StatusCode != test.statusCode {
+				t.Errorf("StatusCode = %v, want %v", test.exception.StatusCode, test.statusCode)
+			}
+			if test.exception.Message != test.message {
+				t.Errorf("Message = %v, want %v", test.exception.Message, test.message)
+			}
+		})
+	}
+}
+
+func TestHTTPException_Error(t *testing.T) {
+	err := NotFound("user %d not found", 7)
+	if got := err.Error(); got != "404 Not Found: user 7 not found" {
+		t.Errorf("Error() = %v, want %v", got, "404 Not Found: user 7 not found")
+	}
+
+	var exception *HTTPException
+	if !errors.As(fmt.Errorf("loading user: %w", err), &exception) || exception != err {
+		t.Error("errors.As() should find a wrapped HTTPException")
+	}
+}
+
+func TestHTTPException_Code(t *testing.T) {
+	tests := []struct {
+		exception *HTTPException
+		expected  string
+	}{
+		{NotFound("x"), "NOT_FOUND"},
+		{UnprocessableEntity("x"), "UNPROCESSABLE_ENTITY"},
+		{NewHTTPException(http.StatusTeapot, ""), "IM_A_TEAPOT"},
+		{NewHTTPException(http.StatusNonAuthoritativeInfo, ""), "NON_AUTHORITATIVE_INFORMATION"},
+		{NotFound("x").WithCode("USER_NOT_FOUND"), "USER_NOT_FOUND"},
+	}
+
+	for _, test := range tests {
+		if test.exception.Code != test.expected {
+			t.Errorf("Code = %v, want %v", test.exception.Code, test.expected)
+		}
+	}
+}
+
+func TestHTTPException_Is(t *testing.T) {
+	err := fmt.Errorf("handler: %w", NotFound("user 7 not found").WithCode("USER_NOT_FOUND"))
+
+	tests := []struct {
+		name     string
+		target   error
+		expected bool
+	}{
+		{"same status sentinel", ErrNotFound, true},
+		{"other status sentinel", ErrConflict, false},
+		{"matching code", NotFound("").WithCode("USER_NOT_FOUND"), true},
+		{"other code", NotFound("").WithCode("ORDER_NOT_FOUND"), false},
+		{"plain error", errors.New("404"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := errors.Is(err, test.target); got != test.expected {
+				t.Errorf("errors.Is() = %v, want %v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestHTTPException_WithCause(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := Internal("loading user").WithCause(cause)
+
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is() should find the cause")
+	}
+	if !errors.Is(err, ErrInternal) {
+		t.Error("errors.Is() should match ErrInternal")
+	}
+	if got := err.Error(); got != "500 Internal Server Error: loading user: connection refused" {
+		t.Errorf("Error() = %v, want the cause appended", got)
+	}
+}
+
+func TestHTTPException_WithCopies(t *testing.T) {
+	base := ErrNotFound
+	derived := base.WithCode("USER_NOT_FOUND").WithDetails(map[string]string{"id": "7"})
+
+	if base.Code != "NOT_FOUND" || base.Details != nil {
+		t.Errorf("sentinel was modified: %+v", base)
+	}
+	if derived.Code != "USER_NOT_FOUND" || derived.Details == nil {
+		t.Errorf("derived exception = %+v, want the code and details set", derived)
+	}
+}
+
+func TestPanicError(t *testing.T) {
+	cause := errors.New("disk full")
+	tests := []struct {
+		name   string
+		value  interface{}
+		want   string
+		unwrap error
+	}{
+		{"string", "nil map", "panic: nil map", nil},
+		{"error", cause, "panic: disk full", cause},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &PanicError{Value: tt.value}
+			if err.Error() != tt.want {
+				t.Errorf("Error() = %q, want %q", err.Error(), tt.want)
+			}
+			if err.Unwrap() != tt.unwrap {
+				t.Errorf("Unwrap() = %v, want %v", err.Unwrap(), tt.unwrap)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// FilterFunc is an adapter to allow ordinary functions to be used as exception filters.
+//
+// A filter handles an error by writing a response and returning nil. To leave
+// the error to the next filter layer it returns the error, possibly replaced
+// by a different one.
+//
+// Example:
+//
+//	notFoundAsEmpty := core.FilterFunc(func(err error, ctx core.Context) error {
+//	    if errors.Is(err, sql.ErrNoRows) {
+//	        return core.NotFound("resource not found")
+//	    }
+//	    return err
+//	})
+type FilterFunc func(err error, ctx Context) error
+
+// Catch calls f(err, ctx).
+func (f FilterFunc) Catch(err error, ctx Context) error {
+	return f(err, ctx)
+}
+
+// DefaultFilter is the exception filter applied after route, controller and
+// global filters. It renders any error as an ErrorResponse:
+//
+//   - HTTPException uses its status code, message, code and details
+//   - ValidationErrors become a 400 response listing the invalid fields
+//   - any other error becomes a 500 response without exposing its message
+//
+// Validation errors wrapped by an HTTPException are listed as well, so
+// returning core.UnprocessableEntity("invalid order").WithCause(errs)
+// produces a 422 response with the invalid fields.
+type DefaultFilter struct{}
+
+// Catch renders err as an ErrorResponse, unless a response was already
+// written. The response format is negotiated from the Accept header and
+// falls back to JSON when no format is acceptable.
+func (f DefaultFilter) Catch(err error, ctx Context) error {
+	if ctx.IsWritten() {
+		return nil
+	}
+
+	response := NewErrorResponse(err, ctx)
+	if err := ctx.Negotiate(response.StatusCode, response); !errors.Is(err, ErrNotAcceptable) {
+		return err
+	}
+	return ctx.JSON(response.StatusCode, response)
+}
+
+// NewErrorResponse builds the ErrorResponse describing err for the request of ctx.
+// Custom filters can use it to render errors in the framework's standard format.
+func NewErrorResponse(err error, ctx Context) ErrorResponse {
+	response := ErrorResponse{
+		StatusCode: http.StatusInternalServerError,
+		Message:    "Internal server error",
+		Path:       ctx.Path(),
+		Timestamp:  time.Now().UTC().Format(time.RFC3339),
+		RequestID:  ctx.RequestID(),
+	}
+
+	var exception *HTTPException
+	var validationErrors ValidationErrors
+	switch {
+	case errors.As(err, &exception):
+		response.StatusCode = exception.StatusCode
+		response.Message = exception.Message
+		response.Code = exception.Code
+		response.Details = exception.Details
+	case errors.As(err, &validationErrors):
+		response.StatusCode = http.StatusBadRequest
+		response.Message = "Validation failed"
+		response.Code = "VALIDATION_FAILED"
+	default:
+		response.Code = codeFor(http.StatusInternalServerError)
+	}
+	if errors.As(err, &validationErrors) {
+		response.Errors = validationErrors
+	}
+
+	response.Error = http.StatusText(response.StatusCode)
+	return response
+}
+
+// SetFilters sets the exception filters consulted by HandleError, innermost
+// first. The router calls it with the filters of the matched route.
+func (c *AppContext) SetFilters(filters []Filter) {
+	c.filters = filters
+}
+
+// HandleError passes err through the exception filters of the route until
+// one of them handles it, falling back to DefaultFilter. The router calls it
+// for errors escaping the handler chain; middleware that needs the final
+// response, such as an access log, may call it and return nil instead.
+//
+// Example:
+//
+//	if err := next(ctx); err != nil {
+//	    ctx.HandleError(err)
+//	}
+//	log.Info("request", "status", ctx.GetStatusCode())
+func (c *AppContext) HandleError(err error) {
+	for _, filter := range c.filters {
+		if err = filter.Catch(err, c); err == nil {
+			return
+		}
+	}
+	_ = DefaultFilter{}.Catch(err, c)
+}
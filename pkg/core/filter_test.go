@@ -0,0 +1,186 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewErrorResponse(t *testing.T) {
+	validation := ValidationErrors{{Field: "email", Message: "email is required"}}
+
+	tests := []struct {
+		name       string
+		err        error
+		statusCode int
+		message    string
+		errors     int
+	}{
+		{"generic error", errors.New("connection refused"), http.StatusInternalServerError, "Internal server error", 0},
+		{"HTTP exception", Conflict("email taken"), http.StatusConflict, "email taken", 0},
+		{"wrapped HTTP exception", fmt.Errorf("creating user: %w", Conflict("email taken")), http.StatusConflict, "email taken", 0},
+		{"validation errors", validation, http.StatusBadRequest, "Validation failed", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest("POST", "/users", nil))
+			response := NewErrorResponse(test.err, ctx)
+
+			if response.StatusCode != test.statusCode {
+				t.Errorf("StatusCode = %v, want %v", response.StatusCode, test.statusCode)
+			}
+			if response.Message != test.message {
+				t.Errorf("Message = %v, want %v", response.Message, test.message)
+			}
+			if response.Error != http.StatusText(test.statusCode) {
+				t.Errorf("Error = %v, want %v", response.Error, http.StatusText(test.statusCode))
+			}
+			if response.Path != "/users" {
+				t.Errorf("Path = %v, want %v", response.Path, "/users")
+			}
+			if _, err := time.Parse(time.RFC3339, response.Timestamp); err != nil {
+				t.Errorf("Timestamp = %v, want an RFC 3339 timestamp", response.Timestamp)
+			}
+			if len(response.Errors) != test.errors {
+				t.Errorf("len(Errors) = %v, want %v", len(response.Errors), test.errors)
+			}
+		})
+	}
+}
+
+func TestNewErrorResponse_CodeAndDetails(t *testing.T) {
+	ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/7", nil))
+
+	details := map[string]string{"id": "7"}
+	response := NewErrorResponse(NotFound("user 7 not found").WithCode("USER_NOT_FOUND").WithDetails(details), ctx)
+	if response.Code != "USER_NOT_FOUND" {
+		t.Errorf("Code = %v, want %v", response.Code, "USER_NOT_FOUND")
+	}
+	if d, ok := response.Details.(map[string]string); !ok || d["id"] != "7" {
+		t.Errorf("Details = %v, want %v", response.Details, details)
+	}
+
+	response = NewErrorResponse(Internal("loading user").WithCause(errors.New("dsn=secret")), ctx)
+	if response.Message != "loading user" || response.Code != "INTERNAL_SERVER_ERROR" {
+		t.Errorf("ErrorResponse = %+v, want the exception message without its cause", response)
+	}
+
+	response = NewErrorResponse(errors.New("dsn=secret"), ctx)
+	if response.Code != "INTERNAL_SERVER_ERROR" {
+		t.Errorf("Code = %v, want %v", response.Code, "INTERNAL_SERVER_ERROR")
+	}
+}
+
+func TestNewErrorResponse_WrappedValidationErrors(t *testing.T) {
+	ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest("POST", "/orders", nil))
+	validation := ValidationErrors{{Field: "items[0].price", Message: "price must be positive"}}
+
+	response := NewErrorResponse(UnprocessableEntity("invalid order").WithCause(validation), ctx)
+	if response.StatusCode != http.StatusUnprocessableEntity || response.Message != "invalid order" {
+		t.Errorf("ErrorResponse = %+v, want 422 with the exception message", response)
+	}
+	if len(response.Errors) != 1 || response.Errors[0].Field != "items[0].price" {
+		t.Errorf("Errors = %v, want the wrapped validation errors", response.Errors)
+	}
+}
+
+func TestDefaultFilter_Catch(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := NewContext(w, httptest.NewRequest("GET", "/users/7", nil))
+
+	if err := (DefaultFilter{}).Catch(NotFound("user 7 not found"), ctx); err != nil {
+		t.Fatalf("Catch() error = %v", err)
+	}
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Status code = %v, want %v", w.Code, http.StatusNotFound)
+	}
+
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if body.Message != "user 7 not found" || body.Path != "/users/7" {
+		t.Errorf("ErrorResponse = %+v, want the exception message and path", body)
+	}
+}
+
+func TestDefaultFilter_CatchWritten(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := NewContext(w, httptest.NewRequest("GET", "/", nil))
+	_ = ctx.String(http.StatusAccepted, "partial")
+
+	if err := (DefaultFilter{}).Catch(errors.New("late failure"), ctx); err != nil {
+		t.Fatalf("Catch() error = %v", err)
+	}
+	if w.Code != http.StatusAccepted || w.Body.String() != "partial" {
+		t.Errorf("response = %d %q, want the already written response untouched", w.Code, w.Body.String())
+	}
+}
+
+func TestFilterFunc_Catch(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	filter := FilterFunc(func(err error, ctx Context) error {
+		if errors.Is(err, sentinel) {
+			return NotFound("translated")
+		}
+		return err
+	})
+
+	var exception *HTTPException
+	if err := filter.Catch(sentinel, nil); !errors.As(err, &exception) || exception.StatusCode != http.StatusNotFound {
+		t.Errorf("Catch() = %v, want a 404 exception", err)
+	}
+
+	other := errors.New("other")
+	if err := filter.Catch(other, nil); err != other {
+		t.Errorf("Catch() = %v, want %v", err, other)
+	}
+}
+
+func TestContext_HandleError(t *testing.T) {
+	teapot := FilterFunc(func(err error, ctx Context) error {
+		if errors.Is(err, ErrNotFound) {
+			return ctx.String(http.StatusTeapot, "handled")
+		}
+		return err
+	})
+
+	tests := []struct {
+		name    string
+		filters []Filter
+		err     error
+		status  int
+	}{
+		{"filter handles", []Filter{teapot}, NotFound("gone"), http.StatusTeapot},
+		{"filter passes", []Filter{teapot}, Conflict("taken"), http.StatusConflict},
+		{"no filters", nil, errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx := NewContext(w, httptest.NewRequest("GET", "/", nil))
+			ctx.SetFilters(tt.filters)
+			ctx.HandleError(tt.err)
+			if w.Code != tt.status {
+				t.Errorf("HandleError() status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestContext_SetResponse(t *testing.T) {
+	original, replacement := httptest.NewRecorder(), httptest.NewRecorder()
+	ctx := NewContext(original, httptest.NewRequest("GET", "/", nil))
+	ctx.SetResponse(replacement)
+
+	_ = ctx.String(http.StatusCreated, "moved")
+	if replacement.Code != http.StatusCreated || replacement.Body.String() != "moved" || original.Body.Len() != 0 {
+		t.Errorf("SetResponse() wrote %d %q to the replacement, %q to the original", replacement.Code, replacement.Body.String(), original.Body.String())
+	}
+}
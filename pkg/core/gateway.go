@@ -0,0 +1,273 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Gateway is the WebSocket counterpart of a Controller: it accepts
+// connections on a path and dispatches their messages to handlers by event
+// name. Messages are JSON envelopes such as {"event": "chat", "data": {...}}.
+//
+// Example:
+//
+//	func (g *ChatGateway) GetPath() string { return "/chat" }
+//
+//	func (g *ChatGateway) RegisterEvents(events core.GatewayEvents) error {
+//	    events.On("message", core.TypedEvent(func(socket *core.Socket, msg ChatMessage) (interface{}, error) {
+//	        g.room.Broadcast(msg)
+//	        return "delivered", nil
+//	    }))
+//	    return nil
+//	}
+type Gateway interface {
+	// GetPath returns the path, relative to the module, accepting WebSocket connections.
+	GetPath() string
+
+	// RegisterEvents registers the message handlers of the gateway.
+	RegisterEvents(events GatewayEvents) error
+}
+
+// GuardedGateway is implemented by gateways whose connections are protected
+// by guards. The guards run on the upgrade request; a denied request gets a
+// 403 response instead of a WebSocket connection.
+type GuardedGateway interface {
+	Gateway
+
+	// GetGuards returns the guards applied to the upgrade request.
+	GetGuards() []Guard
+}
+
+// ConfiguredGateway is implemented by gateways upgrading their connections
+// with custom options, such as subprotocols, a message size limit or an
+// origin check.
+type ConfiguredGateway interface {
+	Gateway
+
+	// GetWebSocketOptions returns the options of the upgrade.
+	GetWebSocketOptions() WebSocketOptions
+}
+
+// GatewayConnectionHandler is implemented by gateways notified of new
+// connections. Returning an error closes the connection with ClosePolicyViolation.
+type GatewayConnectionHandler interface {
+	// HandleConnection is called once the connection is established.
+	HandleConnection(socket *Socket) error
+}
+
+// GatewayDisconnectHandler is implemented by gateways notified of closed connections.
+type GatewayDisconnectHandler interface {
+	// HandleDisconnect is called after the connection ends, with the error that ended it.
+	HandleDisconnect(socket *Socket, err error)
+}
+
+// GatewayEvents registers the message handlers of a gateway.
+type GatewayEvents interface {
+	// On registers handler for event. Guards run before the handler on each
+	// message, with the context of the upgrade request; a denied message
+	// gets a 403 exception event.
+	On(event string, handler EventHandler, guards ...Guard)
+}
+
+// EventHandler handles a gateway message. A non-nil result is sent back as
+// an event of the same name; an error is sent as an "exception" event.
+type EventHandler func(socket *Socket, data json.RawMessage) (interface{}, error)
+
+// ExceptionEvent is the event carrying the ErrorResponse of a failed message.
+const ExceptionEvent = "exception"
+
+// GatewayMessage is the JSON envelope of received gateway messages.
+type GatewayMessage struct {
+	// Event is the name of the event
+	Event string `json:"event"`
+	// Data is the payload of the event
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// outgoingMessage is the JSON envelope of the events sent by Socket.Emit.
+type outgoingMessage struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// TypedEvent adapts a handler receiving decoded event data. The data is
+// decoded with the JSON codec of the request and validated with Validate.
+func TypedEvent[T any](handler func(socket *Socket, data T) (interface{}, error)) EventHandler {
+	return func(socket *Socket, raw json.RawMessage) (interface{}, error) {
+		var data T
+		if len(raw) > 0 {
+			if err := socket.codec.Decode(bytes.NewReader(raw), &data, false); err != nil {
+				return nil, BadRequest("Invalid event data").WithCause(err)
+			}
+		}
+		if err := Validate(&data); err != nil {
+			return nil, err
+		}
+		return handler(socket, data)
+	}
+}
+
+// Socket is a client connected to a gateway. It may be used from other
+// goroutines, to broadcast events for instance, until HandleDisconnect runs.
+type Socket struct {
+	id   string
+	conn *WebSocketConn
+	// ctx is a copy of the context of the upgrade request, which the router
+	// recycles once the gateway handler returns
+	ctx   Context
+	codec JSONCodec
+}
+
+// ID returns a random identifier of the connection.
+func (s *Socket) ID() string {
+	return s.id
+}
+
+// Conn returns the underlying WebSocket connection.
+func (s *Socket) Conn() *WebSocketConn {
+	return s.conn
+}
+
+// Context returns a copy of the context of the upgrade request, holding the
+// values set by middleware and guards such as the authenticated principal.
+// It stays valid after the connection ends.
+func (s *Socket) Context() Context {
+	return s.ctx
+}
+
+// Emit sends an event to the client.
+func (s *Socket) Emit(event string, data interface{}) error {
+	var buf bytes.Buffer
+	if err := s.codec.Encode(&buf, outgoingMessage{Event: event, Data: data}); err != nil {
+		return err
+	}
+	return s.conn.WriteMessage(TextMessage, bytes.TrimRight(buf.Bytes(), "\n"))
+}
+
+// eventRoute is a registered event handler with its guards.
+type eventRoute struct {
+	handler EventHandler
+	guards  []Guard
+}
+
+// gatewayEvents is the GatewayEvents of GatewayHandler.
+type gatewayEvents struct {
+	mu     sync.RWMutex
+	routes map[string]eventRoute
+}
+
+// On registers handler for event.
+func (e *gatewayEvents) On(event string, handler EventHandler, guards ...Guard) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.routes[event] = eventRoute{handler: handler, guards: guards}
+}
+
+// GatewayHandler returns the route handler serving gateway: it upgrades the
+// request with opts, then reads messages and dispatches them until the
+// connection ends. The handler returns once the connection is closed, which
+// happens with CloseGoingAway when the context of the request is done, as
+// it is on application shutdown. Registration errors of the gateway are
+// returned immediately.
+func GatewayHandler(gateway Gateway, opts WebSocketOptions) (HandlerFunc, error) {
+	events := &gatewayEvents{routes: make(map[string]eventRoute)}
+	if err := gateway.RegisterEvents(events); err != nil {
+		return nil, err
+	}
+
+	return func(ctx Context) error {
+		conn, err := ctx.UpgradeWithOptions(opts)
+		if err != nil {
+			return err
+		}
+		socket := &Socket{id: newSocketID(), conn: conn, ctx: ctx.WithContext(ctx.Context()), codec: jsonCodecOf(ctx)}
+		stop := context.AfterFunc(ctx.Context(), func() {
+			_ = conn.CloseWithCode(CloseGoingAway, "")
+		})
+		defer stop()
+
+		if h, ok := gateway.(GatewayConnectionHandler); ok {
+			if err := h.HandleConnection(socket); err != nil {
+				_ = conn.CloseWithCode(ClosePolicyViolation, err.Error())
+				return nil
+			}
+		}
+
+		var readErr error
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				readErr = err
+				break
+			}
+			if err := events.dispatch(socket, messageType, data); err != nil {
+				readErr = err
+				_ = conn.CloseWithCode(CloseInternalServerError, "")
+				break
+			}
+		}
+
+		if h, ok := gateway.(GatewayDisconnectHandler); ok {
+			h.HandleDisconnect(socket, readErr)
+		}
+		return nil
+	}, nil
+}
+
+// dispatch runs the handler of a message and sends its result or error
+// back. Only errors writing to the connection are returned.
+func (e *gatewayEvents) dispatch(socket *Socket, messageType MessageType, data []byte) error {
+	var message GatewayMessage
+	if messageType != TextMessage || json.Unmarshal(data, &message) != nil || message.Event == "" {
+		return socket.Emit(ExceptionEvent, NewErrorResponse(BadRequest("Invalid gateway message"), socket.ctx))
+	}
+
+	e.mu.RLock()
+	route, ok := e.routes[message.Event]
+	e.mu.RUnlock()
+	if !ok {
+		return socket.Emit(ExceptionEvent, NewErrorResponse(NotFound("Unknown event %q", message.Event), socket.ctx))
+	}
+
+	result, err := runEvent(socket, route, message.Data)
+	if err != nil {
+		return socket.Emit(ExceptionEvent, NewErrorResponse(err, socket.ctx))
+	}
+	if result == nil {
+		return nil
+	}
+	return socket.Emit(message.Event, result)
+}
+
+// runEvent checks the guards of route, then calls its handler. Panics are
+// converted into errors so that one message cannot end the connection.
+func runEvent(socket *Socket, route eventRoute, data json.RawMessage) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("gateway handler panic: %v", r)
+		}
+	}()
+
+	for _, guard := range route.guards {
+		allowed, err := guard.CanActivate(socket.ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, Forbidden("Forbidden resource")
+		}
+	}
+	return route.handler(socket, data)
+}
+
+// newSocketID returns a random hexadecimal identifier.
+func newSocketID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,224 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type chatMessage struct {
+	Room string `json:"room" validate:"required"`
+	Text string `json:"text"`
+}
+
+// chatGateway records its lifecycle hooks.
+type chatGateway struct {
+	connected    chan string
+	disconnected chan error
+	rejectAll    bool
+}
+
+func (g *chatGateway) GetPath() string { return "/chat" }
+
+func (g *chatGateway) RegisterEvents(events GatewayEvents) error {
+	events.On("message", TypedEvent(func(socket *Socket, msg chatMessage) (interface{}, error) {
+		return map[string]string{"room": msg.Room, "echo": msg.Text}, nil
+	}))
+	events.On("whoami", func(socket *Socket, data json.RawMessage) (interface{}, error) {
+		return socket.Context().GetValue("principal"), nil
+	}, GuardFunc(func(ctx Context) (bool, error) {
+		return ctx.GetValue("principal") != nil, nil
+	}))
+	events.On("silent", func(socket *Socket, data json.RawMessage) (interface{}, error) {
+		return nil, socket.Emit("notice", "pushed")
+	})
+	events.On("panic", func(socket *Socket, data json.RawMessage) (interface{}, error) {
+		panic("boom")
+	})
+	return nil
+}
+
+func (g *chatGateway) HandleConnection(socket *Socket) error {
+	if g.rejectAll {
+		return errors.New("rejected")
+	}
+	g.connected <- socket.ID()
+	return nil
+}
+
+func (g *chatGateway) HandleDisconnect(socket *Socket, err error) {
+	g.disconnected <- err
+}
+
+func gatewayServer(t *testing.T, gateway Gateway, principal interface{}) *httptest.Server {
+	handler, err := GatewayHandler(gateway, WebSocketOptions{})
+	if err != nil {
+		t.Fatalf("GatewayHandler() error = %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewContext(w, r)
+		if principal != nil {
+			ctx.SetValue("principal", principal)
+		}
+		if err := handler(ctx); err != nil {
+			_ = DefaultFilter{}.Catch(err, ctx)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (c *wsClient) emit(message string) {
+	c.t.Helper()
+	c.send(true, byte(TextMessage), []byte(message))
+}
+
+func (c *wsClient) receive() map[string]interface{} {
+	c.t.Helper()
+	f := c.read()
+	var message map[string]interface{}
+	if err := json.Unmarshal(f.payload, &message); err != nil {
+		c.t.Fatalf("received %q: %v", f.payload, err)
+	}
+	return message
+}
+
+func TestGatewayHandler_Events(t *testing.T) {
+	gateway := &chatGateway{connected: make(chan string, 1), disconnected: make(chan error, 1)}
+	client := dialWebSocket(t, gatewayServer(t, gateway, nil), "/chat", nil)
+	if id := <-gateway.connected; len(id) != 16 {
+		t.Errorf("Socket.ID() = %q, want 16 hexadecimal digits", id)
+	}
+
+	tests := []struct {
+		name    string
+		message string
+		event   string
+		want    string
+	}{
+		{"typed handler", `{"event":"message","data":{"room":"go","text":"hi"}}`, "message", `{"echo":"hi","room":"go"}`},
+		{"validation", `{"event":"message","data":{"text":"hi"}}`, ExceptionEvent, `"code":"VALIDATION_FAILED"`},
+		{"invalid data", `{"event":"message","data":[1]}`, ExceptionEvent, `"message":"Invalid event data"`},
+		{"guard denies", `{"event":"whoami"}`, ExceptionEvent, `"statusCode":403`},
+		{"unknown event", `{"event":"nope"}`, ExceptionEvent, `"message":"Unknown event \"nope\""`},
+		{"invalid envelope", `not json`, ExceptionEvent, `"statusCode":400`},
+		{"emit", `{"event":"silent"}`, "notice", `"pushed"`},
+		{"panic", `{"event":"panic"}`, ExceptionEvent, `"statusCode":500`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client.emit(tt.message)
+			message := client.receive()
+			if message["event"] != tt.event {
+				t.Errorf("event = %v, want %v", message["event"], tt.event)
+			}
+			data, _ := json.Marshal(message["data"])
+			if !strings.Contains(string(data), tt.want) {
+				t.Errorf("data = %s, want it to contain %s", data, tt.want)
+			}
+		})
+	}
+
+	client.send(true, byte(CloseMessage), []byte{0x03, 0xe8})
+	client.readClose()
+	var closeErr *CloseError
+	if err := <-gateway.disconnected; !errors.As(err, &closeErr) || closeErr.Code != CloseNormalClosure {
+		t.Errorf("HandleDisconnect() error = %v, want close %d", err, CloseNormalClosure)
+	}
+}
+
+func TestGatewayHandler_EventGuardAllows(t *testing.T) {
+	gateway := &chatGateway{connected: make(chan string, 1), disconnected: make(chan error, 1)}
+	client := dialWebSocket(t, gatewayServer(t, gateway, "alice"), "/chat", nil)
+
+	client.emit(`{"event":"whoami"}`)
+	if message := client.receive(); message["event"] != "whoami" || message["data"] != "alice" {
+		t.Errorf("whoami = %v, want data %q", message, "alice")
+	}
+}
+
+func TestGatewayHandler_ConnectionRejected(t *testing.T) {
+	gateway := &chatGateway{rejectAll: true}
+	client := dialWebSocket(t, gatewayServer(t, gateway, nil), "/chat", nil)
+
+	if code := client.readClose(); code != ClosePolicyViolation {
+		t.Errorf("close code = %d, want %d", code, ClosePolicyViolation)
+	}
+}
+
+func TestGatewayHandler_NotUpgraded(t *testing.T) {
+	gateway := &chatGateway{}
+	server := gatewayServer(t, gateway, nil)
+
+	resp, err := http.Get(server.URL + "/chat")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("GET /chat status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+// broadcastGateway hands its sockets to a broadcaster.
+type broadcastGateway struct {
+	sockets chan *Socket
+}
+
+func (g *broadcastGateway) GetPath() string { return "/broadcast" }
+
+func (g *broadcastGateway) RegisterEvents(events GatewayEvents) error { return nil }
+
+func (g *broadcastGateway) HandleConnection(socket *Socket) error {
+	g.sockets <- socket
+	return nil
+}
+
+func TestGatewayHandler_EmitDuringDisconnect(t *testing.T) {
+	gateway := &broadcastGateway{sockets: make(chan *Socket, 1)}
+	handler, err := GatewayHandler(gateway, WebSocketOptions{})
+	if err != nil {
+		t.Fatalf("GatewayHandler() error = %v", err)
+	}
+	served := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewContext(w, r)
+		ctx.SetValue("principal", "alice")
+		_ = handler(ctx)
+		// The router recycles the context for the next request.
+		ctx.Reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/other", nil))
+		ctx.SetValue("principal", "bob")
+		close(served)
+	}))
+	t.Cleanup(server.Close)
+
+	client := dialWebSocket(t, server, "/broadcast", nil)
+	socket := <-gateway.sockets
+
+	stop := make(chan struct{})
+	broadcast := make(chan interface{}, 1)
+	go func() {
+		var principal interface{}
+		for {
+			select {
+			case <-stop:
+				broadcast <- principal
+				return
+			default:
+				_ = socket.Emit("tick", 1)
+				principal = socket.Context().GetValue("principal")
+			}
+		}
+	}()
+
+	client.conn.Close()
+	<-served
+	close(stop)
+	if principal := <-broadcast; principal != "alice" {
+		t.Errorf("Socket.Context().GetValue(principal) = %v, want %v", principal, "alice")
+	}
+}
@@ -33,6 +33,9 @@ type Application interface {
 
 	// GetRouter returns the application's router.
 	GetRouter() Router
+
+	// GetLogger returns the root logger of the application.
+	GetLogger() Logger
 }
 
 // Router handles HTTP routing and dispatches requests to the appropriate handler.
@@ -62,12 +65,30 @@ type Router interface {
 	// Handle registers a route with a custom HTTP method
 	Handle(method, path string, handler HandlerFunc) Router
 
+	// HandleWithOptions registers a route with route-specific middleware, guards, pipes, filters and interceptors.
+	HandleWithOptions(method, path string, handler HandlerFunc, opts RouteOptions) Router
+
+	// Route registers a route described by its metadata.
+	Route(route RouteMetadata) Router
+
+	// Mount registers the routes of a controller under its prefix and returns the controller's route group.
+	Mount(controller ControllerMetadata) Router
+
 	// Group creates a route group with a common prefix and optional middleware.
 	Group(prefix string, middleware ...Middleware) Router
 
 	// Use adds middleware to the router.
 	Use(middleware ...Middleware) Router
 
+	// UseGuards adds guards evaluated before every route of the router, ahead of route-level guards.
+	UseGuards(guards ...Guard) Router
+
+	// UseFilters adds exception filters consulted for errors of every route of the router, after route-level filters.
+	UseFilters(filters ...Filter) Router
+
+	// UseInterceptors adds interceptors wrapping every route handler of the router, outside route-level interceptors.
+	UseInterceptors(interceptors ...Interceptor) Router
+
 	// ServeHTTP implements the http.Handler interface.
 	ServeHTTP(w http.ResponseWriter, r *http.Request)
 }
@@ -85,6 +106,12 @@ type Context interface {
 	// Response returns the underlying http.ResponseWriter.
 	Response() http.ResponseWriter
 
+	// SetRequestID stores the ID of the request in the context values and in Context().
+	SetRequestID(id string)
+
+	// RequestID returns the ID of the request, or "" if none was set.
+	RequestID() string
+
 	// Param returns the value of a URL parameter by name
 	// For route like /users/:id, the value of :id will be returned by Param("id")
 	Param(name string) string
@@ -102,9 +129,15 @@ type Context interface {
 	// QueryArray returns all values of a URL query parameter.as []string
 	QueryArray(name string) []string
 
-	// Body binds the request body to a struct using JSON decoding.
+	// Body binds the request body to a struct using the binder registered for its content type.
 	Body(v interface{}) error
 
+	// Bind fills v from the request body, path parameters, query string and headers.
+	Bind(v interface{}) error
+
+	// BindAndValidate binds the request body to v and validates it with Validate.
+	BindAndValidate(v interface{}) error
+
 	// JSON sends a JSON response with the given status code.
 	JSON(statusCode int, data interface{}) error
 
@@ -117,6 +150,10 @@ type Context interface {
 	// Data writes raw binary data with the specified content type.
 	Data(statusCode int, contentType string, data []byte) error
 
+	// Negotiate sends data in the format preferred by the Accept header,
+	// or returns a 406 exception if no renderer is acceptable.
+	Negotiate(statusCode int, data interface{}) error
+
 	// NoContent sends a response with no body content.
 	NoContent(statusCode int) error
 
@@ -151,10 +188,13 @@ type Context interface {
 	// URL returns the full request URL.
 	URL() *url.URL
 
-	// Host returns the host from the request.
+	// Host returns the host from the request, as forwarded by trusted proxies.
 	Host() string
 
-	// ClientIP attempts to get the real client IP address.
+	// Scheme returns "http" or "https", as forwarded by trusted proxies.
+	Scheme() string
+
+	// ClientIP returns the client IP address, as forwarded by trusted proxies.
 	ClientIP() string
 
 	// UserAgent returns the User-Agent header value.
@@ -178,10 +218,16 @@ type Context interface {
 	// IsWebSocket checks if the request is a WebSocket upgrade request.
 	IsWebSocket() bool
 
+	// Upgrade switches the request to the WebSocket protocol.
+	Upgrade() (*WebSocketConn, error)
+
+	// UpgradeWithOptions switches the request to the WebSocket protocol with options.
+	UpgradeWithOptions(opts WebSocketOptions) (*WebSocketConn, error)
+
 	// IsAjax checks if the request is an AJAX request.
 	IsAjax() bool
 
-	// Accepts checks if the client accepts the specified content type.
+	// Accepts reports whether the Accept header allows the specified content type.
 	Accepts(contentType string) bool
 
 	// Next advances the request pipeline to the next handler.
@@ -208,10 +254,27 @@ type Context interface {
 	// Stream sends a streaming response.
 	Stream(statusCode int, contentType string, step func(io.Writer) error) error
 
+	// SSE streams Server-Sent Events written by handler.
+	SSE(handler func(stream SSEWriter) error) error
+
 	// Err returns any error stored in the request context.
 	Err() error
 }
 
+// ResponseContext is implemented by contexts whose response can be taken
+// over by middleware, such as *AppContext. Middleware wrapping the response
+// writer type-asserts the Context to it.
+type ResponseContext interface {
+	Context
+
+	// SetResponse replaces the response writer, e.g., with a wrapper recording the response.
+	SetResponse(w http.ResponseWriter)
+
+	// HandleError writes the response for err through the exception filters of
+	// the route, as if err had been returned by the handler chain.
+	HandleError(err error)
+}
+
 // Controller represents a controller that groups related route handlers.
 // Controllers organize application logic into cohesive units.
 type Controller interface {
@@ -226,6 +289,33 @@ type Controller interface {
 	RegisterRoutes(router Router) error
 }
 
+// GuardedController is implemented by controllers whose routes are all protected by guards.
+// Controller guards run before the guards of individual routes.
+type GuardedController interface {
+	Controller
+
+	// GetGuards returns the guards applied to all controller routes.
+	GetGuards() []Guard
+}
+
+// FilteredController is implemented by controllers handling the errors of their routes with exception filters.
+// Controller filters are consulted after route filters and before global filters.
+type FilteredController interface {
+	Controller
+
+	// GetFilters returns the exception filters applied to all controller routes.
+	GetFilters() []Filter
+}
+
+// InterceptedController is implemented by controllers wrapping their route handlers with interceptors.
+// Controller interceptors run outside the interceptors of individual routes.
+type InterceptedController interface {
+	Controller
+
+	// GetInterceptors returns the interceptors applied to all controller routes.
+	GetInterceptors() []Interceptor
+}
+
 // Provider represents a service or component that can be injected as a dependency.
 // Providers are registered in the dependency injection container.
 type Provider interface {
@@ -287,6 +377,15 @@ type Module interface {
 	OnModuleDestroy() error
 }
 
+// GatewayModule is implemented by modules declaring WebSocket gateways.
+// Gateways are mounted with the module middleware, like controllers.
+type GatewayModule interface {
+	Module
+
+	// GetGateways returns the gateways defined in this module.
+	GetGateways() []Gateway
+}
+
 // Middleware is a function that can process requests before they reach handlers.
 // Middleware can modify the request, response, or terminate the request chain.
 type Middleware func(ctx Context, next HandlerFunc) error
@@ -298,6 +397,22 @@ type Guard interface {
 	CanActivate(ctx Context) (bool, error)
 }
 
+// GuardFunc is an adapter to allow ordinary functions to be used as guards.
+type GuardFunc func(ctx Context) (bool, error)
+
+// CanActivate calls f(ctx).
+func (f GuardFunc) CanActivate(ctx Context) (bool, error) {
+	return f(ctx)
+}
+
+// PrincipalKey is the conventional Context value key under which guards store
+// the authenticated principal for handlers and later pipeline stages.
+//
+// Example:
+//
+//	ctx.SetValue(core.PrincipalKey, user)
+const PrincipalKey = "principal"
+
 // Pipe transforms and validates input data before it reaches the handler.
 // Pipes can parse parameters, validate data, or transform data types.
 type Pipe interface {
@@ -318,6 +433,7 @@ type PipeMetadata struct {
 // Filters can catch errors and return appropriate error responses.
 type Filter interface {
 	// Catch processes an error and generates an appropriate response.
+	// It returns nil once the error is handled, or an error to pass on to the next filter.
 	Catch(err error, ctx Context) error
 }
 
@@ -325,7 +441,20 @@ type Filter interface {
 // Interceptors implement aspect-oriented programming patterns.
 type Interceptor interface {
 	// Intercept wraps the execution of a handler and can modify its result.
-	Intercept(ctx Context, next HandlerFunc) (interface{}, error)
+	// It may skip next to short-circuit the handler with its own result.
+	Intercept(ctx Context, next CallHandler) (interface{}, error)
+}
+
+// CallHandler invokes the next interceptor, or the route handler for the
+// innermost one, and returns the handler result.
+type CallHandler func() (interface{}, error)
+
+// InterceptorFunc is an adapter to allow ordinary functions to be used as interceptors.
+type InterceptorFunc func(ctx Context, next CallHandler) (interface{}, error)
+
+// Intercept calls f(ctx, next).
+func (f InterceptorFunc) Intercept(ctx Context, next CallHandler) (interface{}, error) {
+	return f(ctx, next)
 }
 
 // ProviderScope defines the lifecycle of a provider instance.
@@ -353,3 +482,47 @@ func (s ProviderScope) String() string {
 		return "Unknown"
 	}
 }
+
+// Logger writes leveled, structured log records. Key-value pairs follow the
+// message, as in log/slog:
+//
+//	logger.Info("user created", "id", user.ID, "took", time.Since(start))
+//
+// Every module container provides a Logger named after the module, resolved
+// with the di.Token[core.Logger]() token.
+type Logger interface {
+	// Debug logs a message at LogLevelDebug.
+	Debug(msg string, keyvals ...interface{})
+
+	// Info logs a message at LogLevelInfo.
+	Info(msg string, keyvals ...interface{})
+
+	// Warn logs a message at LogLevelWarn.
+	Warn(msg string, keyvals ...interface{})
+
+	// Error logs a message at LogLevelError.
+	Error(msg string, keyvals ...interface{})
+
+	// Fatal logs a message at LogLevelFatal, then exits the process with status 1.
+	Fatal(msg string, keyvals ...interface{})
+
+	// Log logs a message at the given level.
+	Log(level LogLevel, msg string, keyvals ...interface{})
+
+	// With returns a child logger adding keyvals to every record.
+	With(keyvals ...interface{}) Logger
+
+	// Named returns a child logger whose records carry name as their context,
+	// appended to the context of the parent with a dot.
+	Named(name string) Logger
+
+	// Enabled reports whether records at level are written.
+	Enabled(level LogLevel) bool
+
+	// Level returns the minimum level of the written records.
+	Level() LogLevel
+
+	// SetLevel changes the minimum level of the logger and of every logger
+	// sharing its output, including parents and children.
+	SetLevel(level LogLevel)
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// JSONCodec encodes JSON responses and decodes JSON request bodies. It backs
+// Context.JSON, the JSON renderer used by Context.Negotiate and the JSON
+// binder, so a faster implementation can be swapped in with
+// ConfigOptions.JSONCodec without changing handlers.
+type JSONCodec interface {
+	// Encode writes the JSON encoding of v to w.
+	Encode(w io.Writer, v interface{}) error
+
+	// Decode reads a JSON value from r into v. In strict mode unknown
+	// object fields and data following the value are errors.
+	Decode(r io.Reader, v interface{}, strict bool) error
+}
+
+// StdJSONCodec is the JSONCodec built on encoding/json. Its zero value
+// behaves like json.Encoder and json.Decoder with their defaults.
+type StdJSONCodec struct {
+	// DisableHTMLEscape writes <, > and & in strings as is instead of escaping them
+	DisableHTMLEscape bool
+	// Indent pretty-prints encoded values, indenting nested elements with it
+	Indent string
+	// UseNumber decodes numbers held in interface{} values as json.Number instead of float64
+	UseNumber bool
+}
+
+// Encode writes the JSON encoding of v to w, followed by a newline.
+func (c StdJSONCodec) Encode(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(!c.DisableHTMLEscape)
+	if c.Indent != "" {
+		encoder.SetIndent("", c.Indent)
+	}
+	return encoder.Encode(v)
+}
+
+// Decode reads a JSON value from r into v.
+func (c StdJSONCodec) Decode(r io.Reader, v interface{}, strict bool) error {
+	decoder := json.NewDecoder(r)
+	if c.UseNumber {
+		decoder.UseNumber()
+	}
+	if !strict {
+		return decoder.Decode(v)
+	}
+
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		if err != nil {
+			return err
+		}
+		return errors.New("json: unexpected data after top-level value")
+	}
+	return nil
+}
+
+// Ensure StdJSONCodec implements JSONCodec.
+var _ JSONCodec = StdJSONCodec{}
+
+// jsonCodecKey is the request context key holding the JSONCodec of a request.
+type jsonCodecKey struct{}
+
+// jsonCodecOf returns the codec of ctx set with AppContext.SetJSONCodec.
+func jsonCodecOf(ctx Context) JSONCodec {
+	if c, ok := ctx.(*AppContext); ok {
+		return c.jsonCodec()
+	}
+	return JSONCodecFrom(ctx.Request())
+}
+
+// JSONCodecFrom returns the codec applied to r with AppContext.SetJSONCodec,
+// or a zero StdJSONCodec. Custom binders use it to decode JSON consistently.
+func JSONCodecFrom(r *http.Request) JSONCodec {
+	if codec, ok := r.Context().Value(jsonCodecKey{}).(JSONCodec); ok {
+		return codec
+	}
+	return StdJSONCodec{}
+}
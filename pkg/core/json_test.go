@@ -0,0 +1,122 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStdJSONCodec_Encode(t *testing.T) {
+	data := map[string]interface{}{"html": "<b>&</b>", "n": 1}
+
+	tests := []struct {
+		name  string
+		codec StdJSONCodec
+		want  string
+	}{
+		{"defaults", StdJSONCodec{}, `{"html":"\u003cb\u003e\u0026\u003c/b\u003e","n":1}` + "\n"},
+		{"HTML escaping off", StdJSONCodec{DisableHTMLEscape: true}, `{"html":"<b>&</b>","n":1}` + "\n"},
+		{"indented", StdJSONCodec{DisableHTMLEscape: true, Indent: "  "}, "{\n  \"html\": \"<b>&</b>\",\n  \"n\": 1\n}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.codec.Encode(&buf, data); err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Encode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStdJSONCodec_Decode(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name    string
+		codec   StdJSONCodec
+		body    string
+		strict  bool
+		v       interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"float by default", StdJSONCodec{}, `{"n":12}`, false, &map[string]interface{}{}, &map[string]interface{}{"n": 12.0}, false},
+		{"UseNumber", StdJSONCodec{UseNumber: true}, `{"n":12}`, false, &map[string]interface{}{}, &map[string]interface{}{"n": json.Number("12")}, false},
+		{"unknown field allowed", StdJSONCodec{}, `{"name":"a","x":1}`, false, &payload{}, &payload{Name: "a"}, false},
+		{"unknown field strict", StdJSONCodec{}, `{"name":"a","x":1}`, true, &payload{}, nil, true},
+		{"trailing data strict", StdJSONCodec{}, `{"name":"a"} {}`, true, &payload{}, nil, true},
+		{"invalid", StdJSONCodec{}, `{"name":`, false, &payload{}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.codec.Decode(strings.NewReader(tt.body), tt.v, tt.strict)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(tt.v, tt.want) {
+				t.Errorf("Decode() = %v, want %v", tt.v, tt.want)
+			}
+		})
+	}
+}
+
+func TestContext_SetJSONCodec(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/items", strings.NewReader(`{"price":19.99}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", "application/json")
+	ctx := NewContext(w, r)
+	ctx.SetJSONCodec(StdJSONCodec{DisableHTMLEscape: true, UseNumber: true})
+
+	var body map[string]interface{}
+	if err := ctx.Body(&body); err != nil {
+		t.Fatalf("Body() error = %v", err)
+	}
+	if got := body["price"]; got != json.Number("19.99") {
+		t.Errorf("Body() price = %#v, want %#v", got, json.Number("19.99"))
+	}
+
+	if err := ctx.Negotiate(200, map[string]string{"tag": "<new>"}); err != nil {
+		t.Fatalf("Negotiate() error = %v", err)
+	}
+	if got := w.Body.String(); got != `{"tag":"<new>"}`+"\n" {
+		t.Errorf("Negotiate() body = %q, want %q", got, `{"tag":"<new>"}`+"\n")
+	}
+}
+
+func TestContext_OptionsKeepRequest(t *testing.T) {
+	r := httptest.NewRequest("POST", "/items", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "application/x-options-test")
+	ctx := NewContext(httptest.NewRecorder(), r)
+
+	codec := StdJSONCodec{UseNumber: true}
+	ctx.SetJSONCodec(codec)
+	ctx.SetBodyOptions(BodyOptions{StrictJSON: true})
+	ctx.SetRequestID("req-1")
+	if ctx.Request() != r {
+		t.Errorf("Request() was replaced, want the original request")
+	}
+
+	var got []interface{}
+	RegisterBinder("application/x-options-test", BinderFunc(func(r *http.Request, v interface{}) error {
+		got = []interface{}{JSONCodecFrom(r), BodyOptionsFrom(r).StrictJSON}
+		return nil
+	}))
+	if err := ctx.Body(&struct{}{}); err != nil {
+		t.Fatalf("Body() error = %v", err)
+	}
+	if want := []interface{}{codec, true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("custom binder saw %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,282 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// renderMsgPack encodes data as MessagePack. Field names and values follow
+// the JSON encoding of data, so json tags and json.Marshaler apply.
+func renderMsgPack(w io.Writer, data interface{}) error {
+	document, err := toDocument(data)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := writeMsgPack(&buf, document); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// writeMsgPack appends the MessagePack encoding of a document value to buf.
+func writeMsgPack(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		if n, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			writeMsgPackInt(buf, n)
+		} else if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			buf.WriteByte(0xcf)
+			_ = binary.Write(buf, binary.BigEndian, u)
+		} else {
+			f, err := v.Float64()
+			if err != nil {
+				return err
+			}
+			buf.WriteByte(0xcb)
+			_ = binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+		}
+	case string:
+		writeMsgPackHeader(buf, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		buf.WriteString(v)
+	case []interface{}:
+		writeMsgPackHeader(buf, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			if err := writeMsgPack(buf, item); err != nil {
+				return err
+			}
+		}
+	case orderedMap:
+		writeMsgPackHeader(buf, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		for _, entry := range v {
+			if err := writeMsgPack(buf, entry.key); err != nil {
+				return err
+			}
+			if err := writeMsgPack(buf, entry.value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported value of type %T", v)
+	}
+	return nil
+}
+
+// writeMsgPackInt writes n in its most compact MessagePack integer form.
+func writeMsgPackInt(buf *bytes.Buffer, n int64) {
+	switch {
+	case n >= 0 && n <= math.MaxInt8:
+		buf.WriteByte(byte(n))
+	case n < 0 && n >= -32:
+		buf.WriteByte(byte(int8(n)))
+	case n >= math.MinInt8 && n <= math.MaxInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(int8(n)))
+	case n >= math.MinInt16 && n <= math.MaxInt16:
+		buf.WriteByte(0xd1)
+		_ = binary.Write(buf, binary.BigEndian, int16(n))
+	case n >= math.MinInt32 && n <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		_ = binary.Write(buf, binary.BigEndian, int32(n))
+	default:
+		buf.WriteByte(0xd3)
+		_ = binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+// writeMsgPackHeader writes the header of a string, array or map of n
+// elements: the fix form below fixLimit, then the 8, 16 and 32-bit forms.
+// A zero code8 means the 8-bit form does not exist for the type.
+func writeMsgPackHeader(buf *bytes.Buffer, n int, fix byte, fixLimit int, code8, code16, code32 byte) {
+	switch {
+	case n < fixLimit:
+		buf.WriteByte(fix | byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		buf.WriteByte(code8)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(code16)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(code32)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+// errMsgPackExt is returned for MessagePack extension types, which have no JSON equivalent.
+var errMsgPackExt = errors.New("msgpack: extension types are not supported")
+
+// msgPackMaxDepth bounds the nesting of arrays and maps, as encoding/json
+// does, so that a deeply nested body cannot overflow the stack.
+const msgPackMaxDepth = 10000
+
+// errMsgPackDepth is returned for values nested deeper than msgPackMaxDepth.
+var errMsgPackDepth = errors.New("msgpack: exceeded max depth")
+
+// readMsgPack decodes the next MessagePack value into nil, bool, int64,
+// uint64, float64, string, []byte, []interface{} or map[string]interface{}.
+// depth is the number of arrays and maps enclosing the value.
+func readMsgPack(r *bufio.Reader, depth int) (interface{}, error) {
+	code, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xe0 == 0xa0:
+		return readMsgPackString(r, uint64(code&0x1f))
+	case code&0xf0 == 0x90:
+		return readMsgPackArray(r, int(code&0x0f), depth)
+	case code&0xf0 == 0x80:
+		return readMsgPackMap(r, int(code&0x0f), depth)
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := readMsgPackUint(r, 1<<(code-0xcc))
+		return n, err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (code - 0xd0)
+		n, err := readMsgPackUint(r, size)
+		if err != nil {
+			return nil, err
+		}
+		shift := 64 - 8*size
+		return int64(n<<shift) >> shift, nil
+	case 0xca:
+		n, err := readMsgPackUint(r, 4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := readMsgPackUint(r, 8)
+		return math.Float64frombits(n), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := readMsgPackUint(r, 1<<(code-0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgPackString(r, n)
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readMsgPackUint(r, 1<<(code-0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgPackBytes(r, n)
+	case 0xdc, 0xdd:
+		n, err := readMsgPackUint(r, 2<<(code-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgPackArray(r, int(n), depth)
+	case 0xde, 0xdf:
+		n, err := readMsgPackUint(r, 2<<(code-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgPackMap(r, int(n), depth)
+	case 0xc7, 0xc8, 0xc9, 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return nil, errMsgPackExt
+	}
+	return nil, fmt.Errorf("msgpack: invalid code 0x%02x", code)
+}
+
+// readMsgPackUint reads a big-endian unsigned integer of size bytes.
+func readMsgPackUint(r *bufio.Reader, size int) (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[8-size:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b[:]), nil
+}
+
+// msgPackMaxPrealloc bounds the capacity allocated from a declared length
+// before the data arrives, so that a short body declaring a huge length
+// cannot exhaust memory.
+const msgPackMaxPrealloc = 1024
+
+// readMsgPackBytes reads n bytes, growing the buffer as data arrives.
+func readMsgPackBytes(r *bufio.Reader, n uint64) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(int(min(n, msgPackMaxPrealloc)))
+	read, err := io.Copy(&buf, io.LimitReader(r, int64(min(n, math.MaxInt64))))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(read) < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), nil
+}
+
+// readMsgPackString reads a string of n bytes.
+func readMsgPackString(r *bufio.Reader, n uint64) (string, error) {
+	data, err := readMsgPackBytes(r, n)
+	return string(data), err
+}
+
+// readMsgPackArray reads n values of an array nested in depth others.
+func readMsgPackArray(r *bufio.Reader, n, depth int) ([]interface{}, error) {
+	if depth >= msgPackMaxDepth {
+		return nil, errMsgPackDepth
+	}
+	list := make([]interface{}, 0, min(n, msgPackMaxPrealloc))
+	for i := 0; i < n; i++ {
+		value, err := readMsgPack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+// readMsgPackMap reads n key-value pairs of a map nested in depth arrays
+// and maps. Keys that are not strings are converted with fmt.Sprint.
+func readMsgPackMap(r *bufio.Reader, n, depth int) (map[string]interface{}, error) {
+	if depth >= msgPackMaxDepth {
+		return nil, errMsgPackDepth
+	}
+	m := make(map[string]interface{}, min(n, msgPackMaxPrealloc))
+	for i := 0; i < n; i++ {
+		key, err := readMsgPack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		value, err := readMsgPack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := key.(string); ok {
+			m[s] = value
+		} else {
+			m[fmt.Sprint(key)] = value
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRenderMsgPack(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want []byte
+	}{
+		{"nil", nil, []byte{0xc0}},
+		{"bools", []bool{true, false}, []byte{0x92, 0xc3, 0xc2}},
+		{"fixints", []int{1, -1, 127, -32}, []byte{0x94, 0x01, 0xff, 0x7f, 0xe0}},
+		{"int8", -33, []byte{0xd0, 0xdf}},
+		{"int16", 300, []byte{0xd1, 0x01, 0x2c}},
+		{"int32", 70000, []byte{0xd2, 0x00, 0x01, 0x11, 0x70}},
+		{"uint64", uint64(1 << 63), []byte{0xcf, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+		{"float", 1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"fixstr", "hi", []byte{0xa2, 'h', 'i'}},
+		{"map", map[string]int{"a": 1}, []byte{0x81, 0xa1, 'a', 0x01}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := renderMsgPack(&buf, tt.data); err != nil {
+				t.Fatalf("renderMsgPack() error = %v", err)
+			}
+			if got := buf.Bytes(); !bytes.Equal(got, tt.want) {
+				t.Errorf("renderMsgPack() = % x, want % x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMsgPack_LongString(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderMsgPack(&buf, strings.Repeat("x", 300)); err != nil {
+		t.Fatalf("renderMsgPack() error = %v", err)
+	}
+	if got := buf.Bytes()[:3]; !bytes.Equal(got, []byte{0xda, 0x01, 0x2c}) {
+		t.Errorf("renderMsgPack() header = % x, want % x", got, []byte{0xda, 0x01, 0x2c})
+	}
+}
+
+type msgpackOrder struct {
+	ID     int64             `json:"id"`
+	Total  float64           `json:"total"`
+	Paid   bool              `json:"paid"`
+	Items  []string          `json:"items"`
+	Notes  map[string]string `json:"notes"`
+	Coupon *string           `json:"coupon"`
+}
+
+func TestBody_MsgPackRoundTrip(t *testing.T) {
+	want := msgpackOrder{
+		ID:    -4_000_000_000,
+		Total: 12.25,
+		Paid:  true,
+		Items: []string{"book", strings.Repeat("y", 40)},
+		Notes: map[string]string{"gift": "yes"},
+	}
+
+	var buf bytes.Buffer
+	if err := renderMsgPack(&buf, want); err != nil {
+		t.Fatalf("renderMsgPack() error = %v", err)
+	}
+
+	for _, contentType := range []string{MIMEApplicationMsgPack, MIMEApplicationXMsgPack} {
+		ctx := newBindingContext(contentType, bytes.NewReader(buf.Bytes()))
+		var got msgpackOrder
+		if err := ctx.Body(&got); err != nil {
+			t.Fatalf("Body(%s) error = %v", contentType, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Body(%s) = %+v, want %+v", contentType, got, want)
+		}
+	}
+}
+
+func TestBody_MsgPackStrict(t *testing.T) {
+	body := []byte{0x81, 0xa5, 'e', 'x', 't', 'r', 'a', 0x01}
+	ctx := newBindingContext(MIMEApplicationMsgPack, bytes.NewReader(body))
+	ctx.SetBodyOptions(BodyOptions{StrictJSON: true})
+
+	var got msgpackOrder
+	if err := ctx.Body(&got); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Body() error = %v, want %v", err, ErrBadRequest)
+	}
+}
+
+func TestBody_MsgPackInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{"truncated", []byte{0x92, 0x01}},
+		{"extension", []byte{0xd4, 0x01, 0x02}},
+		{"reserved code", []byte{0xc1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newBindingContext(MIMEApplicationMsgPack, bytes.NewReader(tt.body))
+			var got []int
+			if err := ctx.Body(&got); !errors.Is(err, ErrBadRequest) {
+				t.Errorf("Body() error = %v, want %v", err, ErrBadRequest)
+			}
+		})
+	}
+}
+
+func TestBody_MsgPackHugeLength(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{"bin 32", []byte{0xc6, 0xff, 0xff, 0xff, 0xff}},
+		{"str 32", []byte{0xdb, 0xff, 0xff, 0xff, 0xff}},
+		{"array 32", []byte{0xdd, 0x0f, 0xff, 0xff, 0xff}},
+		{"map 32", []byte{0xdf, 0xff, 0xff, 0xff, 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newBindingContext(MIMEApplicationMsgPack, bytes.NewReader(tt.body))
+			ctx.SetBodyOptions(BodyOptions{MaxBytes: 1024})
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			var got interface{}
+			if err := ctx.Body(&got); !errors.Is(err, ErrBadRequest) {
+				t.Errorf("Body() error = %v, want %v", err, ErrBadRequest)
+			}
+			runtime.ReadMemStats(&after)
+			if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+				t.Errorf("Body() allocated %d bytes, want at most %d", allocated, 1<<20)
+			}
+		})
+	}
+}
+
+func TestBody_MsgPackDepth(t *testing.T) {
+	tests := []struct {
+		name    string
+		depth   int
+		wantErr bool
+	}{
+		{"nested", 100, false},
+		{"too deep", 4 << 20, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := append(bytes.Repeat([]byte{0x91}, tt.depth), 0x01)
+			ctx := newBindingContext(MIMEApplicationMsgPack, bytes.NewReader(body))
+			ctx.SetBodyOptions(BodyOptions{MaxBytes: 8 << 20})
+
+			var got interface{}
+			err := ctx.Body(&got)
+			if tt.wantErr && !errors.Is(err, ErrBadRequest) {
+				t.Errorf("Body() error = %v, want %v", err, ErrBadRequest)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Body() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestBody_MsgPackCodec(t *testing.T) {
+	body := []byte{0x81, 0xa5, 'p', 'r', 'i', 'c', 'e', 0xcb, 0x40, 0x33, 0xfd, 0x70, 0xa3, 0xd7, 0x0a, 0x3d}
+	ctx := newBindingContext(MIMEApplicationMsgPack, bytes.NewReader(body))
+	ctx.SetJSONCodec(StdJSONCodec{UseNumber: true})
+
+	var got map[string]interface{}
+	if err := ctx.Body(&got); err != nil {
+		t.Fatalf("Body() error = %v", err)
+	}
+	if _, ok := got["price"].(json.Number); !ok {
+		t.Errorf("Body() price = %#v, want a json.Number", got["price"])
+	}
+}
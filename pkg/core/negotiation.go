@@ -0,0 +1,349 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Media types rendered by the built-in renderers, besides MIMEApplicationJSON,
+// MIMEApplicationXML and MIMEApplicationMsgPack.
+const (
+	MIMEApplicationYAML = "application/yaml"
+	MIMETextPlain       = "text/plain"
+)
+
+// MediaRange is an entry of an Accept header, such as "text/*;q=0.5".
+type MediaRange struct {
+	// Type is the main type, "*" for a wildcard
+	Type string
+	// Subtype is the subtype, "*" for a wildcard
+	Subtype string
+	// Quality is the weight of the range, between 0 and 1
+	Quality float64
+}
+
+// Matches reports whether mediaType, given without parameters, belongs to the range.
+func (m MediaRange) Matches(mediaType string) bool {
+	typ, subtype, _ := strings.Cut(strings.ToLower(mediaType), "/")
+	return (m.Type == "*" || m.Type == typ) && (m.Subtype == "*" || m.Subtype == subtype)
+}
+
+// specificity ranks exact ranges above "type/*" and "type/*" above "*/*".
+func (m MediaRange) specificity() int {
+	switch {
+	case m.Type == "*":
+		return 0
+	case m.Subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// ParseAccept parses an Accept header into media ranges ordered by
+// preference: higher quality first, then more specific ranges, then header
+// order. Malformed entries are skipped.
+//
+// Example:
+//
+//	core.ParseAccept("text/*;q=0.5, application/json")
+//	// [{application json 1} {text * 0.5}]
+func ParseAccept(header string) []MediaRange {
+	var ranges []MediaRange
+	for _, entry := range strings.Split(header, ",") {
+		parts := strings.Split(entry, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(parts[0]))
+		if mediaType == "*" {
+			mediaType = "*/*"
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+			continue
+		}
+
+		r := MediaRange{Type: typ, Subtype: subtype, Quality: 1}
+		valid := true
+		for _, param := range parts[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if !strings.EqualFold(strings.TrimSpace(key), "q") {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || q < 0 || q > 1 {
+				valid = false
+				break
+			}
+			r.Quality = q
+		}
+		if valid {
+			ranges = append(ranges, r)
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].Quality != ranges[j].Quality {
+			return ranges[i].Quality > ranges[j].Quality
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+	return ranges
+}
+
+// acceptQuality returns the quality ranges give to mediaType: that of the
+// most specific matching range, or 0 if none matches.
+func acceptQuality(ranges []MediaRange, mediaType string) float64 {
+	quality, specificity := 0.0, -1
+	for _, r := range ranges {
+		if r.Matches(mediaType) && r.specificity() > specificity {
+			quality, specificity = r.Quality, r.specificity()
+		}
+	}
+	return quality
+}
+
+// NegotiateContentType returns the offer preferred by an Accept header, or
+// "" if the header accepts none of them. Offers are listed in the server's
+// order of preference, which breaks ties; an empty header accepts the first.
+func NegotiateContentType(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		if len(offers) == 0 {
+			return ""
+		}
+		return offers[0]
+	}
+
+	ranges := ParseAccept(accept)
+	best, bestQuality := "", 0.0
+	for _, offer := range offers {
+		if q := acceptQuality(ranges, offer); q > bestQuality {
+			best, bestQuality = offer, q
+		}
+	}
+	return best
+}
+
+// Renderer encodes response data in a given media type.
+//
+// Renderers for additional media types are registered with RegisterRenderer:
+//
+//	core.RegisterRenderer("text/csv", core.RendererFunc(func(w io.Writer, data interface{}) error {
+//	    return writeCSV(w, data)
+//	}))
+type Renderer interface {
+	// Render writes data to w.
+	Render(w io.Writer, data interface{}) error
+}
+
+// RendererFunc is an adapter to allow ordinary functions to be used as renderers.
+type RendererFunc func(w io.Writer, data interface{}) error
+
+// Render calls f(w, data).
+func (f RendererFunc) Render(w io.Writer, data interface{}) error {
+	return f(w, data)
+}
+
+// renderers maps media types to the renderer producing them. order lists the
+// media types in the server's order of preference.
+var renderers = struct {
+	sync.RWMutex
+	order []string
+	m     map[string]Renderer
+}{
+	order: []string{MIMEApplicationJSON, MIMEApplicationXML, MIMEApplicationYAML, MIMETextPlain, MIMEApplicationMsgPack},
+	m: map[string]Renderer{
+		MIMEApplicationJSON:    jsonRenderer{},
+		MIMEApplicationXML:     RendererFunc(renderXML),
+		MIMEApplicationYAML:    RendererFunc(renderYAML),
+		MIMETextPlain:          RendererFunc(renderText),
+		MIMEApplicationMsgPack: RendererFunc(renderMsgPack),
+	},
+}
+
+// RegisterRenderer registers renderer for mediaType. A new media type is
+// offered after the existing ones; registering a known media type replaces
+// its renderer and keeps its rank.
+func RegisterRenderer(mediaType string, renderer Renderer) {
+	mediaType = strings.ToLower(mediaType)
+
+	renderers.Lock()
+	defer renderers.Unlock()
+	if _, ok := renderers.m[mediaType]; !ok {
+		renderers.order = append(renderers.order, mediaType)
+	}
+	renderers.m[mediaType] = renderer
+}
+
+// negotiateRenderer returns the renderer preferred by an Accept header and
+// its media type, or false if no renderer is acceptable. The offered media
+// types are returned as well for error reporting.
+func negotiateRenderer(accept string) (string, Renderer, []string, bool) {
+	renderers.RLock()
+	defer renderers.RUnlock()
+
+	mediaType := NegotiateContentType(accept, renderers.order)
+	if mediaType == "" {
+		return "", nil, append([]string(nil), renderers.order...), false
+	}
+	return mediaType, renderers.m[mediaType], nil, true
+}
+
+// jsonRenderer is the built-in JSON renderer. Context.Negotiate writes it
+// with Context.JSON so that the configured JSONCodec applies.
+type jsonRenderer struct{}
+
+// Render encodes data with a zero StdJSONCodec.
+func (jsonRenderer) Render(w io.Writer, data interface{}) error {
+	return StdJSONCodec{}.Encode(w, data)
+}
+
+// renderXML encodes data as an XML document. Values encoding/xml cannot
+// handle, such as maps, are written from their JSON document form inside a
+// <response> element, each object member becoming an element and each array
+// item an <item> element.
+func renderXML(w io.Writer, data interface{}) error {
+	raw, err := xml.Marshal(data)
+	var unsupported *xml.UnsupportedTypeError
+	if errors.As(err, &unsupported) {
+		document, err := toDocument(data)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		writeXMLElement(&buf, "response", document)
+		raw = buf.Bytes()
+	} else if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	_, err = w.Write(raw)
+	return err
+}
+
+// writeXMLElement writes a document value as an element named name.
+func writeXMLElement(buf *bytes.Buffer, name string, v interface{}) {
+	name = xmlName(name)
+	buf.WriteString("<" + name + ">")
+	switch v := v.(type) {
+	case orderedMap:
+		for _, entry := range v {
+			writeXMLElement(buf, entry.key, entry.value)
+		}
+	case []interface{}:
+		for _, item := range v {
+			writeXMLElement(buf, "item", item)
+		}
+	case nil:
+	default:
+		_ = xml.EscapeText(buf, []byte(fmt.Sprint(v)))
+	}
+	buf.WriteString("</" + name + ">")
+}
+
+// xmlName turns an object key into a valid element name by replacing the
+// characters XML names do not allow with underscores.
+func xmlName(key string) string {
+	name := []rune(key)
+	for i, r := range name {
+		valid := r == '_' || unicode.IsLetter(r) ||
+			(i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)))
+		if !valid {
+			name[i] = '_'
+		}
+	}
+	if len(name) == 0 {
+		return "_"
+	}
+	return string(name)
+}
+
+// renderText writes strings, byte slices, errors and fmt.Stringer values as
+// is and any other value in its default format.
+func renderText(w io.Writer, data interface{}) error {
+	var err error
+	switch v := data.(type) {
+	case string:
+		_, err = io.WriteString(w, v)
+	case []byte:
+		_, err = w.Write(v)
+	case fmt.Stringer:
+		_, err = io.WriteString(w, v.String())
+	case error:
+		_, err = io.WriteString(w, v.Error())
+	default:
+		_, err = fmt.Fprintf(w, "%v", v)
+	}
+	return err
+}
+
+// orderedMap is a JSON object decoded with its keys in document order.
+type orderedMap []mapEntry
+
+// mapEntry is a member of an orderedMap.
+type mapEntry struct {
+	key   string
+	value interface{}
+}
+
+// toDocument converts data into its JSON document form, made of nil, bool,
+// json.Number, string, []interface{} and orderedMap values. Renderers of
+// other formats use it so that they honor json tags and json.Marshaler.
+func toDocument(data interface{}) (interface{}, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	return decodeDocument(decoder)
+}
+
+// decodeDocument reads the next JSON value from decoder.
+func decodeDocument(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		m := orderedMap{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeDocument(decoder)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, mapEntry{key: key.(string), value: value})
+		}
+		_, err = decoder.Token()
+		return m, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for decoder.More() {
+			value, err := decodeDocument(decoder)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = decoder.Token()
+		return list, err
+	default:
+		return token, nil
+	}
+}
@@ -0,0 +1,204 @@
+package core
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAccept(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []MediaRange
+	}{
+		{"empty", "", nil},
+		{"single", "application/json", []MediaRange{{"application", "json", 1}}},
+		{
+			"quality order",
+			"text/*;q=0.5, application/json, application/xml;q=0.9",
+			[]MediaRange{{"application", "json", 1}, {"application", "xml", 0.9}, {"text", "*", 0.5}},
+		},
+		{
+			"specificity breaks ties",
+			"*/*, text/*, text/html",
+			[]MediaRange{{"text", "html", 1}, {"text", "*", 1}, {"*", "*", 1}},
+		},
+		{"bare wildcard", "*", []MediaRange{{"*", "*", 1}}},
+		{"case insensitive", "Application/JSON;Q=0.2", []MediaRange{{"application", "json", 0.2}}},
+		{"other parameters", "text/html;level=1;q=0.7", []MediaRange{{"text", "html", 0.7}}},
+		{"invalid quality skipped", "text/html;q=2, application/json;q=abc, text/plain", []MediaRange{{"text", "plain", 1}}},
+		{"malformed skipped", "json, */json, text/plain", []MediaRange{{"text", "plain", 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseAccept(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAccept(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	offers := []string{MIMEApplicationJSON, MIMEApplicationXML, MIMETextPlain}
+
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{"empty header", "", MIMEApplicationJSON},
+		{"exact", "application/xml", MIMEApplicationXML},
+		{"highest quality", "application/json;q=0.5, text/plain", MIMETextPlain},
+		{"server order breaks ties", "text/plain, application/xml", MIMEApplicationXML},
+		{"type wildcard", "text/*", MIMETextPlain},
+		{"full wildcard", "*/*", MIMEApplicationJSON},
+		{"specific range wins", "*/*;q=0.1, application/json;q=0", MIMEApplicationXML},
+		{"refused", "application/json;q=0", ""},
+		{"none acceptable", "image/png", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NegotiateContentType(tt.accept, offers); got != tt.want {
+				t.Errorf("NegotiateContentType(%q) = %q, want %q", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
+
+type negotiationUser struct {
+	ID   int    `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+func TestContext_Negotiate(t *testing.T) {
+	user := negotiationUser{ID: 1, Name: "alice"}
+
+	tests := []struct {
+		name        string
+		accept      string
+		data        interface{}
+		contentType string
+		body        string
+	}{
+		{"default JSON", "", user, "application/json", `{"id":1,"name":"alice"}`},
+		{"XML", "application/xml", user, "application/xml", xml.Header + "<negotiationUser><id>1</id><name>alice</name></negotiationUser>"},
+		{"XML map", "application/xml", map[string]interface{}{"ok": true, "tags": []string{"a", "b"}}, "application/xml",
+			xml.Header + "<response><ok>true</ok><tags><item>a</item><item>b</item></tags></response>"},
+		{"YAML", "application/yaml", user, "application/yaml", "id: 1\nname: alice"},
+		{"text", "text/plain", "hello", "text/plain; charset=utf-8", "hello"},
+		{"q-values", "application/json;q=0.1, application/yaml", user, "application/yaml", "id: 1\nname: alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/users/1", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			ctx := NewContext(w, r)
+
+			if err := ctx.Negotiate(201, tt.data); err != nil {
+				t.Fatalf("Negotiate() error = %v", err)
+			}
+			if w.Code != 201 {
+				t.Errorf("Negotiate() status = %v, want %v", w.Code, 201)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Negotiate() Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Negotiate() Vary = %q, want %q", got, "Accept")
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.body {
+				t.Errorf("Negotiate() body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestContext_NegotiateNotAcceptable(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/users/1", nil)
+	r.Header.Set("Accept", "image/png")
+	ctx := NewContext(w, r)
+
+	err := ctx.Negotiate(200, negotiationUser{ID: 1})
+	if !errors.Is(err, ErrNotAcceptable) {
+		t.Fatalf("Negotiate() error = %v, want %v", err, ErrNotAcceptable)
+	}
+	if ctx.IsWritten() {
+		t.Error("Negotiate() wrote a response for an unacceptable request")
+	}
+}
+
+func TestDefaultFilter_Negotiates(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"text", "text/plain", "text/plain; charset=utf-8", "404 Not Found: no user"},
+		{"XML", "application/xml", "application/xml", "<ErrorResponse><statusCode>404</statusCode>"},
+		{"unacceptable falls back to JSON", "image/png", "application/json", `{"statusCode":404`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/users/1", nil)
+			r.Header.Set("Accept", tt.accept)
+			ctx := NewContext(w, r)
+
+			if err := (DefaultFilter{}).Catch(NotFound("no user"), ctx); err != nil {
+				t.Fatalf("Catch() error = %v", err)
+			}
+			if w.Code != 404 {
+				t.Errorf("Catch() status = %v, want %v", w.Code, 404)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Catch() Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("Catch() body = %q, want it to contain %q", w.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestRegisterRenderer(t *testing.T) {
+	const csv = "text/csv"
+	RegisterRenderer(csv, RendererFunc(func(w io.Writer, data interface{}) error {
+		_, err := io.WriteString(w, "id,name\n1,alice\n")
+		return err
+	}))
+	defer func() {
+		renderers.Lock()
+		delete(renderers.m, csv)
+		renderers.order = renderers.order[:len(renderers.order)-1]
+		renderers.Unlock()
+	}()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/users", nil)
+	r.Header.Set("Accept", "text/csv")
+	ctx := NewContext(w, r)
+
+	if err := ctx.Negotiate(200, nil); err != nil {
+		t.Fatalf("Negotiate() error = %v", err)
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+		t.Errorf("Negotiate() Content-Type = %q, want %q", got, "text/csv; charset=utf-8")
+	}
+	if got := w.Body.String(); got != "id,name\n1,alice\n" {
+		t.Errorf("Negotiate() body = %q, want %q", got, "id,name\n1,alice\n")
+	}
+}
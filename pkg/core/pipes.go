@@ -0,0 +1,325 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pipe metadata types identifying the request value a pipe is bound to.
+const (
+	// PipeTypeParam marks a path parameter; PipeMetadata.Data holds its name
+	PipeTypeParam = "param"
+	// PipeTypeQuery marks a query parameter; PipeMetadata.Data holds its key
+	PipeTypeQuery = "query"
+	// PipeTypeBody marks the decoded request body
+	PipeTypeBody = "body"
+)
+
+// PipeFunc is an adapter to allow ordinary functions to be used as pipes.
+type PipeFunc func(value interface{}, metadata PipeMetadata) (interface{}, error)
+
+// Transform calls f(value, metadata).
+func (f PipeFunc) Transform(value interface{}, metadata PipeMetadata) (interface{}, error) {
+	return f(value, metadata)
+}
+
+// PipeBinding binds a chain of pipes to a path parameter, a query parameter
+// or the request body. Routes list bindings in their Pipes; before the
+// handler runs, each binding extracts its raw value, passes it through its
+// pipes and stores the result, which handlers read with ParamAs, QueryAs
+// and BodyAs.
+//
+// Example:
+//
+//	router.HandleWithOptions("GET", "/users/:id", handler, core.RouteOptions{
+//	    Pipes: []core.Pipe{
+//	        core.Param("id", core.ParseInt()),
+//	        core.Query("sort", core.DefaultValue("name"), core.ParseEnum("name", "age")),
+//	    },
+//	})
+//
+//	func handler(ctx core.Context) error {
+//	    id := core.ParamAs[int](ctx, "id")
+//	    ...
+//	}
+type PipeBinding struct {
+	// Metadata identifies the bound request value
+	Metadata PipeMetadata
+	// Pipes transform the value in order
+	Pipes []Pipe
+
+	extract func(ctx Context) (interface{}, error)
+}
+
+// Param binds pipes to the path parameter name.
+func Param(name string, pipes ...Pipe) *PipeBinding {
+	return &PipeBinding{
+		Metadata: PipeMetadata{Type: PipeTypeParam, Data: name},
+		Pipes:    pipes,
+		extract: func(ctx Context) (interface{}, error) {
+			return ctx.Param(name), nil
+		},
+	}
+}
+
+// Query binds pipes to the query parameter key.
+func Query(key string, pipes ...Pipe) *PipeBinding {
+	return &PipeBinding{
+		Metadata: PipeMetadata{Type: PipeTypeQuery, Data: key},
+		Pipes:    pipes,
+		extract: func(ctx Context) (interface{}, error) {
+			return ctx.Query(key), nil
+		},
+	}
+}
+
+// Body binds pipes to the request body, decoded into a value of type T with
+// Context.Body.
+func Body[T any](pipes ...Pipe) *PipeBinding {
+	return &PipeBinding{
+		Metadata: PipeMetadata{Type: PipeTypeBody},
+		Pipes:    pipes,
+		extract: func(ctx Context) (interface{}, error) {
+			var body T
+			if err := ctx.Body(&body); err != nil {
+				return nil, err
+			}
+			return body, nil
+		},
+	}
+}
+
+// Transform passes value through the pipes of the binding in order.
+func (b *PipeBinding) Transform(value interface{}, metadata PipeMetadata) (interface{}, error) {
+	return transform(value, metadata, b.Pipes)
+}
+
+// ApplyPipes resolves the pipe bindings among pipes for the request of ctx.
+// Pipes that are not bindings apply to every bound value, ahead of the
+// binding's own pipes.
+//
+// Failures of all bindings are collected into ValidationErrors: errors
+// returned as ValidationErrors are kept as is and other errors become a
+// ValidationError for the bound field. An HTTPException is returned
+// unchanged, ending the request with its own status.
+func ApplyPipes(ctx Context, pipes []Pipe) error {
+	var shared []Pipe
+	var bindings []*PipeBinding
+	for _, pipe := range pipes {
+		if binding, ok := pipe.(*PipeBinding); ok {
+			bindings = append(bindings, binding)
+		} else {
+			shared = append(shared, pipe)
+		}
+	}
+
+	var failures ValidationErrors
+	for _, binding := range bindings {
+		raw, err := binding.extract(ctx)
+		if err != nil {
+			return err
+		}
+
+		value, err := transform(raw, binding.Metadata, shared)
+		if err == nil {
+			value, err = binding.Transform(value, binding.Metadata)
+		}
+
+		var exception *HTTPException
+		var validationErrors ValidationErrors
+		switch {
+		case err == nil:
+			ctx.SetValue(pipeKey(binding.Metadata), value)
+		case errors.As(err, &exception):
+			return err
+		case errors.As(err, &validationErrors):
+			failures = append(failures, validationErrors...)
+		default:
+			failures = append(failures, ValidationError{
+				Field:   fieldName(binding.Metadata),
+				Message: err.Error(),
+				Value:   raw,
+			})
+		}
+	}
+
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
+}
+
+// transform passes value through pipes in order, stopping at the first error.
+func transform(value interface{}, metadata PipeMetadata, pipes []Pipe) (interface{}, error) {
+	var err error
+	for _, pipe := range pipes {
+		if value, err = pipe.Transform(value, metadata); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+// ParamAs returns the piped value of the path parameter name, or the zero
+// value of T if no binding produced a T for it.
+func ParamAs[T any](ctx Context, name string) T {
+	return piped[T](ctx, PipeMetadata{Type: PipeTypeParam, Data: name})
+}
+
+// QueryAs returns the piped value of the query parameter key, or the zero
+// value of T if no binding produced a T for it.
+func QueryAs[T any](ctx Context, key string) T {
+	return piped[T](ctx, PipeMetadata{Type: PipeTypeQuery, Data: key})
+}
+
+// BodyAs returns the piped request body, or the zero value of T if no
+// binding produced a T for it.
+func BodyAs[T any](ctx Context) T {
+	return piped[T](ctx, PipeMetadata{Type: PipeTypeBody})
+}
+
+// piped returns the value stored for metadata by ApplyPipes.
+func piped[T any](ctx Context, metadata PipeMetadata) T {
+	value, _ := ctx.GetValue(pipeKey(metadata)).(T)
+	return value
+}
+
+// pipeKey is the Context value key under which the piped value of metadata is stored.
+func pipeKey(metadata PipeMetadata) string {
+	return fmt.Sprintf("goaegis.pipe.%s.%v", metadata.Type, metadata.Data)
+}
+
+// fieldName returns the field name reported in validation errors for metadata.
+func fieldName(metadata PipeMetadata) string {
+	if name, ok := metadata.Data.(string); ok && name != "" {
+		return name
+	}
+	return metadata.Type
+}
+
+// ParseInt returns a pipe converting a string into an int.
+func ParseInt() Pipe {
+	return PipeFunc(func(value interface{}, metadata PipeMetadata) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok {
+			return value, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer", fieldName(metadata))
+		}
+		return n, nil
+	})
+}
+
+// ParseBool returns a pipe converting a string into a bool. It accepts the
+// values understood by strconv.ParseBool.
+func ParseBool() Pipe {
+	return PipeFunc(func(value interface{}, metadata PipeMetadata) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok {
+			return value, nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a boolean", fieldName(metadata))
+		}
+		return b, nil
+	})
+}
+
+// ParseUUID returns a pipe checking that a string is a UUID in its canonical
+// 8-4-4-4-12 hexadecimal form. The UUID is returned lowercased.
+func ParseUUID() Pipe {
+	return PipeFunc(func(value interface{}, metadata PipeMetadata) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok {
+			return value, nil
+		}
+		if !isUUID(s) {
+			return nil, fmt.Errorf("%s must be a UUID", fieldName(metadata))
+		}
+		return strings.ToLower(s), nil
+	})
+}
+
+// ParseEnum returns a pipe checking that a string is one of values.
+func ParseEnum(values ...string) Pipe {
+	return PipeFunc(func(value interface{}, metadata PipeMetadata) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok {
+			return value, nil
+		}
+		for _, v := range values {
+			if s == v {
+				return s, nil
+			}
+		}
+		return nil, fmt.Errorf("%s must be one of %s", fieldName(metadata), strings.Join(values, ", "))
+	})
+}
+
+// ParseTime returns a pipe converting a string into a time.Time using layout.
+// An empty layout defaults to time.RFC3339.
+func ParseTime(layout string) Pipe {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return PipeFunc(func(value interface{}, metadata PipeMetadata) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok {
+			return value, nil
+		}
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a time in the format %s", fieldName(metadata), layout)
+		}
+		return t, nil
+	})
+}
+
+// DefaultValue returns a pipe replacing a missing value, nil or the empty
+// string, with defaultValue. Place it before parsing pipes to make a
+// parameter optional.
+func DefaultValue(defaultValue interface{}) Pipe {
+	return PipeFunc(func(value interface{}, metadata PipeMetadata) (interface{}, error) {
+		if value == nil || value == "" {
+			return defaultValue, nil
+		}
+		return value, nil
+	})
+}
+
+// Trim returns a pipe removing leading and trailing white space from strings.
+func Trim() Pipe {
+	return PipeFunc(func(value interface{}, metadata PipeMetadata) (interface{}, error) {
+		if s, ok := value.(string); ok {
+			return strings.TrimSpace(s), nil
+		}
+		return value, nil
+	})
+}
+
+// isUUID reports whether s is a UUID in its canonical 8-4-4-4-12 form.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
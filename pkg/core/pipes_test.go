@@ -0,0 +1,159 @@
+package core
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuiltinPipes(t *testing.T) {
+	metadata := PipeMetadata{Type: PipeTypeQuery, Data: "field"}
+	when := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		pipe     Pipe
+		value    interface{}
+		expected interface{}
+		wantErr  string
+	}{
+		{"ParseInt", ParseInt(), "42", 42, ""},
+		{"ParseInt negative", ParseInt(), "-7", -7, ""},
+		{"ParseInt invalid", ParseInt(), "4x", nil, "field must be an integer"},
+		{"ParseInt non-string", ParseInt(), 10, 10, ""},
+		{"ParseBool", ParseBool(), "true", true, ""},
+		{"ParseBool numeric", ParseBool(), "0", false, ""},
+		{"ParseBool invalid", ParseBool(), "yes", nil, "field must be a boolean"},
+		{"ParseUUID", ParseUUID(), "123E4567-E89B-12D3-A456-426614174000", "123e4567-e89b-12d3-a456-426614174000", ""},
+		{"ParseUUID invalid", ParseUUID(), "123e4567e89b12d3a456426614174000", nil, "field must be a UUID"},
+		{"ParseUUID bad digit", ParseUUID(), "123e4567-e89b-12d3-a456-42661417400g", nil, "field must be a UUID"},
+		{"ParseEnum", ParseEnum("asc", "desc"), "desc", "desc", ""},
+		{"ParseEnum invalid", ParseEnum("asc", "desc"), "up", nil, "field must be one of asc, desc"},
+		{"ParseTime", ParseTime(""), "2026-01-02T03:04:05Z", when, ""},
+		{"ParseTime layout", ParseTime("2006-01-02"), "2026-01-02", time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC), ""},
+		{"ParseTime invalid", ParseTime("2006-01-02"), "02/01/2026", nil, "field must be a time in the format 2006-01-02"},
+		{"DefaultValue empty", DefaultValue("10"), "", "10", ""},
+		{"DefaultValue nil", DefaultValue(5), nil, 5, ""},
+		{"DefaultValue present", DefaultValue("10"), "3", "3", ""},
+		{"Trim", Trim(), "  alice \n", "alice", ""},
+		{"Trim non-string", Trim(), 3, 3, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.pipe.Transform(test.value, metadata)
+			if test.wantErr != "" {
+				if err == nil || err.Error() != test.wantErr {
+					t.Errorf("Transform() error = %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Transform() error = %v", err)
+			}
+			if tm, ok := test.expected.(time.Time); ok {
+				if !tm.Equal(got.(time.Time)) {
+					t.Errorf("Transform() = %v, want %v", got, test.expected)
+				}
+				return
+			}
+			if got != test.expected {
+				t.Errorf("Transform() = %v, want %v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestApplyPipes(t *testing.T) {
+	ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42?active=1&name=%20alice%20", nil))
+	ctx.SetParam("id", "42")
+
+	err := ApplyPipes(ctx, []Pipe{
+		Trim(),
+		Param("id", ParseInt()),
+		Query("active", ParseBool()),
+		Query("name"),
+		Query("page", DefaultValue("1"), ParseInt()),
+	})
+	if err != nil {
+		t.Fatalf("ApplyPipes() error = %v", err)
+	}
+
+	if got := ParamAs[int](ctx, "id"); got != 42 {
+		t.Errorf("ParamAs() = %v, want 42", got)
+	}
+	if got := QueryAs[bool](ctx, "active"); !got {
+		t.Errorf("QueryAs() = %v, want true", got)
+	}
+	if got := QueryAs[string](ctx, "name"); got != "alice" {
+		t.Errorf("QueryAs() = %q, want %q", got, "alice")
+	}
+	if got := QueryAs[int](ctx, "page"); got != 1 {
+		t.Errorf("QueryAs() = %v, want 1", got)
+	}
+	if got := QueryAs[int](ctx, "active"); got != 0 {
+		t.Errorf("QueryAs() with the wrong type = %v, want 0", got)
+	}
+}
+
+func TestApplyPipes_ValidationErrors(t *testing.T) {
+	ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/x?sort=up", nil))
+	ctx.SetParam("id", "x")
+
+	err := ApplyPipes(ctx, []Pipe{
+		Param("id", ParseInt()),
+		Query("sort", ParseEnum("asc", "desc")),
+	})
+
+	var validationErrors ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("ApplyPipes() error = %v, want ValidationErrors", err)
+	}
+	if len(validationErrors) != 2 {
+		t.Fatalf("len(ValidationErrors) = %d, want 2", len(validationErrors))
+	}
+	if validationErrors[0].Field != "id" || validationErrors[0].Value != "x" {
+		t.Errorf("ValidationErrors[0] = %+v, want field id with value x", validationErrors[0])
+	}
+	if validationErrors[1].Field != "sort" {
+		t.Errorf("ValidationErrors[1] = %+v, want field sort", validationErrors[1])
+	}
+}
+
+func TestApplyPipes_Exception(t *testing.T) {
+	ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/7", nil))
+	ctx.SetParam("id", "7")
+
+	exists := PipeFunc(func(value interface{}, metadata PipeMetadata) (interface{}, error) {
+		return nil, NotFound("user %v not found", value)
+	})
+	if err := ApplyPipes(ctx, []Pipe{Param("id", ParseInt(), exists)}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ApplyPipes() error = %v, want a 404 exception", err)
+	}
+}
+
+func TestBody(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"alice"}`))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := NewContext(httptest.NewRecorder(), req)
+
+	if err := ApplyPipes(ctx, []Pipe{Body[payload]()}); err != nil {
+		t.Fatalf("ApplyPipes() error = %v", err)
+	}
+	if got := BodyAs[payload](ctx); got.Name != "alice" {
+		t.Errorf("BodyAs() = %+v, want name alice", got)
+	}
+
+	req = httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":`))
+	req.Header.Set("Content-Type", "application/json")
+	ctx = NewContext(httptest.NewRecorder(), req)
+	if err := ApplyPipes(ctx, []Pipe{Body[payload]()}); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("ApplyPipes() error = %v, want a 400 exception", err)
+	}
+}
@@ -0,0 +1,301 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies lists the networks of the reverse proxies whose forwarding
+// headers, selected by ProxyHeaders, are believed. Headers of requests coming
+// from other addresses are ignored, so clients cannot spoof them.
+type TrustedProxies []netip.Prefix
+
+// ProxyHeaders selects the forwarding headers read from trusted proxies. Only
+// one family is read, so that a client cannot pass headers of the other one
+// through a proxy that does not strip them.
+type ProxyHeaders int
+
+const (
+	// XForwardedHeaders reads X-Forwarded-For, X-Forwarded-Proto and
+	// X-Forwarded-Host, and X-Real-IP when X-Forwarded-For is absent.
+	XForwardedHeaders ProxyHeaders = iota
+	// ForwardedHeader reads the Forwarded header of RFC 7239.
+	ForwardedHeader
+)
+
+// String returns the name of the header family.
+func (h ProxyHeaders) String() string {
+	switch h {
+	case XForwardedHeaders:
+		return "X-Forwarded"
+	case ForwardedHeader:
+		return "Forwarded"
+	default:
+		return "unknown"
+	}
+}
+
+// PrivateProxies are the loopback, private and link-local networks, trusted
+// when ConfigOptions.TrustProxy is set without explicit TrustedProxies.
+var PrivateProxies = MustParseTrustedProxies(
+	"127.0.0.0/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "169.254.0.0/16",
+	"::1/128", "fc00::/7", "fe80::/10",
+)
+
+// ParseTrustedProxies parses CIDR ranges and single IP addresses.
+//
+// Example:
+//
+//	proxies, err := core.ParseTrustedProxies("10.0.0.0/8", "2001:db8::1")
+func ParseTrustedProxies(cidrs ...string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", cidr)
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return proxies, nil
+}
+
+// MustParseTrustedProxies is like ParseTrustedProxies but panics on an invalid entry.
+func MustParseTrustedProxies(cidrs ...string) TrustedProxies {
+	proxies, err := ParseTrustedProxies(cidrs...)
+	if err != nil {
+		panic(err)
+	}
+	return proxies
+}
+
+// Contains reports whether addr belongs to a trusted network.
+func (p TrustedProxies) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap().WithZone("")
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteAddr parses the IP address of a RemoteAddr such as "192.0.2.1:1234"
+// or "[2001:db8::1]:1234"; addresses without a port are accepted as well.
+func remoteAddr(remote string) (netip.Addr, bool) {
+	host := remote
+	if h, _, err := net.SplitHostPort(remote); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(strings.Trim(host, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// forwardedElement is an element of a Forwarded header (RFC 7239).
+type forwardedElement struct {
+	forNode string
+	proto   string
+	host    string
+}
+
+// parseForwarded parses the Forwarded header values of a request into its
+// elements, ordered from the client to the nearest proxy.
+func parseForwarded(header http.Header) []forwardedElement {
+	var elements []forwardedElement
+	for _, value := range header.Values("Forwarded") {
+		for _, element := range splitQuoted(value, ',') {
+			var e forwardedElement
+			for _, pair := range splitQuoted(element, ';') {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					continue
+				}
+				val = strings.Trim(strings.TrimSpace(val), `"`)
+				switch strings.ToLower(strings.TrimSpace(key)) {
+				case "for":
+					e.forNode = val
+				case "proto":
+					e.proto = strings.ToLower(val)
+				case "host":
+					e.host = val
+				}
+			}
+			elements = append(elements, e)
+		}
+	}
+	return elements
+}
+
+// splitQuoted splits s on sep, ignoring separators inside quoted strings.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// nodeAddr parses a forwarded node: an IP address, optionally bracketed and
+// followed by a port. Obfuscated identifiers and "unknown" are rejected.
+func nodeAddr(node string) (netip.Addr, bool) {
+	node = strings.TrimSpace(node)
+	if addr, err := netip.ParseAddr(node); err == nil {
+		return addr.Unmap(), true
+	}
+	return remoteAddr(node)
+}
+
+// headerList returns the comma-separated entries of every value of a header.
+func headerList(header http.Header, name string) []string {
+	var list []string
+	for _, value := range header.Values(name) {
+		for _, entry := range strings.Split(value, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				list = append(list, entry)
+			}
+		}
+	}
+	return list
+}
+
+// SetTrustedProxies sets the proxies whose forwarding headers are honored by
+// ClientIP, Scheme and Host. The router calls it with the proxies of the
+// application.
+func (c *AppContext) SetTrustedProxies(proxies TrustedProxies) {
+	c.proxies = proxies
+}
+
+// SetProxyHeaders selects the forwarding headers read from trusted proxies.
+// The router calls it with the headers of the application.
+func (c *AppContext) SetProxyHeaders(headers ProxyHeaders) {
+	c.proxyHeaders = headers
+}
+
+// forwardedHops returns the hops of the forwarding chain, ordered from the
+// client to the nearest proxy, from the headers selected by SetProxyHeaders.
+// X-Forwarded-Proto and X-Forwarded-Host entries are aligned from the right
+// with the X-Forwarded-For entries.
+func (c *AppContext) forwardedHops() []forwardedElement {
+	if c.proxyHeaders == ForwardedHeader {
+		return parseForwarded(c.request.Header)
+	}
+
+	nodes := headerList(c.request.Header, "X-Forwarded-For")
+	protos := headerList(c.request.Header, "X-Forwarded-Proto")
+	hosts := headerList(c.request.Header, "X-Forwarded-Host")
+	if len(nodes) == 0 {
+		if realIP := strings.TrimSpace(c.GetHeader("X-Real-IP")); realIP != "" {
+			nodes = []string{realIP}
+		}
+	}
+
+	hops := make([]forwardedElement, max(len(nodes), len(protos), len(hosts)))
+	for i := range hops {
+		fromRight := len(hops) - i
+		if j := len(nodes) - fromRight; j >= 0 {
+			hops[i].forNode = nodes[j]
+		}
+		if j := len(protos) - fromRight; j >= 0 {
+			hops[i].proto = strings.ToLower(protos[j])
+		}
+		if j := len(hosts) - fromRight; j >= 0 {
+			hops[i].host = hosts[j]
+		}
+	}
+	return hops
+}
+
+// origin returns the client address of the request and the scheme and host
+// it requested. When the request comes from a trusted proxy, the forwarding
+// chain is walked from right to left: each hop is reported by a trusted
+// proxy, and the walk stops at the first untrusted or unusable address, the
+// client. The scheme and host are those of the last hop walked that reports
+// them; they are empty when no trusted proxy reports them.
+func (c *AppContext) origin() (client netip.Addr, proto, host string, ok bool) {
+	client, ok = remoteAddr(c.request.RemoteAddr)
+	if !ok || !c.proxies.Contains(client) {
+		return client, "", "", ok
+	}
+
+	hops := c.forwardedHops()
+	for i := len(hops) - 1; i >= 0; i-- {
+		if hops[i].proto != "" {
+			proto = hops[i].proto
+		}
+		if hops[i].host != "" {
+			host = hops[i].host
+		}
+		addr, valid := nodeAddr(hops[i].forNode)
+		if !valid {
+			// The proxy reporting an unusable address is the last known hop.
+			break
+		}
+		client = addr
+		if !c.proxies.Contains(addr) {
+			break
+		}
+	}
+	return client, proto, host, true
+}
+
+// ClientIP returns the IP address of the client. When the request comes
+// from a trusted proxy, the forwarding chain selected by SetProxyHeaders is
+// walked from right to left, skipping trusted proxies, and the first
+// untrusted address is the client. Otherwise the address of the connection
+// is returned.
+func (c *AppContext) ClientIP() string {
+	client, _, _, ok := c.origin()
+	if !ok {
+		return c.request.RemoteAddr
+	}
+	return client.String()
+}
+
+// Scheme returns "https" or "http". Requests from trusted proxies report the
+// scheme of the client connection, read with the client address from the
+// Forwarded proto parameter or the X-Forwarded-Proto header; values other
+// than http and https are ignored.
+func (c *AppContext) Scheme() string {
+	_, proto, _, _ := c.origin()
+	if proto = strings.ToLower(proto); proto == "http" || proto == "https" {
+		return proto
+	}
+	if c.request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Host returns the host from the request. Requests from trusted proxies
+// report the host requested by the client, read with the client address
+// from the Forwarded host parameter or the X-Forwarded-Host header.
+//
+// Example:
+//
+//	c.Host() might return "example.com:8080"
+func (c *AppContext) Host() string {
+	if _, _, host, _ := c.origin(); host != "" {
+		return host
+	}
+	return c.request.Host
+}
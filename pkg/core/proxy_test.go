@@ -0,0 +1,50 @@
+package core
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8", " 192.168.1.7 ", "2001:db8::/32", "::ffff:172.16.0.1")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies() error = %v", err)
+	}
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"10.20.30.40", true},
+		{"192.168.1.7", true},
+		{"192.168.1.8", false},
+		{"2001:db8::42", true},
+		{"2001:db9::1", false},
+		{"172.16.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"fe80::1%eth0", false},
+	}
+	for _, tt := range tests {
+		if got := proxies.Contains(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("Contains(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+
+	if _, err := ParseTrustedProxies("10.0.0.0/33"); err == nil {
+		t.Error("ParseTrustedProxies() should reject an invalid CIDR")
+	}
+	if _, err := ParseTrustedProxies("proxy.internal"); err == nil {
+		t.Error("ParseTrustedProxies() should reject a host name")
+	}
+}
+
+func TestPrivateProxies(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1", "10.1.2.3", "172.20.0.1", "192.168.0.10", "::1", "fd00::1", "fe80::1%eth0"} {
+		if !PrivateProxies.Contains(netip.MustParseAddr(addr)) {
+			t.Errorf("PrivateProxies.Contains(%s) = false, want true", addr)
+		}
+	}
+	if PrivateProxies.Contains(netip.MustParseAddr("203.0.113.1")) {
+		t.Error("PrivateProxies.Contains(203.0.113.1) = true, want false")
+	}
+}
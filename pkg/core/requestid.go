@@ -0,0 +1,58 @@
+package core
+
+import "context"
+
+// RequestIDKey is the key of the request ID among the values of a Context.
+const RequestIDKey = "requestID"
+
+// requestIDKey is the request context key of the request ID.
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID, for
+// outbound calls made on behalf of a request.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or "" if none.
+//
+// Example:
+//
+//	req, _ := http.NewRequestWithContext(ctx, "GET", billingURL, nil)
+//	req.Header.Set("X-Request-ID", core.RequestIDFromContext(ctx))
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestLogger returns log with a request_id field when ctx carries a
+// request ID, so that the records of services tie back to the request.
+//
+// Example:
+//
+//	func (s *UserService) Create(ctx context.Context, user User) error {
+//	    core.RequestLogger(s.log, ctx).Info("creating user", "email", user.Email)
+//	    ...
+//	}
+func RequestLogger(log Logger, ctx context.Context) Logger {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return log.With("request_id", id)
+	}
+	return log
+}
+
+// SetRequestID stores the ID of the request among the values of the context
+// under RequestIDKey, and makes Context return a context.Context carrying it.
+// The request ID middleware calls it.
+func (c *AppContext) SetRequestID(id string) {
+	c.SetValue(RequestIDKey, id)
+	c.requestID = id
+}
+
+// RequestID returns the ID of the request set with SetRequestID, or "" if none.
+func (c *AppContext) RequestID() string {
+	if id, ok := c.GetValue(RequestIDKey).(string); ok {
+		return id
+	}
+	return RequestIDFromContext(c.Context())
+}
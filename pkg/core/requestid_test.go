@@ -0,0 +1,48 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+// recordingLogger is a Logger recording the fields added with With.
+type recordingLogger struct {
+	Logger
+	fields []interface{}
+}
+
+func (l *recordingLogger) With(keyvals ...interface{}) Logger {
+	return &recordingLogger{fields: append(append([]interface{}(nil), l.fields...), keyvals...)}
+}
+
+func TestContext_RequestID(t *testing.T) {
+	ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+	if ctx.RequestID() != "" {
+		t.Errorf("RequestID() = %q, want none", ctx.RequestID())
+	}
+
+	ctx.SetRequestID("req-1")
+	if ctx.RequestID() != "req-1" || ctx.GetValue(RequestIDKey) != "req-1" {
+		t.Errorf("RequestID() = %q, value %v, want %q", ctx.RequestID(), ctx.GetValue(RequestIDKey), "req-1")
+	}
+	if got := RequestIDFromContext(ctx.Context()); got != "req-1" {
+		t.Errorf("RequestIDFromContext() = %q, want %q", got, "req-1")
+	}
+	if got := NewErrorResponse(NotFound("gone"), ctx).RequestID; got != "req-1" {
+		t.Errorf("NewErrorResponse().RequestID = %q, want %q", got, "req-1")
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	base := &recordingLogger{}
+
+	if got := RequestLogger(base, context.Background()); got != base {
+		t.Errorf("RequestLogger() without ID = %v, want the logger itself", got)
+	}
+	log := RequestLogger(base, ContextWithRequestID(context.Background(), "req-2")).(*recordingLogger)
+	if fmt.Sprint(log.fields) != "[request_id req-2]" {
+		t.Errorf("RequestLogger() fields = %v, want request_id req-2", log.fields)
+	}
+}
@@ -0,0 +1,56 @@
+package core
+
+import "net/http"
+
+// resultKey is the Context value key under which handler results are stored.
+const resultKey = "goaegis.result"
+
+// Typed adapts a handler returning a value to a HandlerFunc. The value is
+// handed to the route interceptors and, once they returned, serialized as the
+// response unless one was already written.
+//
+// Example:
+//
+//	router.GET("/users/:id", core.Typed(func(ctx core.Context) (*User, error) {
+//	    return users.Find(ctx.Param("id"))
+//	}))
+func Typed[T any](handler func(ctx Context) (T, error)) HandlerFunc {
+	return func(ctx Context) error {
+		result, err := handler(ctx)
+		if err != nil {
+			return err
+		}
+		SetResult(ctx, result)
+		return nil
+	}
+}
+
+// SetResult stores the handler result of the request. Handlers not built
+// with Typed can call it to return a value through the interceptors.
+func SetResult(ctx Context, result interface{}) {
+	ctx.SetValue(resultKey, result)
+}
+
+// Result returns the handler result of the request, or nil if the handler
+// produced none.
+func Result(ctx Context) interface{} {
+	return ctx.GetValue(resultKey)
+}
+
+// WriteResult serializes a handler result as the response in the format
+// negotiated from the Accept header. A SuccessResponse is written with its
+// own status code, any other value with 200 OK.
+func WriteResult(ctx Context, result interface{}) error {
+	statusCode := http.StatusOK
+	switch r := result.(type) {
+	case SuccessResponse:
+		if r.StatusCode != 0 {
+			statusCode = r.StatusCode
+		}
+	case *SuccessResponse:
+		if r != nil && r.StatusCode != 0 {
+			statusCode = r.StatusCode
+		}
+	}
+	return ctx.Negotiate(statusCode, result)
+}
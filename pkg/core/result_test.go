@@ -0,0 +1,75 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type resultUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestTyped(t *testing.T) {
+	ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	handler := Typed(func(ctx Context) (resultUser, error) {
+		return resultUser{ID: 1, Name: "alice"}, nil
+	})
+
+	if err := handler(ctx); err != nil {
+		t.Fatalf("handler() error = %v", err)
+	}
+	if user, ok := Result(ctx).(resultUser); !ok || user.Name != "alice" {
+		t.Errorf("Result() = %v, want the handler result", Result(ctx))
+	}
+	if ctx.IsWritten() {
+		t.Error("Typed handlers should leave serialization to the router")
+	}
+}
+
+func TestTyped_Error(t *testing.T) {
+	ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	handler := Typed(func(ctx Context) (*resultUser, error) {
+		return nil, NotFound("user 1 not found")
+	})
+
+	if err := handler(ctx); !errors.Is(err, ErrNotFound) {
+		t.Errorf("handler() error = %v, want a 404 exception", err)
+	}
+	if Result(ctx) != nil {
+		t.Errorf("Result() = %v, want nil", Result(ctx))
+	}
+}
+
+func TestWriteResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     interface{}
+		statusCode int
+	}{
+		{"plain value", resultUser{ID: 1, Name: "alice"}, http.StatusOK},
+		{"success response", SuccessResponse{StatusCode: http.StatusCreated, Data: "x"}, http.StatusCreated},
+		{"success response pointer", &SuccessResponse{StatusCode: http.StatusAccepted}, http.StatusAccepted},
+		{"success response without status", SuccessResponse{Data: "x"}, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx := NewContext(w, httptest.NewRequest("GET", "/", nil))
+
+			if err := WriteResult(ctx, test.result); err != nil {
+				t.Fatalf("WriteResult() error = %v", err)
+			}
+			if w.Code != test.statusCode {
+				t.Errorf("Status code = %v, want %v", w.Code, test.statusCode)
+			}
+			if !json.Valid(w.Body.Bytes()) {
+				t.Errorf("Response body = %q, want JSON", w.Body.String())
+			}
+		})
+	}
+}
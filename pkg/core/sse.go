@@ -0,0 +1,231 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MIMETextEventStream is the media type of Server-Sent Events streams.
+const MIMETextEventStream = "text/event-stream"
+
+// DefaultSSEHeartbeat is the interval between the comments Context.SSE sends
+// to keep idle connections open through proxies. SSEWriter.SetHeartbeat
+// changes it for one stream.
+const DefaultSSEHeartbeat = 15 * time.Second
+
+// SSEEvent is a Server-Sent Event.
+type SSEEvent struct {
+	// ID sets the last event ID the client sends back when reconnecting
+	ID string
+	// Event is the event type; empty means "message"
+	Event string
+	// Data is the event payload; each line is sent as its own data field
+	Data string
+	// Retry asks the client to wait this long before reconnecting
+	Retry time.Duration
+}
+
+// SSEWriter writes events to a Server-Sent Events stream. It is safe for
+// concurrent use. Once the request is cancelled every write fails with the
+// error of its context.
+type SSEWriter interface {
+	// Send writes an event and flushes it to the client.
+	Send(event SSEEvent) error
+
+	// Comment writes a comment line, ignored by clients.
+	Comment(text string) error
+
+	// LastEventID returns the Last-Event-ID header of a reconnecting client.
+	LastEventID() string
+
+	// SetHeartbeat changes the interval of heartbeat comments; zero or less disables them.
+	SetHeartbeat(interval time.Duration)
+
+	// Done is closed when the request is cancelled or the client disconnects.
+	Done() <-chan struct{}
+}
+
+// errInvalidSSEField is returned when an event ID or type spans several lines.
+var errInvalidSSEField = errors.New("sse: event id and type must not contain line breaks")
+
+// sseWriter is the SSEWriter of Context.SSE.
+type sseWriter struct {
+	mu          sync.Mutex
+	w           io.Writer
+	rc          *http.ResponseController
+	ctx         context.Context
+	lastEventID string
+	heartbeat   *time.Ticker
+	interval    time.Duration
+}
+
+// Send writes event as id, event, retry and data fields followed by a blank line.
+func (s *sseWriter) Send(event SSEEvent) error {
+	if strings.ContainsAny(event.ID, "\r\n\x00") || strings.ContainsAny(event.Event, "\r\n") {
+		return errInvalidSSEField
+	}
+
+	var b strings.Builder
+	if event.ID != "" {
+		b.WriteString("id: " + event.ID + "\n")
+	}
+	if event.Event != "" {
+		b.WriteString("event: " + event.Event + "\n")
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", event.Retry.Milliseconds())
+	}
+	if event.Data != "" {
+		for _, line := range splitSSELines(event.Data) {
+			b.WriteString("data: " + line + "\n")
+		}
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Comment writes text as comment lines followed by a blank line.
+func (s *sseWriter) Comment(text string) error {
+	var b strings.Builder
+	for _, line := range splitSSELines(text) {
+		b.WriteString(": " + line + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// LastEventID returns the Last-Event-ID header of the request.
+func (s *sseWriter) LastEventID() string {
+	return s.lastEventID
+}
+
+// SetHeartbeat changes the interval of heartbeat comments.
+func (s *sseWriter) SetHeartbeat(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval = interval
+	if interval <= 0 {
+		s.heartbeat.Stop()
+		return
+	}
+	s.heartbeat.Reset(interval)
+}
+
+// Done is closed when the request context is done.
+func (s *sseWriter) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// write sends data and flushes it, unless the request is over. Any write
+// postpones the next heartbeat, so heartbeats only fill idle periods.
+func (s *sseWriter) write(data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(s.w, data); err != nil {
+		return err
+	}
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if s.interval > 0 {
+		s.heartbeat.Reset(s.interval)
+	}
+	return nil
+}
+
+// keepAlive sends heartbeat comments until stop is closed or the request ends.
+func (s *sseWriter) keepAlive(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-s.ctx.Done():
+			return
+		case <-s.heartbeat.C:
+			if s.Comment("heartbeat") != nil {
+				return
+			}
+		}
+	}
+}
+
+// splitSSELines splits text on CRLF, CR and LF line breaks.
+func splitSSELines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	return strings.Split(text, "\n")
+}
+
+// SSE streams Server-Sent Events produced by handler. The response is
+// flushed after each event, a heartbeat comment is sent every
+// DefaultSSEHeartbeat while the stream is idle, and the stream ends when
+// handler returns or the request is cancelled. A cancelled request is not
+// reported as an error.
+//
+// Example:
+//
+//	return c.SSE(func(stream core.SSEWriter) error {
+//	    for {
+//	        select {
+//	        case <-stream.Done():
+//	            return nil
+//	        case order := <-orders:
+//	            if err := stream.Send(core.SSEEvent{ID: order.ID, Event: "order", Data: order.JSON()}); err != nil {
+//	                return err
+//	            }
+//	        }
+//	    }
+//	})
+func (c *AppContext) SSE(handler func(stream SSEWriter) error) error {
+	c.SetHeader("Cache-Control", "no-cache")
+	c.SetHeader("X-Accel-Buffering", "no")
+
+	var handlerErr error
+	err := c.Stream(http.StatusOK, MIMETextEventStream, func(w io.Writer) error {
+		stream := &sseWriter{
+			w:           w,
+			rc:          http.NewResponseController(c.response),
+			ctx:         c.Context(),
+			lastEventID: c.GetHeader("Last-Event-ID"),
+			heartbeat:   time.NewTicker(DefaultSSEHeartbeat),
+			interval:    DefaultSSEHeartbeat,
+		}
+		defer stream.heartbeat.Stop()
+		// The stream outlives the WriteTimeout of the server.
+		if err := stream.rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		// Send the headers right away so the client sees the stream open.
+		_ = stream.rc.Flush()
+
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stream.keepAlive(stop)
+		}()
+
+		handlerErr = handler(stream)
+		close(stop)
+		wg.Wait()
+		return io.EOF
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctxErr := c.Context().Err(); ctxErr != nil && errors.Is(handlerErr, ctxErr) {
+		return nil
+	}
+	return handlerErr
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContext_SSE(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/events", nil)
+	r.Header.Set("Last-Event-ID", "41")
+	ctx := NewContext(w, r)
+
+	var lastEventID string
+	err := ctx.SSE(func(stream SSEWriter) error {
+		lastEventID = stream.LastEventID()
+		if err := stream.Send(SSEEvent{ID: "42", Event: "order", Data: "line one\nline two", Retry: 3 * time.Second}); err != nil {
+			return err
+		}
+		if err := stream.Send(SSEEvent{Data: "plain"}); err != nil {
+			return err
+		}
+		return stream.Comment("bye")
+	})
+	if err != nil {
+		t.Fatalf("SSE() error = %v", err)
+	}
+
+	if lastEventID != "41" {
+		t.Errorf("LastEventID() = %q, want %q", lastEventID, "41")
+	}
+	if got := w.Header().Get("Content-Type"); got != MIMETextEventStream {
+		t.Errorf("Content-Type = %q, want %q", got, MIMETextEventStream)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Cache-Control = %q, want %q", got, "no-cache")
+	}
+	want := "id: 42\nevent: order\nretry: 3000\ndata: line one\ndata: line two\n\n" +
+		"data: plain\n\n" +
+		": bye\n\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("SSE() body = %q, want %q", got, want)
+	}
+	if !w.Flushed {
+		t.Error("SSE() should flush the response")
+	}
+}
+
+func TestContext_SSEInvalidFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		event SSEEvent
+	}{
+		{"multi-line id", SSEEvent{ID: "1\n2", Data: "x"}},
+		{"multi-line event", SSEEvent{Event: "a\rb", Data: "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/events", nil))
+			err := ctx.SSE(func(stream SSEWriter) error {
+				return stream.Send(tt.event)
+			})
+			if !errors.Is(err, errInvalidSSEField) {
+				t.Errorf("SSE() error = %v, want %v", err, errInvalidSSEField)
+			}
+		})
+	}
+}
+
+func TestContext_SSEHeartbeat(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := NewContext(w, httptest.NewRequest("GET", "/events", nil))
+
+	err := ctx.SSE(func(stream SSEWriter) error {
+		stream.SetHeartbeat(5 * time.Millisecond)
+		time.Sleep(40 * time.Millisecond)
+		stream.SetHeartbeat(0)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SSE() error = %v", err)
+	}
+	if !strings.Contains(w.Body.String(), ": heartbeat\n\n") {
+		t.Errorf("SSE() body = %q, want heartbeat comments", w.Body.String())
+	}
+}
+
+func TestContext_SSECancelled(t *testing.T) {
+	reqCtx, cancel := context.WithCancel(context.Background())
+	w := httptest.NewRecorder()
+	ctx := NewContext(w, httptest.NewRequest("GET", "/events", nil).WithContext(reqCtx))
+
+	var sendErr error
+	err := ctx.SSE(func(stream SSEWriter) error {
+		cancel()
+		<-stream.Done()
+		sendErr = stream.Send(SSEEvent{Data: "late"})
+		return sendErr
+	})
+	if err != nil {
+		t.Errorf("SSE() error = %v, want nil for a cancelled request", err)
+	}
+	if !errors.Is(sendErr, context.Canceled) {
+		t.Errorf("Send() error = %v, want %v", sendErr, context.Canceled)
+	}
+	if strings.Contains(w.Body.String(), "late") {
+		t.Errorf("SSE() body = %q, want no event after cancellation", w.Body.String())
+	}
+}
+
+func TestContext_SSEWriteTimeout(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewContext(w, r)
+		_ = ctx.SSE(func(stream SSEWriter) error {
+			for i := 0; i < 4; i++ {
+				time.Sleep(50 * time.Millisecond)
+				if err := stream.Send(SSEEvent{Data: fmt.Sprint(i)}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading stream error = %v, body so far %q", err, body)
+	}
+	if !strings.Contains(string(body), "data: 3\n\n") {
+		t.Errorf("stream = %q, want the 4 events sent past the write timeout", body)
+	}
+}
@@ -0,0 +1,273 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// TimeoutOptions configures the Timeout middleware.
+type TimeoutOptions struct {
+	// Timeout is the maximum duration of the rest of the chain
+	Timeout time.Duration
+	// StatusCode is the status of the timeout response, http.StatusServiceUnavailable
+	// (the default) or http.StatusGatewayTimeout
+	StatusCode int
+	// Message is the message of the timeout response; empty selects "Request timed out"
+	Message string
+	// Logger receives the panics of the chain after the deadline; nil selects the
+	// application logger named "Timeout", or the slog default logger
+	Logger Logger
+}
+
+// Timeout returns a middleware running the rest of the chain with a
+// deadline. The chain runs in its own goroutine on a copy of the context
+// made with WithContext, so handlers see the deadline through
+// ctx.Context() and should stop their work once it is done.
+//
+// When the deadline hits first, a ServiceUnavailable or GatewayTimeout
+// exception wrapping context.DeadlineExceeded is returned to the exception
+// filters, unless the handler already started writing its response, which
+// is then left truncated. From then on the writes of the handler fail with
+// http.ErrHandlerTimeout, so it cannot write after the timeout response.
+// Panics of the chain are re-raised in the calling goroutine as a
+// *PanicError carrying the stack of the panic, until the deadline hits;
+// afterwards they are logged. A chain hijacking the connection, such as a
+// WebSocket upgrade, gets no timeout response. Contexts not implementing
+// ResponseContext only get the deadline, without the guarded response.
+//
+// Routes registered with RouteOptions.Timeout use this middleware.
+//
+// Example:
+//
+//	api := r.Group("/api", core.Timeout(core.TimeoutOptions{Timeout: 5 * time.Second}))
+func Timeout(opts TimeoutOptions) Middleware {
+	statusCode := opts.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusServiceUnavailable
+	}
+	message := opts.Message
+	if message == "" {
+		message = "Request timed out"
+	}
+
+	return func(ctx Context, next HandlerFunc) error {
+		deadline, cancel := context.WithTimeout(ctx.Context(), opts.Timeout)
+		defer cancel()
+
+		inner, ok := ctx.WithContext(deadline).(ResponseContext)
+		if !ok {
+			// The response cannot be guarded: only pass the deadline on.
+			return next(ctx.WithContext(deadline))
+		}
+		writer := &timeoutWriter{ResponseWriter: ctx.Response(), header: ctx.Response().Header().Clone(), deadline: deadline}
+		inner.SetResponse(writer)
+
+		done := make(chan error, 1)
+		panicked := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+				if r != http.ErrAbortHandler {
+					r = &PanicError{Value: r, Stack: debug.Stack()}
+				}
+				writer.mu.Lock()
+				defer writer.mu.Unlock()
+				if !writer.returned {
+					panicked <- r
+				} else if p, ok := r.(*PanicError); ok {
+					logLatePanic(opts.Logger, inner, p)
+				}
+			}()
+			done <- next(inner)
+		}()
+
+		var err error
+		var r interface{}
+		select {
+		case err = <-done:
+		case r = <-panicked:
+		case <-deadline.Done():
+		}
+
+		writer.mu.Lock()
+		defer writer.mu.Unlock()
+		if r == nil {
+			select {
+			case r = <-panicked:
+			default:
+			}
+		}
+		writer.returned = true
+		if writer.wroteHeader {
+			markWritten(ctx, writer.status)
+		}
+		switch {
+		case r != nil:
+			panic(r)
+		case writer.wroteHeader:
+			return err
+		case errors.Is(deadline.Err(), context.DeadlineExceeded):
+			return NewHTTPException(statusCode, message).WithCause(deadline.Err())
+		case deadline.Err() != nil:
+			// The client went away: there is nobody to respond to.
+			return nil
+		default:
+			// Keep the headers of the chain for the error response.
+			writer.copyHeader()
+			return err
+		}
+	}
+}
+
+// logLatePanic logs a panic of a chain the Timeout middleware stopped
+// waiting for, with log, the application logger or the slog default logger.
+func logLatePanic(log Logger, ctx Context, p *PanicError) {
+	keyvals := []interface{}{
+		"panic", fmt.Sprint(p.Value),
+		"method", ctx.Method(),
+		"path", ctx.Path(),
+		"stack", string(p.Stack),
+	}
+	if log == nil {
+		if log = LoggerFrom(ctx); log != nil {
+			log = log.Named("Timeout")
+		}
+	}
+	if log == nil {
+		if id := ctx.RequestID(); id != "" {
+			keyvals = append(keyvals, "request_id", id)
+		}
+		slog.Error("panic after timeout", keyvals...)
+		return
+	}
+	RequestLogger(log, ctx.Context()).Error("panic after timeout", keyvals...)
+}
+
+// markWritten records on ctx a response written through a copy of it.
+func markWritten(ctx Context, statusCode int) {
+	if c, ok := ctx.(*AppContext); ok {
+		c.statusCode = statusCode
+		c.headerWritten = true
+		c.written = true
+	}
+}
+
+// timeoutWriter guards the response writer of a chain running under the
+// Timeout middleware: headers are buffered until the status is written, and
+// writes fail once the deadline has hit or the middleware has returned.
+type timeoutWriter struct {
+	http.ResponseWriter
+
+	// mu serializes writes with the timeout
+	mu sync.Mutex
+
+	// header holds the headers of the chain until the status is written
+	header http.Header
+
+	// status is the status code written by the chain
+	status int
+
+	// wroteHeader reports whether the chain started writing the response or hijacked the connection
+	wroteHeader bool
+
+	// returned reports whether the middleware returned; later panics of the chain are logged
+	returned bool
+
+	// deadline is the context of the chain; writes fail once it is done
+	deadline context.Context
+}
+
+// Header returns the headers of the chain, sent with the status.
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader sends the buffered headers and the status, unless the deadline hit.
+func (w *timeoutWriter) WriteHeader(statusCode int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.wroteHeader || w.deadline.Err() != nil {
+		return
+	}
+	w.writeHeader(statusCode)
+}
+
+// writeHeader copies the buffered headers and sends the status. The caller must hold w.mu.
+func (w *timeoutWriter) writeHeader(statusCode int) {
+	w.copyHeader()
+	if statusCode >= http.StatusOK {
+		w.status, w.wroteHeader = statusCode, true
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// copyHeader replaces the headers of the wrapped writer with the buffered ones.
+func (w *timeoutWriter) copyHeader() {
+	dst := w.ResponseWriter.Header()
+	for key := range dst {
+		if _, ok := w.header[key]; !ok {
+			delete(dst, key)
+		}
+	}
+	for key, values := range w.header {
+		dst[key] = values
+	}
+}
+
+// Write writes body bytes, or fails with http.ErrHandlerTimeout once the deadline hit.
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.deadline.Err() != nil {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !w.wroteHeader {
+		w.writeHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// FlushError flushes the response, or fails with http.ErrHandlerTimeout once the deadline hit.
+func (w *timeoutWriter) FlushError() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.deadline.Err() != nil {
+		return http.ErrHandlerTimeout
+	}
+	if !w.wroteHeader {
+		w.writeHeader(http.StatusOK)
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack takes over the connection, as WebSocket upgrades do, recording a
+// 101 status so that no timeout response is written. It fails with
+// http.ErrHandlerTimeout once the deadline hit.
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.deadline.Err() != nil {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && !w.wroteHeader {
+		w.status, w.wroteHeader = http.StatusSwitchingProtocols, true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
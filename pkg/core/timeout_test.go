@@ -0,0 +1,231 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveTimeout runs handler behind the Timeout middleware like the router does.
+func serveTimeout(opts TimeoutOptions, handler HandlerFunc) (*httptest.ResponseRecorder, Context) {
+	w := httptest.NewRecorder()
+	ctx := NewContext(w, httptest.NewRequest("GET", "/reports", nil))
+	timeout := Timeout(opts)
+	ctx.SetHandlers([]HandlerFunc{
+		func(ctx Context) error {
+			return timeout(ctx, func(ctx Context) error { return ctx.Next() })
+		},
+		handler,
+	})
+	if err := ctx.Next(); err != nil {
+		ctx.HandleError(err)
+	}
+	return w, ctx
+}
+
+func TestTimeout(t *testing.T) {
+	fast := func(ctx Context) error {
+		return ctx.String(http.StatusCreated, "done")
+	}
+	slow := func(ctx Context) error {
+		<-ctx.Context().Done()
+		return ctx.String(http.StatusOK, "late")
+	}
+	failing := func(ctx Context) error {
+		return Conflict("report exists")
+	}
+
+	tests := []struct {
+		name    string
+		opts    TimeoutOptions
+		handler HandlerFunc
+		code    int
+		body    string
+	}{
+		{"completes in time", TimeoutOptions{Timeout: time.Second}, fast, http.StatusCreated, "done"},
+		{"error in time", TimeoutOptions{Timeout: time.Second}, failing, http.StatusConflict, ""},
+		{"times out", TimeoutOptions{Timeout: 10 * time.Millisecond}, slow, http.StatusServiceUnavailable, ""},
+		{"gateway timeout", TimeoutOptions{Timeout: 10 * time.Millisecond, StatusCode: http.StatusGatewayTimeout}, slow, http.StatusGatewayTimeout, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w, ctx := serveTimeout(test.opts, test.handler)
+			if w.Code != test.code {
+				t.Errorf("Code = %v, want %v", w.Code, test.code)
+			}
+			if ctx.GetStatusCode() != test.code {
+				t.Errorf("GetStatusCode() = %v, want %v", ctx.GetStatusCode(), test.code)
+			}
+			if test.body != "" && w.Body.String() != test.body {
+				t.Errorf("Body = %q, want %q", w.Body.String(), test.body)
+			}
+		})
+	}
+}
+
+func TestTimeout_Headers(t *testing.T) {
+	w, _ := serveTimeout(TimeoutOptions{Timeout: time.Second}, func(ctx Context) error {
+		ctx.SetHeader("X-Report", "1")
+		return ctx.NoContent(http.StatusNoContent)
+	})
+	if w.Header().Get("X-Report") != "1" {
+		t.Errorf("X-Report = %q, want %q", w.Header().Get("X-Report"), "1")
+	}
+
+	w, _ = serveTimeout(TimeoutOptions{Timeout: time.Second}, func(ctx Context) error {
+		ctx.SetHeader("Retry-After", "30")
+		return TooManyRequests("slow down")
+	})
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Errorf("error response = %d with Retry-After %q, want %d with %q", w.Code, w.Header().Get("Retry-After"), http.StatusTooManyRequests, "30")
+	}
+}
+
+func TestTimeout_LateWrite(t *testing.T) {
+	written := make(chan error, 1)
+	w, _ := serveTimeout(TimeoutOptions{Timeout: 10 * time.Millisecond, Message: "report too slow"}, func(ctx Context) error {
+		<-ctx.Context().Done()
+		ctx.SetHeader("X-Late", "1")
+		_, err := ctx.Response().Write([]byte("late"))
+		written <- err
+		return err
+	})
+
+	if err := <-written; !errors.Is(err, http.ErrHandlerTimeout) {
+		t.Errorf("late Write() error = %v, want %v", err, http.ErrHandlerTimeout)
+	}
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Code = %v, want %v", w.Code, http.StatusServiceUnavailable)
+	}
+	var response ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	if response.Message != "report too slow" {
+		t.Errorf("Message = %v, want %v", response.Message, "report too slow")
+	}
+	if w.Header().Get("X-Late") != "" {
+		t.Errorf("X-Late = %q, want it unset", w.Header().Get("X-Late"))
+	}
+}
+
+func TestTimeout_PartialResponse(t *testing.T) {
+	w, ctx := serveTimeout(TimeoutOptions{Timeout: 10 * time.Millisecond}, func(ctx Context) error {
+		_ = ctx.String(http.StatusOK, "partial")
+		<-ctx.Context().Done()
+		return nil
+	})
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("response = %d %q, want %d %q", w.Code, w.Body.String(), http.StatusOK, "partial")
+	}
+	if !ctx.IsWritten() {
+		t.Errorf("IsWritten() = false, want true")
+	}
+}
+
+func TestTimeout_Panic(t *testing.T) {
+	defer func() {
+		p, ok := recover().(*PanicError)
+		if !ok || p.Value != "boom" {
+			t.Fatalf("recover() = %v, want a *PanicError with value %q", p, "boom")
+		}
+		if !strings.Contains(string(p.Stack), "TestTimeout_Panic.func") {
+			t.Errorf("PanicError.Stack = %s, want the stack of the handler", p.Stack)
+		}
+	}()
+	serveTimeout(TimeoutOptions{Timeout: time.Second}, func(ctx Context) error {
+		panic("boom")
+	})
+}
+
+// panicLogger is a Logger sending the records logged at error level to records.
+type panicLogger struct {
+	Logger
+	records chan []interface{}
+}
+
+func (l *panicLogger) Error(msg string, keyvals ...interface{}) {
+	l.records <- append([]interface{}{msg}, keyvals...)
+}
+
+func TestTimeout_LatePanic(t *testing.T) {
+	log := &panicLogger{records: make(chan []interface{}, 1)}
+	returned := make(chan struct{})
+	w, _ := serveTimeout(TimeoutOptions{Timeout: 10 * time.Millisecond, Logger: log}, func(ctx Context) error {
+		<-returned
+		panic("too late")
+	})
+	close(returned)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Code = %v, want %v", w.Code, http.StatusServiceUnavailable)
+	}
+
+	select {
+	case record := <-log.records:
+		if record[0] != "panic after timeout" || record[2] != "too late" {
+			t.Errorf("logged %v, want the late panic", record)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("panic after the deadline was not logged")
+	}
+}
+
+// hijackRecorder is a ResponseRecorder supporting Hijack.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	conn, _ := net.Pipe()
+	return conn, bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn)), nil
+}
+
+func TestTimeout_Hijack(t *testing.T) {
+	w := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	ctx := NewContext(w, httptest.NewRequest("GET", "/ws", nil))
+
+	err := Timeout(TimeoutOptions{Timeout: 10 * time.Millisecond})(ctx, func(ctx Context) error {
+		conn, _, err := http.NewResponseController(ctx.Response()).Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		<-ctx.Context().Done()
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("Timeout() error = %v, want nil", err)
+	}
+	if !w.hijacked || !ctx.IsWritten() {
+		t.Errorf("hijacked = %v, IsWritten() = %v, want both true", w.hijacked, ctx.IsWritten())
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Body = %q, want no timeout response", w.Body.String())
+	}
+}
+
+func TestTimeout_ClientGone(t *testing.T) {
+	w := httptest.NewRecorder()
+	parent, cancel := context.WithCancel(context.Background())
+	cancel()
+	ctx := NewContext(w, httptest.NewRequest("GET", "/reports", nil).WithContext(parent))
+
+	err := Timeout(TimeoutOptions{Timeout: time.Second})(ctx, func(ctx Context) error {
+		<-ctx.Context().Done()
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Timeout() error = %v, want nil", err)
+	}
+}
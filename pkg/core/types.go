@@ -1,6 +1,12 @@
 package core
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
 
 // HTTPMethod represents HTTP request methods.
 type HTTPMethod string
@@ -41,6 +47,12 @@ type RouteMetadata struct {
 	Filters []Filter
 	// Interceptors are interceptors applied to this route
 	Interceptors []Interceptor
+	// MaxBodyBytes overrides the application body size limit when non-zero; a negative value disables it
+	MaxBodyBytes int64
+	// StrictJSON rejects unknown fields and trailing data in JSON bodies of this route
+	StrictJSON bool
+	// Timeout bounds the duration of the route with the Timeout middleware when non-zero
+	Timeout time.Duration
 }
 
 // ControllerMetadata holds metadata about a controller including its prefix and routes.
@@ -53,12 +65,18 @@ type ControllerMetadata struct {
 	Middleware []Middleware
 	// Guards applied to all routes in this controller
 	Guards []Guard
+	// Filters applied to all routes in this controller
+	Filters []Filter
+	// Interceptors applied to all routes in this controller
+	Interceptors []Interceptor
 }
 
 // ModuleMetadata holds configuration and metadata for a module.
 type ModuleMetadata struct {
 	// Controllers are the controllers defined in this module
 	Controllers []Controller
+	// Gateways are the WebSocket gateways defined in this module
+	Gateways []Gateway
 	// Providers are the services/providers defined in this module
 	Providers []Provider
 	// Imports are other modules that this module depends on
@@ -71,6 +89,46 @@ type ModuleMetadata struct {
 	IsGlobal bool
 }
 
+// GetControllers returns the module controllers, allowing *ModuleMetadata to be used as a Module.
+func (m *ModuleMetadata) GetControllers() []Controller {
+	return m.Controllers
+}
+
+// GetGateways returns the module gateways.
+func (m *ModuleMetadata) GetGateways() []Gateway {
+	return m.Gateways
+}
+
+// GetProviders returns the module providers.
+func (m *ModuleMetadata) GetProviders() []Provider {
+	return m.Providers
+}
+
+// GetImports returns the imported modules.
+func (m *ModuleMetadata) GetImports() []Module {
+	return m.Imports
+}
+
+// GetExports returns the exported provider tokens.
+func (m *ModuleMetadata) GetExports() interface{} {
+	return m.Exports
+}
+
+// GetMiddleware returns the module middleware.
+func (m *ModuleMetadata) GetMiddleware() []Middleware {
+	return m.Middleware
+}
+
+// OnModuleInit does nothing; modules needing initialization implement Module themselves.
+func (m *ModuleMetadata) OnModuleInit() error {
+	return nil
+}
+
+// OnModuleDestroy does nothing; modules needing cleanup implement Module themselves.
+func (m *ModuleMetadata) OnModuleDestroy() error {
+	return nil
+}
+
 // ProviderMetadata holds metadata about a provider registration.
 type ProviderMetadata struct {
 	// Token is the unique identifier for this provider
@@ -83,18 +141,53 @@ type ProviderMetadata struct {
 	Dependencies []interface{}
 }
 
+// GetToken returns the provider token, allowing ProviderMetadata to be used as a Provider.
+func (p ProviderMetadata) GetToken() interface{} {
+	return p.Token
+}
+
+// GetScope returns the provider scope.
+func (p ProviderMetadata) GetScope() ProviderScope {
+	return p.Scope
+}
+
+// GetFactory returns the provider factory.
+func (p ProviderMetadata) GetFactory() ProviderFactory {
+	return p.Factory
+}
+
 // ErrorResponse represents a standard error response structure.
 type ErrorResponse struct {
 	// StatusCode is the HTTP status code
-	StatusCode int `json:"statusCode"`
+	StatusCode int `json:"statusCode" xml:"statusCode"`
 	// Message is the error message
-	Message string `json:"message"`
+	Message string `json:"message" xml:"message"`
 	// Error is the error type/name
-	Error string `json:"error,omitempty"`
+	Error string `json:"error,omitempty" xml:"error,omitempty"`
+	// Code is the machine-readable error code
+	Code string `json:"code,omitempty" xml:"code,omitempty"`
+	// Details is optional structured data describing the error
+	Details interface{} `json:"details,omitempty" xml:"details,omitempty"`
 	// Path is the request path where the error occurred
-	Path string `json:"path"`
+	Path string `json:"path" xml:"path"`
 	// Timestamp is when the error occurred
-	Timestamp string `json:"timestamp,omitempty"`
+	Timestamp string `json:"timestamp,omitempty" xml:"timestamp,omitempty"`
+	// RequestID is the ID of the failed request, when the request ID middleware is used
+	RequestID string `json:"requestId,omitempty" xml:"requestId,omitempty"`
+	// Errors lists the invalid fields of a failed validation
+	Errors ValidationErrors `json:"errors,omitempty" xml:"errors,omitempty"`
+}
+
+// String formats the response as plain text, such as
+// "404 Not Found: Cannot GET /users", followed by one line per invalid field.
+// It is used when the client prefers text/plain.
+func (e ErrorResponse) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	for _, field := range e.Errors {
+		fmt.Fprintf(&b, "\n%s: %s", field.Field, field.Message)
+	}
+	return b.String()
 }
 
 // SuccessResponse represents a standard success response structure.
@@ -143,10 +236,27 @@ type ConfigOptions struct {
 	WriteTimeout int
 	// MaxHeaderBytes is the maximum size of request headers
 	MaxHeaderBytes int
-	// EnableCORS enables Cross-Origin Resource Sharing
+	// MaxBodyBytes is the maximum size of request bodies; zero disables the limit
+	MaxBodyBytes int64
+	// StrictJSON rejects unknown fields and trailing data in JSON request bodies
+	StrictJSON bool
+	// JSONCodec encodes and decodes JSON; nil selects StdJSONCodec, indented in development
+	JSONCodec JSONCodec
+	// EnableCORS enables Cross-Origin Resource Sharing with the CORS options
 	EnableCors bool
-	// TrustProxy enables trusting proxy headers (X-Forwarded-*)
+	// CORS configures the CORS middleware installed when EnableCors is set
+	CORS CORSOptions
+	// TrustProxy trusts the proxy headers selected by ProxyHeaders sent from loopback
+	// and private networks, unless TrustedProxies is set
 	TrustProxy bool
+	// TrustedProxies lists the CIDR ranges or addresses of the proxies whose headers are trusted
+	TrustedProxies []string
+	// ProxyHeaders selects the forwarding headers read from trusted proxies:
+	// XForwardedHeaders (the default) or ForwardedHeader, never both
+	ProxyHeaders ProxyHeaders
+	// Logger is the root logger of the application; nil selects a console logger at
+	// debug level in development and a JSON logger at info level otherwise
+	Logger Logger
 	// Environment is the application environment (development, production, etc.)
 	Environment string
 }
@@ -159,20 +269,50 @@ func DefaultConfigOptions() ConfigOptions {
 		ReadTimeout:    30,
 		WriteTimeout:   30,
 		MaxHeaderBytes: 1 << 20,
+		MaxBodyBytes:   4 << 20,
+		StrictJSON:     false,
 		EnableCors:     false,
 		TrustProxy:     false,
 		Environment:    "development",
 	}
 }
 
+// CORSOptions configures the Cross-Origin Resource Sharing middleware.
+// The zero value allows every origin to send simple and preflighted requests
+// without credentials.
+type CORSOptions struct {
+	// AllowOrigins lists the allowed origins: exact values such as
+	// "https://app.example.com", wildcard subdomains such as "https://*.example.com",
+	// or "*" for any origin; empty allows any origin unless patterns or a func are set
+	AllowOrigins []string
+	// AllowOriginPatterns lists regular expressions matched against the origin
+	AllowOriginPatterns []*regexp.Regexp
+	// AllowOriginFunc reports whether an origin is allowed, in addition to the lists above
+	AllowOriginFunc func(origin string) bool
+	// AllowMethods lists the methods allowed in preflight requests; empty allows
+	// GET, HEAD, PUT, PATCH, POST and DELETE
+	AllowMethods []string
+	// AllowHeaders lists the request headers allowed in preflight requests; empty or
+	// "*" allows the headers requested by the client
+	AllowHeaders []string
+	// ExposeHeaders lists the response headers readable by the client
+	ExposeHeaders []string
+	// AllowCredentials allows cookies and authorization headers; the origin is then
+	// echoed instead of "*". It requires AllowOrigins without "*", AllowOriginPatterns
+	// or AllowOriginFunc
+	AllowCredentials bool
+	// MaxAge is the number of seconds preflight responses may be cached; zero omits it
+	MaxAge int
+}
+
 // ValidationError represents a validation error with field-level details.
 type ValidationError struct {
 	// Field is the name of the field that failed validation
-	Field string `json:"field"`
+	Field string `json:"field" xml:"field"`
 	// Message is the validation error message
-	Message string `json:"message"`
+	Message string `json:"message" xml:"message"`
 	// Value is the value that failed validation (optional)
-	Value interface{} `json:"value"`
+	Value interface{} `json:"value" xml:"value"`
 }
 
 // ValidationErrors is a collection of validation errors.
@@ -202,6 +342,12 @@ type RouteOptions struct {
 	Filters []Filter
 	// Interceptors specific to this route
 	Interceptors []Interceptor
+	// MaxBodyBytes overrides the application body size limit when non-zero; a negative value disables it
+	MaxBodyBytes int64
+	// StrictJSON rejects unknown fields and trailing data in JSON bodies of this route
+	StrictJSON bool
+	// Timeout bounds the duration of the route with the Timeout middleware when non-zero
+	Timeout time.Duration
 }
 
 // LifecycleHook represents a hook that can be executed at various lifecycle stages.
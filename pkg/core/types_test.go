@@ -186,3 +186,22 @@ func TestSuccessResponse(t *testing.T) {
 		t.Errorf("SuccessResponse.Message = %v, want %v", resp.Message, "Success")
 	}
 }
+
+func TestProviderMetadata_Provider(t *testing.T) {
+	factory := func(c Container) (interface{}, error) { return "instance", nil }
+	var provider Provider = ProviderMetadata{
+		Token:   "service",
+		Scope:   TransientScope,
+		Factory: factory,
+	}
+
+	if provider.GetToken() != "service" {
+		t.Errorf("GetToken() = %v, want %v", provider.GetToken(), "service")
+	}
+	if provider.GetScope() != TransientScope {
+		t.Errorf("GetScope() = %v, want %v", provider.GetScope(), TransientScope)
+	}
+	if instance, _ := provider.GetFactory()(nil); instance != "instance" {
+		t.Errorf("GetFactory()() = %v, want %v", instance, "instance")
+	}
+}
@@ -0,0 +1,411 @@
+package core
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Validate checks v against the `validate` tags of its struct fields and
+// returns ValidationErrors describing every invalid field, or nil.
+//
+// Supported rules, separated by commas:
+//
+//   - required: the field must not be its zero value (or a nil pointer)
+//   - omitempty: skip the remaining rules when the field is its zero value
+//   - min=n, max=n, len=n: bounds on the length of strings (in characters),
+//     slices and maps, or on the value of numbers
+//   - gt=n, gte=n, lt=n, lte=n: strict and inclusive bounds, measured like min and max
+//   - email: a plain email address
+//   - uuid: a UUID in canonical 8-4-4-4-12 form
+//   - oneof=a b c: one of the space separated values
+//   - dive: apply the following rules to the elements of a slice or map
+//
+// Nested structs, including those held by pointers, slices and maps, are
+// validated as well. Errors are reported with the JSON path of the field,
+// such as "items[2].price".
+//
+// Example:
+//
+//	type CreateOrder struct {
+//	    Email string `json:"email" validate:"required,email"`
+//	    Items []Item `json:"items" validate:"required,min=1"`
+//	}
+//
+//	type Item struct {
+//	    SKU   string  `json:"sku" validate:"required,len=8"`
+//	    Price float64 `json:"price" validate:"gt=0"`
+//	}
+//
+// An invalid tag makes Validate return a plain error rather than ValidationErrors.
+func Validate(v interface{}) error {
+	var errs ValidationErrors
+	if err := validateValue(reflect.ValueOf(v), "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ValidationPipe returns a pipe validating its value with Validate, typically
+// bound to the request body:
+//
+//	core.Body[CreateOrder](core.ValidationPipe())
+func ValidationPipe() Pipe {
+	return PipeFunc(func(value interface{}, metadata PipeMetadata) (interface{}, error) {
+		if err := Validate(value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	})
+}
+
+// rule is a parsed validation rule such as "min=3".
+type rule struct {
+	name  string
+	param string
+}
+
+// fieldSpec holds the validation rules of a struct field.
+type fieldSpec struct {
+	index int
+	name  string
+	rules []rule
+	dive  bool
+	elem  []rule
+}
+
+// typeSpec is the cached result of parsing the validation tags of a struct type.
+type typeSpec struct {
+	fields []fieldSpec
+	err    error
+}
+
+// typeSpecs caches the validation rules of struct types.
+var typeSpecs sync.Map
+
+// timeType is skipped when descending into nested structs.
+var timeType = reflect.TypeOf(time.Time{})
+
+// validateValue validates v, located at path, and the values nested in it.
+func validateValue(v reflect.Value, path string, errs *ValidationErrors) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return nil
+		}
+		spec := structSpec(v.Type())
+		if spec.err != nil {
+			return spec.err
+		}
+		for _, field := range spec.fields {
+			if err := validateField(v.Field(field.index), joinPath(path, field.name), field, errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range sortedKeys(v) {
+			if err := validateValue(v.MapIndex(key), fmt.Sprintf("%s[%v]", path, key), errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateField applies the rules of field to v, then validates the values nested in it.
+func validateField(v reflect.Value, path string, field fieldSpec, errs *ValidationErrors) error {
+	if err := checkRules(v, path, field.rules, errs); err != nil {
+		return err
+	}
+	if !field.dive {
+		return validateValue(v, path, errs)
+	}
+
+	v = indirect(v)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			elemPath := fmt.Sprintf("%s[%d]", path, i)
+			if err := checkRules(v.Index(i), elemPath, field.elem, errs); err != nil {
+				return err
+			}
+			if err := validateValue(v.Index(i), elemPath, errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range sortedKeys(v) {
+			elemPath := fmt.Sprintf("%s[%v]", path, key)
+			if err := checkRules(v.MapIndex(key), elemPath, field.elem, errs); err != nil {
+				return err
+			}
+			if err := validateValue(v.MapIndex(key), elemPath, errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Invalid:
+		// nil pointer, nothing to dive into
+	default:
+		return fmt.Errorf("validator: dive on %s requires a slice or map, got %s", path, v.Kind())
+	}
+	return nil
+}
+
+// checkRules applies rules to v, recording at most one failure for path.
+func checkRules(v reflect.Value, path string, rules []rule, errs *ValidationErrors) error {
+	for _, r := range rules {
+		switch r.name {
+		case "omitempty":
+			if isEmpty(v) {
+				return nil
+			}
+			continue
+		case "required":
+			if isEmpty(v) {
+				*errs = append(*errs, ValidationError{Field: path, Message: path + " is required"})
+				return nil
+			}
+			continue
+		}
+
+		value := indirect(v)
+		if !value.IsValid() {
+			return nil
+		}
+		message, err := checkRule(value, r)
+		if err != nil {
+			return fmt.Errorf("validator: rule %q on %s: %w", r.name, path, err)
+		}
+		if message != "" {
+			failure := ValidationError{Field: path, Message: path + " " + message}
+			if value.CanInterface() {
+				failure.Value = value.Interface()
+			}
+			*errs = append(*errs, failure)
+			return nil
+		}
+	}
+	return nil
+}
+
+// checkRule applies r to v and returns the failure message, or "" if v is valid.
+func checkRule(v reflect.Value, r rule) (string, error) {
+	switch r.name {
+	case "min", "max", "len", "gt", "gte", "lt", "lte":
+		return checkBound(v, r)
+	case "email":
+		s, err := stringOf(v)
+		if err != nil {
+			return "", err
+		}
+		if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+			return "must be a valid email address", nil
+		}
+	case "uuid":
+		s, err := stringOf(v)
+		if err != nil {
+			return "", err
+		}
+		if !isUUID(s) {
+			return "must be a UUID", nil
+		}
+	case "oneof":
+		options := strings.Fields(r.param)
+		s := fmt.Sprint(v)
+		for _, option := range options {
+			if s == option {
+				return "", nil
+			}
+		}
+		return "must be one of " + strings.Join(options, ", "), nil
+	}
+	return "", nil
+}
+
+// checkBound applies a size or value bound rule to v.
+func checkBound(v reflect.Value, r rule) (string, error) {
+	limit, err := strconv.ParseFloat(r.param, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid parameter %q", r.param)
+	}
+
+	var size float64
+	unit := ""
+	switch v.Kind() {
+	case reflect.String:
+		size, unit = float64(utf8.RuneCountInString(v.String())), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		size, unit = float64(v.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		size = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = v.Float()
+	default:
+		return "", fmt.Errorf("not applicable to %s", v.Kind())
+	}
+
+	var ok bool
+	var message string
+	switch r.name {
+	case "min", "gte":
+		ok, message = size >= limit, "must be at least "
+	case "max", "lte":
+		ok, message = size <= limit, "must be at most "
+	case "len":
+		ok, message = size == limit, "must be exactly "
+	case "gt":
+		ok, message = size > limit, "must be greater than "
+	case "lt":
+		ok, message = size < limit, "must be less than "
+	}
+	if ok {
+		return "", nil
+	}
+	return message + r.param + unit, nil
+}
+
+// structSpec returns the parsed validation rules of struct type t.
+func structSpec(t reflect.Type) *typeSpec {
+	if cached, ok := typeSpecs.Load(t); ok {
+		return cached.(*typeSpec)
+	}
+
+	spec := &typeSpec{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		field := fieldSpec{index: i, name: jsonName(f)}
+		tag := f.Tag.Get("validate")
+		if tag == "-" {
+			continue
+		}
+		if tag != "" {
+			target := &field.rules
+			for _, part := range strings.Split(tag, ",") {
+				name, param, _ := strings.Cut(strings.TrimSpace(part), "=")
+				if name == "dive" {
+					field.dive = true
+					target = &field.elem
+					continue
+				}
+				if !knownRule(name) {
+					spec = &typeSpec{err: fmt.Errorf("validator: unknown rule %q on %s.%s", name, t.Name(), f.Name)}
+					actual, _ := typeSpecs.LoadOrStore(t, spec)
+					return actual.(*typeSpec)
+				}
+				*target = append(*target, rule{name: name, param: param})
+			}
+		}
+		spec.fields = append(spec.fields, field)
+	}
+
+	actual, _ := typeSpecs.LoadOrStore(t, spec)
+	return actual.(*typeSpec)
+}
+
+// knownRule reports whether name is a supported validation rule.
+func knownRule(name string) bool {
+	switch name {
+	case "required", "omitempty", "min", "max", "len", "gt", "gte", "lt", "lte", "email", "uuid", "oneof":
+		return true
+	}
+	return false
+}
+
+// jsonName returns the name of f in JSON paths. Embedded structs without a
+// JSON name share the path of their parent.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	switch {
+	case name == "-":
+		return f.Name
+	case name != "":
+		return name
+	case f.Anonymous:
+		return ""
+	default:
+		return f.Name
+	}
+}
+
+// joinPath appends name to the JSON path of its parent.
+func joinPath(path, name string) string {
+	switch {
+	case name == "":
+		return path
+	case path == "":
+		return name
+	default:
+		return path + "." + name
+	}
+}
+
+// isEmpty reports whether v is a nil pointer or a zero value.
+func isEmpty(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// indirect dereferences pointers and interfaces, returning the zero Value for nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// stringOf returns the string held by v.
+func stringOf(v reflect.Value) (string, error) {
+	if v.Kind() != reflect.String {
+		return "", fmt.Errorf("not applicable to %s", v.Kind())
+	}
+	return v.String(), nil
+}
+
+// sortedKeys returns the keys of map v in a stable order.
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	return keys
+}
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type validatorItem struct {
+	SKU   string  `json:"sku" validate:"required,len=4"`
+	Price float64 `json:"price" validate:"gt=0"`
+}
+
+type validatorAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type validatorOrder struct {
+	ID       string                      `json:"id" validate:"uuid"`
+	Email    string                      `json:"email" validate:"required,email"`
+	Name     string                      `json:"name" validate:"required,min=3,max=10"`
+	Status   string                      `json:"status" validate:"oneof=open closed"`
+	Quantity int                         `json:"quantity" validate:"gte=0,lte=100"`
+	Note     string                      `json:"note,omitempty" validate:"omitempty,min=5"`
+	Items    []validatorItem             `json:"items" validate:"required,min=1"`
+	Address  *validatorAddress           `json:"address"`
+	Tags     []string                    `json:"tags" validate:"dive,min=2"`
+	Extras   map[string]validatorAddress `json:"extras"`
+	internal string
+}
+
+func validOrder() validatorOrder {
+	return validatorOrder{
+		ID:       "123e4567-e89b-12d3-a456-426614174000",
+		Email:    "alice@example.com",
+		Name:     "alice",
+		Status:   "open",
+		Quantity: 3,
+		Items:    []validatorItem{{SKU: "AB12", Price: 9.5}},
+		Address:  &validatorAddress{City: "Lisbon"},
+		Tags:     []string{"new"},
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	order := validOrder()
+	if err := Validate(order); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := Validate(&order); err != nil {
+		t.Errorf("Validate() with a pointer error = %v", err)
+	}
+}
+
+func TestValidate_Rules(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(o *validatorOrder)
+		field   string
+		message string
+	}{
+		{"required", func(o *validatorOrder) { o.Email = "" }, "email", "email is required"},
+		{"email", func(o *validatorOrder) { o.Email = "alice" }, "email", "email must be a valid email address"},
+		{"email display name", func(o *validatorOrder) { o.Email = "Alice <alice@example.com>" }, "email", "email must be a valid email address"},
+		{"min string", func(o *validatorOrder) { o.Name = "al" }, "name", "name must be at least 3 characters"},
+		{"max string", func(o *validatorOrder) { o.Name = "alexandrina" }, "name", "name must be at most 10 characters"},
+		{"oneof", func(o *validatorOrder) { o.Status = "lost" }, "status", "status must be one of open, closed"},
+		{"uuid", func(o *validatorOrder) { o.ID = "42" }, "id", "id must be a UUID"},
+		{"gte", func(o *validatorOrder) { o.Quantity = -1 }, "quantity", "quantity must be at least 0"},
+		{"lte", func(o *validatorOrder) { o.Quantity = 101 }, "quantity", "quantity must be at most 100"},
+		{"omitempty set", func(o *validatorOrder) { o.Note = "hey" }, "note", "note must be at least 5 characters"},
+		{"empty items", func(o *validatorOrder) { o.Items = []validatorItem{} }, "items", "items is required"},
+		{"nested slice", func(o *validatorOrder) {
+			o.Items = append(o.Items, validatorItem{SKU: "CD34", Price: 1}, validatorItem{SKU: "EF56", Price: 0})
+		}, "items[2].price", "items[2].price must be greater than 0"},
+		{"len", func(o *validatorOrder) { o.Items[0].SKU = "ABC" }, "items[0].sku", "items[0].sku must be exactly 4 characters"},
+		{"nested pointer", func(o *validatorOrder) { o.Address.City = "" }, "address.city", "address.city is required"},
+		{"dive", func(o *validatorOrder) { o.Tags = []string{"new", "x"} }, "tags[1]", "tags[1] must be at least 2 characters"},
+		{"map", func(o *validatorOrder) {
+			o.Extras = map[string]validatorAddress{"home": {City: "Porto"}, "work": {}}
+		}, "extras[work].city", "extras[work].city is required"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			order := validOrder()
+			test.mutate(&order)
+
+			var validationErrors ValidationErrors
+			if err := Validate(order); !errors.As(err, &validationErrors) {
+				t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+			}
+			if len(validationErrors) != 1 {
+				t.Fatalf("ValidationErrors = %v, want a single failure", validationErrors)
+			}
+			if validationErrors[0].Field != test.field {
+				t.Errorf("Field = %v, want %v", validationErrors[0].Field, test.field)
+			}
+			if validationErrors[0].Message != test.message {
+				t.Errorf("Message = %v, want %v", validationErrors[0].Message, test.message)
+			}
+		})
+	}
+}
+
+func TestValidate_CollectsAllFields(t *testing.T) {
+	var validationErrors ValidationErrors
+	if err := Validate(validatorOrder{Status: "open"}); !errors.As(err, &validationErrors) {
+		t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+	}
+
+	fields := make([]string, len(validationErrors))
+	for i, e := range validationErrors {
+		fields[i] = e.Field
+	}
+	if got := strings.Join(fields, ","); got != "id,email,name,items" {
+		t.Errorf("failed fields = %v, want id,email,name,items", got)
+	}
+}
+
+func TestValidate_InvalidTag(t *testing.T) {
+	type dto struct {
+		Name string `validate:"required,shiny"`
+	}
+
+	err := Validate(dto{Name: "x"})
+	var validationErrors ValidationErrors
+	if err == nil || errors.As(err, &validationErrors) {
+		t.Errorf("Validate() error = %v, want a tag error", err)
+	}
+}
+
+func TestValidationPipe(t *testing.T) {
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(`{"name":"al","items":[{"sku":"AB12","price":-1}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := NewContext(httptest.NewRecorder(), req)
+
+	err := ApplyPipes(ctx, []Pipe{Body[validatorOrder](ValidationPipe())})
+
+	var validationErrors ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		t.Fatalf("ApplyPipes() error = %v, want ValidationErrors", err)
+	}
+	last := validationErrors[len(validationErrors)-1]
+	if last.Field != "items[0].price" || last.Value != -1.0 {
+		t.Errorf("ValidationError = %+v, want items[0].price with value -1", last)
+	}
+}
+
+func TestContext_BindAndValidate(t *testing.T) {
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(`{"email":"nope"}`))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := NewContext(httptest.NewRecorder(), req)
+
+	var item validatorAddress
+	if err := ctx.BindAndValidate(&item); err == nil {
+		t.Fatal("BindAndValidate() should fail without a city")
+	}
+
+	req = httptest.NewRequest("POST", "/orders", strings.NewReader(`{"city":`))
+	req.Header.Set("Content-Type", "application/json")
+	ctx = NewContext(httptest.NewRecorder(), req)
+	if err := ctx.BindAndValidate(&item); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("BindAndValidate() error = %v, want a 400 exception", err)
+	}
+
+	req = httptest.NewRequest("POST", "/orders", strings.NewReader(`{"city":"Lisbon"}`))
+	req.Header.Set("Content-Type", "application/json")
+	ctx = NewContext(httptest.NewRecorder(), req)
+	if err := ctx.BindAndValidate(&item); err != nil || item.City != "Lisbon" {
+		t.Errorf("BindAndValidate() = %v, %+v, want the bound address", err, item)
+	}
+}
@@ -0,0 +1,574 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageType is the type of a WebSocket message or control frame.
+type MessageType int
+
+// WebSocket message types, equal to the frame opcodes of RFC 6455.
+const (
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+	CloseMessage  MessageType = 8
+	PingMessage   MessageType = 9
+	PongMessage   MessageType = 10
+)
+
+// continuationFrame is the opcode of the frames continuing a fragmented message.
+const continuationFrame = 0
+
+// WebSocket close codes defined by RFC 6455.
+const (
+	CloseNormalClosure       = 1000
+	CloseGoingAway           = 1001
+	CloseProtocolError       = 1002
+	CloseUnsupportedData     = 1003
+	CloseNoStatusReceived    = 1005
+	CloseAbnormalClosure     = 1006
+	CloseInvalidPayload      = 1007
+	ClosePolicyViolation     = 1008
+	CloseMessageTooBig       = 1009
+	CloseInternalServerError = 1011
+)
+
+// DefaultWebSocketReadLimit is the maximum size of a received message when
+// WebSocketOptions.ReadLimit is zero.
+const DefaultWebSocketReadLimit = 1 << 20 // 1 MB
+
+// maxControlPayload is the maximum payload of a control frame.
+const maxControlPayload = 125
+
+// websocketGUID is appended to the client key to compute Sec-WebSocket-Accept.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ErrWebSocketClosed is returned when writing to a connection after its close frame was sent.
+var ErrWebSocketClosed = errors.New("websocket: connection closed")
+
+// CloseError describes the end of a WebSocket connection: the close frame
+// sent by the peer, or the protocol violation that made the server close it.
+type CloseError struct {
+	// Code is the close code
+	Code int
+	// Text is the close reason
+	Text string
+}
+
+// Error implements the error interface.
+func (e *CloseError) Error() string {
+	if e.Text == "" {
+		return fmt.Sprintf("websocket: close %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: close %d: %s", e.Code, e.Text)
+}
+
+// WebSocketOptions configures the upgrade of a request to a WebSocket connection.
+type WebSocketOptions struct {
+	// Subprotocols lists the supported subprotocols; the first one requested by the client is selected
+	Subprotocols []string
+	// ReadLimit is the maximum size of a received message; zero uses DefaultWebSocketReadLimit, a negative value disables it
+	ReadLimit int64
+	// CheckOrigin accepts or rejects the Origin of the request; nil accepts requests without
+	// an Origin header and those whose Origin scheme and host match Context.Scheme and
+	// Context.Host, as forwarded by trusted proxies
+	CheckOrigin func(r *http.Request) bool
+}
+
+// WebSocketConn is a server-side WebSocket connection. One goroutine may
+// read at a time; writes are safe for concurrent use.
+type WebSocketConn struct {
+	conn        net.Conn
+	reader      *bufio.Reader
+	subprotocol string
+	readLimit   int64
+	readErr     error
+	pongHandler func(data []byte)
+
+	// writeMu serializes frames and guards closeSent
+	writeMu   sync.Mutex
+	closeSent bool
+}
+
+// ReadMessage returns the next text or binary message, reassembling
+// fragmented messages. Pings are answered and pongs passed to the pong
+// handler while waiting. When the peer closes the connection, or breaks the
+// protocol, the close handshake is completed and a *CloseError returned;
+// every later call returns the same error.
+func (c *WebSocketConn) ReadMessage() (MessageType, []byte, error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+
+	var messageType MessageType
+	var message []byte
+	for {
+		maxPayload := int64(-1)
+		if c.readLimit > 0 {
+			maxPayload = c.readLimit - int64(len(message))
+		}
+		f, err := readFrame(c.reader, true, maxPayload)
+		if err != nil {
+			return 0, nil, c.fail(err)
+		}
+
+		switch MessageType(f.opcode) {
+		case PingMessage:
+			if err := c.writeFrame(PongMessage, f.payload); err != nil && err != ErrWebSocketClosed {
+				return 0, nil, c.fail(err)
+			}
+			continue
+		case PongMessage:
+			if c.pongHandler != nil {
+				c.pongHandler(f.payload)
+			}
+			continue
+		case CloseMessage:
+			return 0, nil, c.fail(parseClosePayload(f.payload))
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.fail(&CloseError{Code: CloseProtocolError, Text: "expected continuation frame"})
+			}
+			messageType = MessageType(f.opcode)
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.fail(&CloseError{Code: CloseProtocolError, Text: "unexpected continuation frame"})
+			}
+		default:
+			return 0, nil, c.fail(&CloseError{Code: CloseProtocolError, Text: fmt.Sprintf("unknown opcode %d", f.opcode)})
+		}
+
+		message = append(message, f.payload...)
+		if !f.fin {
+			continue
+		}
+		if messageType == TextMessage && !utf8.Valid(message) {
+			return 0, nil, c.fail(&CloseError{Code: CloseInvalidPayload, Text: "invalid UTF-8 in text message"})
+		}
+		return messageType, message, nil
+	}
+}
+
+// fail ends the connection after a read error. Close errors are answered
+// with a close frame carrying their code before the connection is closed.
+func (c *WebSocketConn) fail(err error) error {
+	var closeErr *CloseError
+	if errors.As(err, &closeErr) {
+		code := closeErr.Code
+		if code == CloseNoStatusReceived {
+			code = CloseNormalClosure
+		}
+		_ = c.writeClose(code, closeErr.Text)
+	} else {
+		err = &CloseError{Code: CloseAbnormalClosure, Text: err.Error()}
+	}
+	_ = c.conn.Close()
+	c.readErr = err
+	return err
+}
+
+// WriteMessage sends data as a single text or binary frame.
+func (c *WebSocketConn) WriteMessage(messageType MessageType, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", messageType)
+	}
+	return c.writeFrame(messageType, data)
+}
+
+// Ping sends a ping control frame; the peer answers with a pong carrying data.
+func (c *WebSocketConn) Ping(data []byte) error {
+	if len(data) > maxControlPayload {
+		return errors.New("websocket: control frame payload exceeds 125 bytes")
+	}
+	return c.writeFrame(PingMessage, data)
+}
+
+// SetPongHandler sets the function called with the payload of received pongs.
+func (c *WebSocketConn) SetPongHandler(handler func(data []byte)) {
+	c.pongHandler = handler
+}
+
+// SetReadLimit sets the maximum size of a received message; zero or less disables the limit.
+// A larger message closes the connection with CloseMessageTooBig.
+func (c *WebSocketConn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetReadDeadline sets the deadline of the reads of the underlying connection.
+func (c *WebSocketConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline of the writes of the underlying connection.
+func (c *WebSocketConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// Subprotocol returns the subprotocol negotiated during the handshake.
+func (c *WebSocketConn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr returns the network address of the client.
+func (c *WebSocketConn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// CloseWithCode sends a close frame with code and reason, then closes the connection.
+func (c *WebSocketConn) CloseWithCode(code int, reason string) error {
+	err := c.writeClose(code, reason)
+	if closeErr := c.conn.Close(); err == nil || err == ErrWebSocketClosed {
+		err = closeErr
+	}
+	return err
+}
+
+// Close closes the connection with CloseNormalClosure.
+func (c *WebSocketConn) Close() error {
+	return c.CloseWithCode(CloseNormalClosure, "")
+}
+
+// writeClose sends a close frame, unless one was already sent.
+func (c *WebSocketConn) writeClose(code int, reason string) error {
+	if len(reason) > maxControlPayload-2 {
+		reason = reason[:maxControlPayload-2]
+	}
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	return c.writeFrame(CloseMessage, payload)
+}
+
+// writeFrame sends one final, unmasked frame. Nothing is sent after a close frame.
+func (c *WebSocketConn) writeFrame(messageType MessageType, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return ErrWebSocketClosed
+	}
+	if messageType == CloseMessage {
+		c.closeSent = true
+	}
+	return writeFrame(c.conn, true, byte(messageType), false, payload)
+}
+
+// frame is a decoded WebSocket frame.
+type frame struct {
+	fin     bool
+	opcode  byte
+	payload []byte
+}
+
+// readFrame reads and unmasks a frame. Clients must mask their frames and
+// servers must not, so masked tells which side sent it. Data frames longer
+// than maxPayload, unless it is negative, are rejected with CloseMessageTooBig.
+func readFrame(r io.Reader, masked bool, maxPayload int64) (frame, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return frame{}, err
+	}
+
+	f := frame{fin: header[0]&0x80 != 0, opcode: header[0] & 0x0f}
+	if header[0]&0x70 != 0 {
+		return frame{}, &CloseError{Code: CloseProtocolError, Text: "reserved bits set without a negotiated extension"}
+	}
+	if (header[1]&0x80 != 0) != masked {
+		if masked {
+			return frame{}, &CloseError{Code: CloseProtocolError, Text: "client frames must be masked"}
+		}
+		return frame{}, &CloseError{Code: CloseProtocolError, Text: "server frames must not be masked"}
+	}
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return frame{}, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return frame{}, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+		if length>>63 != 0 {
+			return frame{}, &CloseError{Code: CloseProtocolError, Text: "invalid payload length"}
+		}
+	}
+
+	if f.opcode >= byte(CloseMessage) {
+		if !f.fin || length > maxControlPayload {
+			return frame{}, &CloseError{Code: CloseProtocolError, Text: "invalid control frame"}
+		}
+	} else if maxPayload >= 0 && length > uint64(maxPayload) {
+		return frame{}, &CloseError{Code: CloseMessageTooBig, Text: "message too big"}
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err := io.ReadFull(r, key[:]); err != nil {
+			return frame{}, err
+		}
+	}
+	payload, err := readFramePayload(r, length)
+	if err != nil {
+		return frame{}, err
+	}
+	f.payload = payload
+	if masked {
+		maskBytes(key, f.payload)
+	}
+	return f, nil
+}
+
+// maxFramePrealloc bounds the buffer allocated from a declared payload length
+// before the data arrives, so that a frame header alone cannot exhaust memory.
+const maxFramePrealloc = 64 << 10
+
+// readFramePayload reads a payload of length bytes, growing the buffer as data arrives.
+func readFramePayload(r io.Reader, length uint64) ([]byte, error) {
+	if length <= maxFramePrealloc {
+		payload := make([]byte, length)
+		_, err := io.ReadFull(r, payload)
+		return payload, err
+	}
+	var buf bytes.Buffer
+	buf.Grow(maxFramePrealloc)
+	read, err := io.Copy(&buf, io.LimitReader(r, int64(length)))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(read) < length {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return buf.Bytes(), nil
+}
+
+// writeFrame writes a frame in a single write, masking it with a random key if masked is set.
+func writeFrame(w io.Writer, fin bool, opcode byte, masked bool, payload []byte) error {
+	buf := make([]byte, 0, 14+len(payload))
+	b0 := opcode
+	if fin {
+		b0 |= 0x80
+	}
+	buf = append(buf, b0)
+
+	var maskBit byte
+	if masked {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, maskBit|byte(n))
+	case n <= 0xffff:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+
+	start := len(buf)
+	if masked {
+		var key [4]byte
+		if _, err := rand.Read(key[:]); err != nil {
+			return err
+		}
+		buf = append(buf, key[:]...)
+		start = len(buf)
+		buf = append(buf, payload...)
+		maskBytes(key, buf[start:])
+	} else {
+		buf = append(buf, payload...)
+	}
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// maskBytes applies the masking key to data in place.
+func maskBytes(key [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= key[i%4]
+	}
+}
+
+// parseClosePayload converts the payload of a received close frame into a CloseError.
+func parseClosePayload(payload []byte) error {
+	switch {
+	case len(payload) == 0:
+		return &CloseError{Code: CloseNoStatusReceived}
+	case len(payload) == 1:
+		return &CloseError{Code: CloseProtocolError, Text: "invalid close frame"}
+	}
+
+	code := int(binary.BigEndian.Uint16(payload))
+	text := string(payload[2:])
+	if !validCloseCode(code) {
+		return &CloseError{Code: CloseProtocolError, Text: fmt.Sprintf("invalid close code %d", code)}
+	}
+	if !utf8.ValidString(text) {
+		return &CloseError{Code: CloseInvalidPayload, Text: "invalid UTF-8 in close reason"}
+	}
+	return &CloseError{Code: code, Text: text}
+}
+
+// validCloseCode reports whether code may be sent in a close frame.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// Upgrade switches the request to the WebSocket protocol with default
+// options. See UpgradeWithOptions.
+//
+// Example:
+//
+//	conn, err := c.Upgrade()
+//	if err != nil {
+//	    return err
+//	}
+//	defer conn.Close()
+//	for {
+//	    messageType, data, err := conn.ReadMessage()
+//	    if err != nil {
+//	        return nil
+//	    }
+//	    conn.WriteMessage(messageType, data)
+//	}
+func (c *AppContext) Upgrade() (*WebSocketConn, error) {
+	return c.UpgradeWithOptions(WebSocketOptions{})
+}
+
+// UpgradeWithOptions performs the WebSocket opening handshake of RFC 6455
+// and takes over the connection. Invalid handshakes return a 400, 403 or 426
+// exception without writing a response; afterwards the response is written
+// and the handler must only use the returned connection.
+func (c *AppContext) UpgradeWithOptions(opts WebSocketOptions) (*WebSocketConn, error) {
+	r := c.request
+	if r.Method != http.MethodGet {
+		return nil, BadRequest("WebSocket upgrade requires a GET request")
+	}
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") {
+		return nil, BadRequest("Missing WebSocket upgrade headers")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		c.SetHeader("Sec-WebSocket-Version", "13")
+		return nil, NewHTTPException(http.StatusUpgradeRequired, "Unsupported WebSocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, BadRequest("Invalid Sec-WebSocket-Key header")
+	}
+	checkOrigin := opts.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = c.sameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, Forbidden("Origin not allowed")
+	}
+
+	subprotocol := selectSubprotocol(r, opts.Subprotocols)
+	netConn, rw, err := http.NewResponseController(c.response).Hijack()
+	if err != nil {
+		return nil, Internal("WebSocket upgrade is not supported").WithCause(err)
+	}
+	// Clear the deadlines set by the server for the HTTP request.
+	_ = netConn.SetDeadline(time.Time{})
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n"
+	if subprotocol != "" {
+		response += "Sec-WebSocket-Protocol: " + subprotocol + "\r\n"
+	}
+	if _, err := io.WriteString(netConn, response+"\r\n"); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+	c.statusCode = http.StatusSwitchingProtocols
+	c.headerWritten = true
+	c.written = true
+
+	readLimit := opts.ReadLimit
+	if readLimit == 0 {
+		readLimit = DefaultWebSocketReadLimit
+	}
+	return &WebSocketConn{
+		conn:        netConn,
+		reader:      rw.Reader,
+		subprotocol: subprotocol,
+		readLimit:   readLimit,
+	}, nil
+}
+
+// acceptKey computes the Sec-WebSocket-Accept value for a client key.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// sameOrigin accepts requests without an Origin header and those whose
+// Origin scheme and host equal Scheme and Host, so that requests forwarded
+// by trusted proxies are compared with the host the browser connected to.
+func (c *AppContext) sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Scheme, c.Scheme()) && strings.EqualFold(u.Host, c.Host())
+}
+
+// selectSubprotocol returns the first subprotocol requested by the client that the server supports.
+func selectSubprotocol(r *http.Request, supported []string) string {
+	for _, value := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, requested := range strings.Split(value, ",") {
+			requested = strings.TrimSpace(requested)
+			for _, protocol := range supported {
+				if requested == protocol {
+					return protocol
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// headerHasToken reports whether a comma-separated header contains token, case-insensitively.
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,348 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsClient is a minimal WebSocket client writing raw frames.
+type wsClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	resp   *http.Response
+}
+
+// dialWebSocket performs the opening handshake against server with extra request headers.
+func dialWebSocket(t *testing.T, server *httptest.Server, path string, header http.Header) *wsClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req, _ := http.NewRequest("GET", server.URL+path, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatalf("Write() handshake error = %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		t.Fatalf("ReadResponse() error = %v", err)
+	}
+	return &wsClient{t: t, conn: conn, reader: reader, resp: resp}
+}
+
+func (c *wsClient) send(fin bool, opcode byte, payload []byte) {
+	c.t.Helper()
+	if err := writeFrame(c.conn, fin, opcode, true, payload); err != nil {
+		c.t.Fatalf("writeFrame() error = %v", err)
+	}
+}
+
+func (c *wsClient) read() frame {
+	c.t.Helper()
+	f, err := readFrame(c.reader, false, -1)
+	if err != nil {
+		c.t.Fatalf("readFrame() error = %v", err)
+	}
+	return f
+}
+
+// readClose reads frames until a close frame and returns its code.
+func (c *wsClient) readClose() int {
+	c.t.Helper()
+	for {
+		f := c.read()
+		if MessageType(f.opcode) == CloseMessage {
+			if len(f.payload) < 2 {
+				return CloseNoStatusReceived
+			}
+			return int(binary.BigEndian.Uint16(f.payload))
+		}
+	}
+}
+
+// echoServer upgrades requests and echoes every message until the connection ends.
+func echoServer(t *testing.T, opts WebSocketOptions, done chan<- error) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewContext(w, r)
+		conn, err := ctx.UpgradeWithOptions(opts)
+		if err != nil {
+			_ = DefaultFilter{}.Catch(err, ctx)
+			return
+		}
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				if done != nil {
+					done <- err
+				}
+				return
+			}
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestUpgrade_Handshake(t *testing.T) {
+	server := echoServer(t, WebSocketOptions{Subprotocols: []string{"chat.v2", "chat.v1"}}, nil)
+	client := dialWebSocket(t, server, "/", http.Header{"Sec-Websocket-Protocol": {"chat.v1, chat.v2"}})
+
+	if client.resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %d, want %d", client.resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+	// Sample key and accept value from RFC 6455, section 1.3.
+	if got := client.resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Sec-WebSocket-Accept = %q, want %q", got, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=")
+	}
+	if got := client.resp.Header.Get("Sec-WebSocket-Protocol"); got != "chat.v1" {
+		t.Errorf("Sec-WebSocket-Protocol = %q, want %q", got, "chat.v1")
+	}
+}
+
+func TestUpgrade_Rejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		opts   WebSocketOptions
+		status int
+	}{
+		{"unsupported version", http.Header{"Sec-Websocket-Version": {"8"}}, WebSocketOptions{}, http.StatusUpgradeRequired},
+		{"invalid key", http.Header{"Sec-Websocket-Key": {"short"}}, WebSocketOptions{}, http.StatusBadRequest},
+		{"missing upgrade", http.Header{"Upgrade": {"h2c"}}, WebSocketOptions{}, http.StatusBadRequest},
+		{"cross origin", http.Header{"Origin": {"https://evil.example"}}, WebSocketOptions{}, http.StatusForbidden},
+		{"origin check", http.Header{"Origin": {"https://app.example"}}, WebSocketOptions{
+			CheckOrigin: func(r *http.Request) bool { return r.Header.Get("Origin") == "https://other.example" },
+		}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := echoServer(t, tt.opts, nil)
+			client := dialWebSocket(t, server, "/", tt.header)
+			if client.resp.StatusCode != tt.status {
+				t.Errorf("handshake status = %d, want %d", client.resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestUpgrade_AllowedOrigin(t *testing.T) {
+	server := echoServer(t, WebSocketOptions{
+		CheckOrigin: func(r *http.Request) bool { return r.Header.Get("Origin") == "https://app.example" },
+	}, nil)
+	client := dialWebSocket(t, server, "/", http.Header{"Origin": {"https://app.example"}})
+	if client.resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("handshake status = %d, want %d", client.resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+}
+
+func TestUpgrade_ForwardedOrigin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewContext(w, r)
+		ctx.SetTrustedProxies(MustParseTrustedProxies("127.0.0.0/8", "::1/128"))
+		conn, err := ctx.Upgrade()
+		if err != nil {
+			_ = DefaultFilter{}.Catch(err, ctx)
+			return
+		}
+		_ = conn.Close()
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name   string
+		origin string
+		status int
+	}{
+		{"forwarded origin", "https://app.example", http.StatusSwitchingProtocols},
+		{"backend origin", "http://" + server.Listener.Addr().String(), http.StatusForbidden},
+		{"scheme mismatch", "http://app.example", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := dialWebSocket(t, server, "/", http.Header{
+				"Origin":            {tt.origin},
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"app.example"},
+			})
+			if client.resp.StatusCode != tt.status {
+				t.Errorf("handshake status = %d, want %d", client.resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestWebSocketConn_Echo(t *testing.T) {
+	server := echoServer(t, WebSocketOptions{}, nil)
+	client := dialWebSocket(t, server, "/", nil)
+
+	tests := []struct {
+		name   string
+		opcode byte
+		data   []byte
+	}{
+		{"text", byte(TextMessage), []byte("hello")},
+		{"binary", byte(BinaryMessage), []byte{0, 1, 2, 255}},
+		{"16-bit length", byte(TextMessage), []byte(strings.Repeat("a", 1000))},
+		{"64-bit length", byte(BinaryMessage), []byte(strings.Repeat("b", 70000))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client.send(true, tt.opcode, tt.data)
+			f := client.read()
+			if f.opcode != tt.opcode || string(f.payload) != string(tt.data) {
+				t.Errorf("echo = opcode %d, %d bytes, want opcode %d, %d bytes", f.opcode, len(f.payload), tt.opcode, len(tt.data))
+			}
+		})
+	}
+}
+
+func TestWebSocketConn_Fragmentation(t *testing.T) {
+	server := echoServer(t, WebSocketOptions{}, nil)
+	client := dialWebSocket(t, server, "/", nil)
+
+	client.send(false, byte(TextMessage), []byte("hel"))
+	client.send(true, byte(PingMessage), []byte("ping"))
+	client.send(false, continuationFrame, []byte("lo "))
+	client.send(true, continuationFrame, []byte("world"))
+
+	if f := client.read(); MessageType(f.opcode) != PongMessage || string(f.payload) != "ping" {
+		t.Errorf("first frame = opcode %d %q, want pong %q", f.opcode, f.payload, "ping")
+	}
+	if f := client.read(); MessageType(f.opcode) != TextMessage || string(f.payload) != "hello world" {
+		t.Errorf("message = opcode %d %q, want text %q", f.opcode, f.payload, "hello world")
+	}
+}
+
+func TestWebSocketConn_Close(t *testing.T) {
+	done := make(chan error, 1)
+	server := echoServer(t, WebSocketOptions{}, done)
+	client := dialWebSocket(t, server, "/", nil)
+
+	payload := binary.BigEndian.AppendUint16(nil, CloseGoingAway)
+	client.send(true, byte(CloseMessage), append(payload, "bye"...))
+
+	if code := client.readClose(); code != CloseGoingAway {
+		t.Errorf("echoed close code = %d, want %d", code, CloseGoingAway)
+	}
+	var closeErr *CloseError
+	if err := <-done; !errors.As(err, &closeErr) || closeErr.Code != CloseGoingAway || closeErr.Text != "bye" {
+		t.Errorf("ReadMessage() error = %v, want close %d %q", err, CloseGoingAway, "bye")
+	}
+}
+
+func TestWebSocketConn_ProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(c *wsClient)
+		code  int
+	}{
+		{"unmasked frame", func(c *wsClient) {
+			_ = writeFrame(c.conn, true, byte(TextMessage), false, []byte("x"))
+		}, CloseProtocolError},
+		{"reserved bits", func(c *wsClient) {
+			_, _ = c.conn.Write([]byte{0xc1, 0x80, 0, 0, 0, 0})
+		}, CloseProtocolError},
+		{"unknown opcode", func(c *wsClient) { c.send(true, 3, nil) }, CloseProtocolError},
+		{"fragmented control frame", func(c *wsClient) { c.send(false, byte(PingMessage), nil) }, CloseProtocolError},
+		{"orphan continuation", func(c *wsClient) { c.send(true, continuationFrame, []byte("x")) }, CloseProtocolError},
+		{"interleaved message", func(c *wsClient) {
+			c.send(false, byte(TextMessage), []byte("a"))
+			c.send(true, byte(TextMessage), []byte("b"))
+		}, CloseProtocolError},
+		{"invalid UTF-8", func(c *wsClient) { c.send(true, byte(TextMessage), []byte{0xff, 0xfe}) }, CloseInvalidPayload},
+		{"invalid close code", func(c *wsClient) { c.send(true, byte(CloseMessage), []byte{0x03, 0xed}) }, CloseProtocolError},
+		{"message too big", func(c *wsClient) { c.send(true, byte(BinaryMessage), make([]byte, 64)) }, CloseMessageTooBig},
+		{"fragments too big", func(c *wsClient) {
+			c.send(false, byte(BinaryMessage), make([]byte, 20))
+			c.send(true, continuationFrame, make([]byte, 20))
+		}, CloseMessageTooBig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan error, 1)
+			server := echoServer(t, WebSocketOptions{ReadLimit: 32}, done)
+			client := dialWebSocket(t, server, "/", nil)
+
+			tt.write(client)
+			if code := client.readClose(); code != tt.code {
+				t.Errorf("close code = %d, want %d", code, tt.code)
+			}
+			var closeErr *CloseError
+			if err := <-done; !errors.As(err, &closeErr) || closeErr.Code != tt.code {
+				t.Errorf("ReadMessage() error = %v, want close %d", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestReadFrame_HugeLength(t *testing.T) {
+	header := []byte{0x82, 0x80 | 127}
+	header = binary.BigEndian.AppendUint64(header, 1<<62)
+	header = append(header, 0, 0, 0, 0, 'x')
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if _, err := readFrame(bytes.NewReader(header), true, -1); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("readFrame() error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("readFrame() allocated %d bytes, want at most %d", allocated, 1<<20)
+	}
+}
+
+func TestWebSocketConn_WriteAfterClose(t *testing.T) {
+	result := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := NewContext(w, r).Upgrade()
+		if err != nil {
+			result <- err
+			return
+		}
+		_ = conn.Ping([]byte("hi"))
+		_ = conn.CloseWithCode(CloseGoingAway, "restart")
+		result <- conn.WriteMessage(TextMessage, []byte("late"))
+	}))
+	t.Cleanup(server.Close)
+	client := dialWebSocket(t, server, "/", nil)
+
+	if f := client.read(); MessageType(f.opcode) != PingMessage || string(f.payload) != "hi" {
+		t.Errorf("first frame = opcode %d %q, want ping %q", f.opcode, f.payload, "hi")
+	}
+	if code := client.readClose(); code != CloseGoingAway {
+		t.Errorf("close code = %d, want %d", code, CloseGoingAway)
+	}
+	if err := <-result; !errors.Is(err, ErrWebSocketClosed) {
+		t.Errorf("WriteMessage() after close error = %v, want %v", err, ErrWebSocketClosed)
+	}
+}
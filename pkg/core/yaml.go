@@ -0,0 +1,134 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// renderYAML encodes data as a YAML document. Field names and values follow
+// the JSON encoding of data, so json tags and json.Marshaler apply.
+func renderYAML(w io.Writer, data interface{}) error {
+	document, err := toDocument(data)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	writeYAML(&buf, document, 0)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// writeYAML writes the block representation of v indented by indent spaces.
+func writeYAML(buf *bytes.Buffer, v interface{}, indent int) {
+	pad := strings.Repeat(" ", indent)
+	switch v := v.(type) {
+	case orderedMap:
+		if len(v) == 0 {
+			buf.WriteString(pad + "{}\n")
+			return
+		}
+		for _, entry := range v {
+			buf.WriteString(pad + yamlString(entry.key) + ":")
+			writeYAMLValue(buf, entry.value, indent)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString(pad + "[]\n")
+			return
+		}
+		for _, item := range v {
+			// Render the item one level deeper, then replace its
+			// indentation with the sequence indicator.
+			var sub bytes.Buffer
+			writeYAML(&sub, item, indent+2)
+			buf.WriteString(pad + "- " + sub.String()[indent+2:])
+		}
+	default:
+		buf.WriteString(pad + yamlScalar(v) + "\n")
+	}
+}
+
+// writeYAMLValue writes the value of a mapping key whose "key:" was just written.
+func writeYAMLValue(buf *bytes.Buffer, v interface{}, indent int) {
+	switch x := v.(type) {
+	case orderedMap:
+		if len(x) == 0 {
+			buf.WriteString(" {}\n")
+			return
+		}
+		buf.WriteString("\n")
+		writeYAML(buf, x, indent+2)
+	case []interface{}:
+		if len(x) == 0 {
+			buf.WriteString(" []\n")
+			return
+		}
+		buf.WriteString("\n")
+		writeYAML(buf, x, indent+2)
+	default:
+		buf.WriteString(" " + yamlScalar(v) + "\n")
+	}
+}
+
+// yamlScalar returns the YAML representation of a scalar document value.
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlString(v)
+	default:
+		return yamlString(fmt.Sprint(v))
+	}
+}
+
+// yamlString returns s as a plain YAML scalar when that is unambiguous and as
+// a double-quoted scalar otherwise.
+func yamlString(s string) string {
+	if isPlainYAML(s) {
+		return s
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// isPlainYAML reports whether s can be written unquoted without being read
+// back as another type or breaking the document structure.
+func isPlainYAML(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return false
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return false
+	}
+
+	c := s[0]
+	if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c == '/') {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '_' || c == '-' || c == '.' || c == '/' || c == ' ':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRenderYAML(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+	}
+	type user struct {
+		Name    string            `json:"name"`
+		Age     int               `json:"age"`
+		Score   float64           `json:"score"`
+		Active  bool              `json:"active"`
+		Tags    []string          `json:"tags"`
+		Address address           `json:"address"`
+		Orders  []address         `json:"orders"`
+		Meta    map[string]string `json:"meta"`
+		Note    *string           `json:"note"`
+	}
+
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{"scalar", "hello", "hello\n"},
+		{"quoted scalars", []string{"yes", "12", "a: b", ""}, "- \"yes\"\n- \"12\"\n- \"a: b\"\n- \"\"\n"},
+		{"empty collections", map[string]interface{}{"list": []int{}, "map": map[string]int{}}, "list: []\nmap: {}\n"},
+		{
+			"struct",
+			user{
+				Name: "alice", Age: 30, Score: 9.5, Active: true,
+				Tags:    []string{"a", "b"},
+				Address: address{City: "Lisbon"},
+				Orders:  []address{{City: "Porto"}},
+				Meta:    map[string]string{"k": "v"},
+			},
+			"name: alice\nage: 30\nscore: 9.5\nactive: true\ntags:\n  - a\n  - b\naddress:\n  city: Lisbon\n" +
+				"orders:\n  - city: Porto\nmeta:\n  k: v\nnote: null\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := renderYAML(&buf, tt.data); err != nil {
+				t.Fatalf("renderYAML() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("renderYAML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
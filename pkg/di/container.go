@@ -0,0 +1,543 @@
+// Package di provides the dependency injection container of the GoAegis framework.
+//
+// Providers are registered with a token and a scope and resolved lazily:
+//
+//	c := di.NewContainer()
+//	c.Register(core.ProviderMetadata{
+//	    Token:   "users",
+//	    Scope:   core.SingletonScope,
+//	    Factory: func(c core.Container) (interface{}, error) {
+//	        db, err := c.Resolve("db")
+//	        if err != nil {
+//	            return nil, err
+//	        }
+//	        return NewUserService(db.(*sql.DB)), nil
+//	    },
+//	})
+//
+// Singleton instances are cached by the container, transient instances are
+// rebuilt on every resolution and request-scoped instances are cached for the
+// lifetime of a single request, see Container.Scope. Singletons implementing
+// core.LifecycleHook are initialized when created and destroyed by Destroy.
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+)
+
+// Errors returned by the container. Resolution errors wrap them, so use errors.Is to test for them.
+var (
+	// ErrInvalidProvider is returned when registering a provider without a usable token or factory.
+	ErrInvalidProvider = errors.New("invalid provider")
+
+	// ErrProviderAlreadyRegistered is returned when a token is registered twice.
+	ErrProviderAlreadyRegistered = errors.New("provider already registered")
+
+	// ErrProviderNotFound is returned when resolving a token that has no provider.
+	ErrProviderNotFound = errors.New("provider not found")
+
+	// ErrCircularDependency is returned when a provider depends on itself, directly or not.
+	ErrCircularDependency = errors.New("circular dependency detected")
+
+	// ErrNoRequestScope is returned when a request-scoped provider is resolved outside a request.
+	ErrNoRequestScope = errors.New("request-scoped provider resolved outside of a request")
+)
+
+// requestScopeKey is the Context value key holding the request-scoped instances.
+const requestScopeKey = "goaegis.di.requestScope"
+
+// Container is the default implementation of core.Container.
+// It is safe for concurrent use.
+type Container struct {
+	// mu protects entries and imports
+	mu sync.RWMutex
+
+	// entries holds the registered providers, keyed by token
+	entries map[interface{}]*entry
+
+	// imports holds providers made visible from other containers, keyed by token
+	imports map[interface{}]*entry
+
+	// initialized lists the singleton entries implementing core.LifecycleHook, in creation order
+	initialized []*entry
+}
+
+// entry is a registered provider together with its cached singleton instance.
+type entry struct {
+	// token is the provider token
+	token interface{}
+
+	// owner is the container the provider was registered in
+	owner *Container
+
+	// scope is the provider lifecycle scope
+	scope core.ProviderScope
+
+	// factory creates provider instances
+	factory core.ProviderFactory
+
+	// mu guards instance and resolved
+	mu sync.Mutex
+
+	// instance is the cached singleton instance
+	instance interface{}
+
+	// resolved indicates that instance has been created
+	resolved bool
+
+	// creating is the creation of instance in progress, guarded by creationMu
+	creating *creation
+}
+
+// requestScope holds the request-scoped instances of a single request.
+type requestScope struct {
+	mu        sync.Mutex
+	instances map[*entry]interface{}
+}
+
+// scopeMu serializes the creation of request scopes stored in a Context.
+var scopeMu sync.Mutex
+
+// resolution identifies a top-level resolution together with the nested
+// resolutions of the dependencies it creates.
+type resolution struct {
+	// waiting is the entry whose creation the resolution waits for
+	waiting *entry
+}
+
+// waitsFor reports whether r is target or waits, directly or through other
+// resolutions, for a creation owned by target. The caller must hold creationMu.
+func (r *resolution) waitsFor(target *resolution) bool {
+	for r != nil {
+		if r == target {
+			return true
+		}
+		if r.waiting == nil || r.waiting.creating == nil {
+			return false
+		}
+		r = r.waiting.creating.owner
+	}
+	return false
+}
+
+// creation is the creation of a singleton instance in progress.
+type creation struct {
+	// owner is the resolution running the factory
+	owner *resolution
+
+	// done is closed once instance and err are set
+	done chan struct{}
+
+	// instance and err are the outcome of the creation
+	instance interface{}
+	err      error
+}
+
+// creationMu guards the creations in progress and the entries resolutions
+// wait for, across containers.
+var creationMu sync.Mutex
+
+// Ensure Container implements core.Container.
+var _ core.Container = (*Container)(nil)
+
+// NewContainer creates an empty Container.
+func NewContainer() *Container {
+	return &Container{
+		entries: make(map[interface{}]*entry),
+		imports: make(map[interface{}]*entry),
+	}
+}
+
+// Register registers a provider in the container.
+// Tokens must be comparable and unique within the container.
+func (c *Container) Register(provider core.Provider) error {
+	if provider == nil {
+		return fmt.Errorf("%w: provider is nil", ErrInvalidProvider)
+	}
+
+	token := provider.GetToken()
+	if token == nil {
+		return fmt.Errorf("%w: token is nil", ErrInvalidProvider)
+	}
+	if !reflect.TypeOf(token).Comparable() {
+		return fmt.Errorf("%w: token of type %T is not comparable", ErrInvalidProvider, token)
+	}
+
+	factory := provider.GetFactory()
+	if factory == nil {
+		return fmt.Errorf("%w: provider %s has no factory", ErrInvalidProvider, FormatToken(token))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[token]; exists {
+		return fmt.Errorf("%w: %s", ErrProviderAlreadyRegistered, FormatToken(token))
+	}
+
+	c.entries[token] = &entry{
+		token:   token,
+		owner:   c,
+		scope:   provider.GetScope(),
+		factory: factory,
+	}
+	return nil
+}
+
+// Resolve resolves a dependency by its token and returns the instance.
+// Request-scoped providers cannot be resolved directly; use Scope to obtain
+// a container bound to the current request.
+func (c *Container) Resolve(token interface{}) (interface{}, error) {
+	return c.resolve(token, nil, nil, nil)
+}
+
+// Has checks if a provider with the given token is registered or imported.
+func (c *Container) Has(token interface{}) bool {
+	_, ok := c.lookup(token)
+	return ok
+}
+
+// Clear removes all registered providers, imports and cached instances.
+func (c *Container) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[interface{}]*entry)
+	c.imports = make(map[interface{}]*entry)
+	c.initialized = nil
+}
+
+// Destroy calls OnDestroy on every singleton instance implementing
+// core.LifecycleHook, in reverse order of creation, and drops them from the
+// cache so that a later resolution creates fresh instances. Every hook runs
+// even if an earlier one fails; the returned error joins all failures.
+func (c *Container) Destroy() error {
+	c.mu.Lock()
+	initialized := c.initialized
+	c.initialized = nil
+	c.mu.Unlock()
+
+	var errs []error
+	for i := len(initialized) - 1; i >= 0; i-- {
+		e := initialized[i]
+
+		e.mu.Lock()
+		instance := e.instance
+		e.instance = nil
+		e.resolved = false
+		e.mu.Unlock()
+
+		if err := instance.(core.LifecycleHook).OnDestroy(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to destroy %s: %w", FormatToken(e.token), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Import makes the providers of source identified by tokens resolvable through c.
+// Imported providers keep resolving their own dependencies in source, so only
+// the imported tokens become visible; this is how module exports are enforced.
+// A token may be re-exported: source can itself have imported it.
+//
+// Example:
+//
+//	app := di.NewContainer()
+//	if err := app.Import(database, "db"); err != nil {
+//	    return err
+//	}
+func (c *Container) Import(source *Container, tokens ...interface{}) error {
+	for _, token := range tokens {
+		e, ok := source.lookup(token)
+		if !ok {
+			return fmt.Errorf("%w: cannot import %s", ErrProviderNotFound, FormatToken(token))
+		}
+
+		c.mu.Lock()
+		existing, imported := c.imports[token]
+		if !imported {
+			c.imports[token] = e
+		}
+		c.mu.Unlock()
+
+		if imported && existing != e {
+			return fmt.Errorf("%w: %s is imported from two different providers",
+				ErrProviderAlreadyRegistered, FormatToken(token))
+		}
+	}
+	return nil
+}
+
+// Scope returns a view of the container bound to the request of ctx.
+// Request-scoped providers resolved through it are created once per request
+// and shared by every resolution made with the same ctx.
+//
+// Example:
+//
+//	func (h *Handler) Get(ctx core.Context) error {
+//	    tx, err := h.container.Scope(ctx).Resolve("tx")
+//	    ...
+//	}
+func (c *Container) Scope(ctx core.Context) core.Container {
+	return &resolver{container: c, request: ctx}
+}
+
+// lookup returns the entry registered for token, preferring local providers over imported ones.
+func (c *Container) lookup(token interface{}) (*entry, bool) {
+	if token == nil || !reflect.TypeOf(token).Comparable() {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if e, ok := c.entries[token]; ok {
+		return e, true
+	}
+	e, ok := c.imports[token]
+	return e, ok
+}
+
+// resolve resolves token on behalf of request, where chain lists the tokens
+// currently being resolved by the caller.
+func (c *Container) resolve(token interface{}, request core.Context, chain []interface{}, res *resolution) (interface{}, error) {
+	for _, t := range chain {
+		if t == token {
+			return nil, fmt.Errorf("%w: %s", ErrCircularDependency, formatChain(append(chain[:len(chain):len(chain)], token)))
+		}
+	}
+
+	e, ok := c.lookup(token)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotFound, FormatToken(token))
+	}
+
+	path := append(chain[:len(chain):len(chain)], token)
+	if res == nil {
+		res = &resolution{}
+	}
+
+	// Providers always resolve their dependencies in the container that owns them.
+	owner := e.owner
+	switch e.scope {
+	case core.SingletonScope:
+		return owner.resolveSingleton(e, path, res)
+	case core.RequestScope:
+		return owner.resolveRequest(e, request, path, res)
+	default:
+		return owner.create(e, &resolver{container: owner, request: request, chain: path, resolution: res})
+	}
+}
+
+// resolveSingleton returns the cached instance of e, creating it on first use.
+// Singletons never see the request, so they cannot depend on request-scoped providers.
+//
+// The factory runs once: concurrent first resolutions wait for the creation
+// in progress. A resolution that would wait, directly or through others, for
+// a creation waiting on itself reports ErrCircularDependency instead of
+// deadlocking.
+func (c *Container) resolveSingleton(e *entry, path []interface{}, res *resolution) (interface{}, error) {
+	creationMu.Lock()
+	e.mu.Lock()
+	if e.resolved {
+		defer e.mu.Unlock()
+		creationMu.Unlock()
+		return e.instance, nil
+	}
+	e.mu.Unlock()
+
+	if cr := e.creating; cr != nil {
+		if cr.owner.waitsFor(res) {
+			creationMu.Unlock()
+			return nil, fmt.Errorf("%w: %s", ErrCircularDependency, formatChain(path))
+		}
+		res.waiting = e
+		creationMu.Unlock()
+
+		<-cr.done
+
+		creationMu.Lock()
+		res.waiting = nil
+		creationMu.Unlock()
+		return cr.instance, cr.err
+	}
+
+	cr := &creation{owner: res, done: make(chan struct{})}
+	e.creating = cr
+	creationMu.Unlock()
+
+	cr.instance, cr.err = c.initialize(e, path, res)
+
+	creationMu.Lock()
+	e.creating = nil
+	close(cr.done)
+	creationMu.Unlock()
+	return cr.instance, cr.err
+}
+
+// initialize creates the singleton instance of e, runs its OnInit hook and caches it.
+func (c *Container) initialize(e *entry, path []interface{}, res *resolution) (interface{}, error) {
+	instance, err := c.create(e, &resolver{container: c, chain: path, resolution: res})
+	if err != nil {
+		return nil, err
+	}
+
+	if hook, ok := instance.(core.LifecycleHook); ok {
+		if err := hook.OnInit(); err != nil {
+			return nil, fmt.Errorf("failed to initialize %s: %w", FormatToken(e.token), err)
+		}
+		c.mu.Lock()
+		c.initialized = append(c.initialized, e)
+		c.mu.Unlock()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.instance = instance
+	e.resolved = true
+	return instance, nil
+}
+
+// resolveRequest returns the instance of e bound to request, creating it on first use.
+func (c *Container) resolveRequest(e *entry, request core.Context, path []interface{}, res *resolution) (interface{}, error) {
+	if request == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNoRequestScope, formatChain(path))
+	}
+
+	scope := scopeOf(request)
+	scope.mu.Lock()
+	instance, ok := scope.instances[e]
+	scope.mu.Unlock()
+	if ok {
+		return instance, nil
+	}
+
+	// The factory runs without holding the lock so that it can resolve other
+	// request-scoped providers; the first instance stored wins.
+	instance, err := c.create(e, &resolver{container: c, request: request, chain: path, resolution: res})
+	if err != nil {
+		return nil, err
+	}
+
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	if existing, ok := scope.instances[e]; ok {
+		return existing, nil
+	}
+	scope.instances[e] = instance
+	return instance, nil
+}
+
+// create invokes the factory of e, wrapping its errors with the provider token.
+func (c *Container) create(e *entry, r *resolver) (interface{}, error) {
+	instance, err := e.factory(r)
+	if err != nil {
+		if errors.Is(err, ErrCircularDependency) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to resolve %s: %w", FormatToken(e.token), err)
+	}
+	return instance, nil
+}
+
+// scopeOf returns the request scope stored in ctx, creating it if necessary.
+func scopeOf(ctx core.Context) *requestScope {
+	scopeMu.Lock()
+	defer scopeMu.Unlock()
+
+	if scope, ok := ctx.GetValue(requestScopeKey).(*requestScope); ok {
+		return scope
+	}
+	scope := &requestScope{instances: make(map[*entry]interface{})}
+	ctx.SetValue(requestScopeKey, scope)
+	return scope
+}
+
+// resolver is the core.Container handed to provider factories and returned by Scope.
+// It carries the request, the chain of tokens being resolved and the resolution
+// they belong to, so nested resolutions share the request scope and circular
+// dependencies are detected, within a goroutine or across goroutines.
+type resolver struct {
+	container  *Container
+	request    core.Context
+	chain      []interface{}
+	resolution *resolution
+}
+
+// Register registers a provider in the underlying container.
+func (r *resolver) Register(provider core.Provider) error {
+	return r.container.Register(provider)
+}
+
+// Resolve resolves token as a dependency of the provider being created.
+func (r *resolver) Resolve(token interface{}) (interface{}, error) {
+	return r.container.resolve(token, r.request, r.chain, r.resolution)
+}
+
+// Has checks if a provider with the given token is registered.
+func (r *resolver) Has(token interface{}) bool {
+	return r.container.Has(token)
+}
+
+// Clear removes all registered providers from the underlying container.
+func (r *resolver) Clear() {
+	r.container.Clear()
+}
+
+// Token returns the reflect.Type of T, the conventional token for type-keyed providers.
+//
+// Example:
+//
+//	c.Register(core.ProviderMetadata{Token: di.Token[*UserService](), Factory: newUserService})
+func Token[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// ResolveAs resolves token from c and asserts the instance to T.
+//
+// Example:
+//
+//	users, err := di.ResolveAs[*UserService](c, di.Token[*UserService]())
+func ResolveAs[T any](c core.Container, token interface{}) (T, error) {
+	var zero T
+
+	instance, err := c.Resolve(token)
+	if err != nil {
+		return zero, err
+	}
+
+	typed, ok := instance.(T)
+	if !ok {
+		return zero, fmt.Errorf("provider %s resolved to %T, not %s",
+			FormatToken(token), instance, reflect.TypeOf((*T)(nil)).Elem())
+	}
+	return typed, nil
+}
+
+// FormatToken returns a human-readable representation of a provider token.
+func FormatToken(token interface{}) string {
+	switch t := token.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return t
+	case reflect.Type:
+		return t.String()
+	case fmt.Stringer:
+		return t.String()
+	default:
+		return fmt.Sprintf("%T(%v)", token, token)
+	}
+}
+
+// formatChain renders a resolution chain as "A -> B -> A".
+func formatChain(chain []interface{}) string {
+	names := make([]string, len(chain))
+	for i, token := range chain {
+		names[i] = FormatToken(token)
+	}
+	return strings.Join(names, " -> ")
+}
@@ -0,0 +1,463 @@
+package di
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+)
+
+type counter struct {
+	id int
+}
+
+func counterProvider(token interface{}, scope core.ProviderScope, calls *int) core.Provider {
+	var mu sync.Mutex
+	return core.ProviderMetadata{
+		Token: token,
+		Scope: scope,
+		Factory: func(c core.Container) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			*calls++
+			return &counter{id: *calls}, nil
+		},
+	}
+}
+
+func newRequestContext() core.Context {
+	return core.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestContainer_Register(t *testing.T) {
+	factory := func(c core.Container) (interface{}, error) { return 1, nil }
+
+	tests := []struct {
+		name     string
+		provider core.Provider
+		wantErr  error
+	}{
+		{"Valid", core.ProviderMetadata{Token: "a", Factory: factory}, nil},
+		{"Nil provider", nil, ErrInvalidProvider},
+		{"Nil token", core.ProviderMetadata{Factory: factory}, ErrInvalidProvider},
+		{"Uncomparable token", core.ProviderMetadata{Token: []string{"a"}, Factory: factory}, ErrInvalidProvider},
+		{"Nil factory", core.ProviderMetadata{Token: "b"}, ErrInvalidProvider},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewContainer()
+			err := c.Register(tt.provider)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Register() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("Duplicate", func(t *testing.T) {
+		c := NewContainer()
+		_ = c.Register(core.ProviderMetadata{Token: "a", Factory: factory})
+		err := c.Register(core.ProviderMetadata{Token: "a", Factory: factory})
+		if !errors.Is(err, ErrProviderAlreadyRegistered) {
+			t.Errorf("Register() error = %v, want %v", err, ErrProviderAlreadyRegistered)
+		}
+	})
+}
+
+func TestContainer_SingletonScope(t *testing.T) {
+	c := NewContainer()
+	calls := 0
+	_ = c.Register(counterProvider("svc", core.SingletonScope, &calls))
+
+	var wg sync.WaitGroup
+	instances := make([]interface{}, 20)
+	for i := range instances {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			instances[i], _ = c.Resolve("svc")
+		}(i)
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("factory called %d times, want 1", calls)
+	}
+	for i, instance := range instances {
+		if instance != instances[0] {
+			t.Errorf("instance %d differs from the first singleton instance", i)
+		}
+	}
+}
+
+func TestContainer_TransientScope(t *testing.T) {
+	c := NewContainer()
+	calls := 0
+	_ = c.Register(counterProvider("svc", core.TransientScope, &calls))
+
+	first, _ := c.Resolve("svc")
+	second, _ := c.Resolve("svc")
+
+	if first == second {
+		t.Error("transient provider should return a new instance on every Resolve")
+	}
+	if calls != 2 {
+		t.Errorf("factory called %d times, want 2", calls)
+	}
+}
+
+func TestContainer_RequestScope(t *testing.T) {
+	c := NewContainer()
+	calls := 0
+	_ = c.Register(counterProvider("req", core.RequestScope, &calls))
+
+	if _, err := c.Resolve("req"); !errors.Is(err, ErrNoRequestScope) {
+		t.Fatalf("Resolve() outside a request error = %v, want %v", err, ErrNoRequestScope)
+	}
+
+	ctx1 := newRequestContext()
+	a, err := c.Scope(ctx1).Resolve("req")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	b, _ := c.Scope(ctx1).Resolve("req")
+	if a != b {
+		t.Error("request-scoped provider should be shared within a request")
+	}
+
+	ctx2 := newRequestContext()
+	other, _ := c.Scope(ctx2).Resolve("req")
+	if other == a {
+		t.Error("request-scoped provider should not be shared between requests")
+	}
+	if calls != 2 {
+		t.Errorf("factory called %d times, want 2", calls)
+	}
+}
+
+func TestContainer_RequestScopeDependencies(t *testing.T) {
+	c := NewContainer()
+	calls := 0
+	_ = c.Register(counterProvider("req", core.RequestScope, &calls))
+	_ = c.Register(core.ProviderMetadata{
+		Token: "handler",
+		Scope: core.TransientScope,
+		Factory: func(c core.Container) (interface{}, error) {
+			return c.Resolve("req")
+		},
+	})
+	_ = c.Register(core.ProviderMetadata{
+		Token: "singleton",
+		Scope: core.SingletonScope,
+		Factory: func(c core.Container) (interface{}, error) {
+			return c.Resolve("req")
+		},
+	})
+
+	ctx := newRequestContext()
+	direct, _ := c.Scope(ctx).Resolve("req")
+	viaTransient, err := c.Scope(ctx).Resolve("handler")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if direct != viaTransient {
+		t.Error("transient dependency should receive the request's instance")
+	}
+
+	if _, err := c.Scope(ctx).Resolve("singleton"); !errors.Is(err, ErrNoRequestScope) {
+		t.Errorf("singleton depending on request scope error = %v, want %v", err, ErrNoRequestScope)
+	}
+}
+
+func TestContainer_CircularDependency(t *testing.T) {
+	c := NewContainer()
+	dependsOn := func(token, dep string) core.Provider {
+		return core.ProviderMetadata{
+			Token: token,
+			Factory: func(c core.Container) (interface{}, error) {
+				return c.Resolve(dep)
+			},
+		}
+	}
+	_ = c.Register(dependsOn("A", "B"))
+	_ = c.Register(dependsOn("B", "C"))
+	_ = c.Register(dependsOn("C", "A"))
+
+	_, err := c.Resolve("A")
+	if !errors.Is(err, ErrCircularDependency) {
+		t.Fatalf("Resolve() error = %v, want %v", err, ErrCircularDependency)
+	}
+	if !strings.Contains(err.Error(), "A -> B -> C -> A") {
+		t.Errorf("error %q should contain the full chain", err.Error())
+	}
+}
+
+func TestContainer_ConcurrentCircularDependency(t *testing.T) {
+	c := NewContainer()
+	started := map[string]chan struct{}{"A": make(chan struct{}), "B": make(chan struct{})}
+	once := map[string]*sync.Once{"A": {}, "B": {}}
+	dependsOn := func(token, dep string) core.Provider {
+		return core.ProviderMetadata{
+			Token: token,
+			Factory: func(c core.Container) (interface{}, error) {
+				// Both factories run before either resolves its dependency.
+				once[token].Do(func() {
+					close(started[token])
+					<-started[dep]
+				})
+				return c.Resolve(dep)
+			},
+		}
+	}
+	_ = c.Register(dependsOn("A", "B"))
+	_ = c.Register(dependsOn("B", "A"))
+
+	errs := make(chan error, 2)
+	for _, token := range []string{"A", "B"} {
+		go func(token string) {
+			_, err := c.Resolve(token)
+			errs <- err
+		}(token)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, ErrCircularDependency) {
+				t.Errorf("Resolve() error = %v, want %v", err, ErrCircularDependency)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Resolve() deadlocked")
+		}
+	}
+}
+
+func TestContainer_ConcurrentSingleton(t *testing.T) {
+	var events []string
+	var created atomic.Int32
+	c := NewContainer()
+	_ = c.Register(core.ProviderMetadata{
+		Token: "db",
+		Factory: func(core.Container) (interface{}, error) {
+			created.Add(1)
+			time.Sleep(10 * time.Millisecond)
+			return &hooked{name: "db", events: &events}, nil
+		},
+	})
+
+	const n = 50
+	instances := make(chan interface{}, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			instance, err := c.Resolve("db")
+			if err != nil {
+				t.Errorf("Resolve() error = %v", err)
+			}
+			instances <- instance
+		}()
+	}
+	wg.Wait()
+	close(instances)
+
+	if got := created.Load(); got != 1 {
+		t.Errorf("factory ran %d times, want 1", got)
+	}
+	first := <-instances
+	for instance := range instances {
+		if instance != first {
+			t.Fatalf("Resolve() returned different instances %p and %p", instance, first)
+		}
+	}
+	if len(events) != 1 {
+		t.Errorf("events = %v, want a single init", events)
+	}
+}
+
+func TestContainer_ResolveErrors(t *testing.T) {
+	c := NewContainer()
+	_ = c.Register(core.ProviderMetadata{
+		Token: "broken",
+		Factory: func(c core.Container) (interface{}, error) {
+			return nil, errors.New("boom")
+		},
+	})
+
+	if _, err := c.Resolve("missing"); !errors.Is(err, ErrProviderNotFound) {
+		t.Errorf("Resolve(missing) error = %v, want %v", err, ErrProviderNotFound)
+	}
+
+	_, err := c.Resolve("broken")
+	if err == nil || !strings.Contains(err.Error(), "failed to resolve broken: boom") {
+		t.Errorf("Resolve(broken) error = %v, want wrapped factory error", err)
+	}
+}
+
+func TestContainer_HasAndClear(t *testing.T) {
+	c := NewContainer()
+	calls := 0
+	_ = c.Register(counterProvider("svc", core.SingletonScope, &calls))
+
+	if !c.Has("svc") {
+		t.Error("Has(svc) should be true after Register")
+	}
+	if c.Has("other") || c.Has([]int{1}) {
+		t.Error("Has() should be false for unknown tokens")
+	}
+
+	c.Clear()
+	if c.Has("svc") {
+		t.Error("Has(svc) should be false after Clear")
+	}
+}
+
+func TestResolveAs(t *testing.T) {
+	c := NewContainer()
+	calls := 0
+	_ = c.Register(counterProvider(Token[*counter](), core.SingletonScope, &calls))
+
+	instance, err := ResolveAs[*counter](c, Token[*counter]())
+	if err != nil {
+		t.Fatalf("ResolveAs() error = %v", err)
+	}
+	if instance.id != 1 {
+		t.Errorf("instance.id = %d, want 1", instance.id)
+	}
+
+	if _, err := ResolveAs[string](c, Token[*counter]()); err == nil {
+		t.Error("ResolveAs() with the wrong type should return an error")
+	}
+}
+
+func TestFormatToken(t *testing.T) {
+	tests := []struct {
+		token interface{}
+		want  string
+	}{
+		{"users", "users"},
+		{Token[*counter](), "*di.counter"},
+		{core.RequestScope, "Request"},
+		{42, "int(42)"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatToken(tt.token); got != tt.want {
+			t.Errorf("FormatToken(%v) = %q, want %q", tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestContainer_Import(t *testing.T) {
+	database := NewContainer()
+	_ = database.Register(core.ProviderMetadata{
+		Token:   "dsn",
+		Factory: func(c core.Container) (interface{}, error) { return "postgres://", nil },
+	})
+	_ = database.Register(core.ProviderMetadata{
+		Token: "db",
+		Factory: func(c core.Container) (interface{}, error) {
+			dsn, err := c.Resolve("dsn")
+			if err != nil {
+				return nil, err
+			}
+			return "conn:" + dsn.(string), nil
+		},
+	})
+
+	app := NewContainer()
+	if err := app.Import(database, "db"); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	db, err := app.Resolve("db")
+	if err != nil {
+		t.Fatalf("Resolve(db) error = %v", err)
+	}
+	if db != "conn:postgres://" {
+		t.Errorf("Resolve(db) = %v, want %v", db, "conn:postgres://")
+	}
+	if again, _ := database.Resolve("db"); again != db {
+		t.Error("imported singleton should be shared with the source container")
+	}
+
+	if app.Has("dsn") {
+		t.Error("Has(dsn) should be false for a provider that was not imported")
+	}
+	if _, err := app.Resolve("dsn"); !errors.Is(err, ErrProviderNotFound) {
+		t.Errorf("Resolve(dsn) error = %v, want %v", err, ErrProviderNotFound)
+	}
+
+	if err := app.Import(database, "missing"); !errors.Is(err, ErrProviderNotFound) {
+		t.Errorf("Import(missing) error = %v, want %v", err, ErrProviderNotFound)
+	}
+
+	reexport := NewContainer()
+	if err := reexport.Import(app, "db"); err != nil {
+		t.Errorf("re-importing an imported token error = %v", err)
+	}
+}
+
+type hooked struct {
+	name   string
+	events *[]string
+	fail   bool
+}
+
+func (h *hooked) OnInit() error {
+	*h.events = append(*h.events, "init:"+h.name)
+	return nil
+}
+
+func (h *hooked) OnDestroy() error {
+	*h.events = append(*h.events, "destroy:"+h.name)
+	if h.fail {
+		return errors.New(h.name + " failed")
+	}
+	return nil
+}
+
+func TestContainer_LifecycleHooks(t *testing.T) {
+	var events []string
+	c := NewContainer()
+	register := func(name string, fail bool, deps ...string) {
+		_ = c.Register(core.ProviderMetadata{
+			Token: name,
+			Factory: func(c core.Container) (interface{}, error) {
+				for _, dep := range deps {
+					if _, err := c.Resolve(dep); err != nil {
+						return nil, err
+					}
+				}
+				return &hooked{name: name, events: &events, fail: fail}, nil
+			},
+		})
+	}
+	register("db", true)
+	register("cache", false)
+	register("users", false, "db", "cache")
+
+	if _, err := c.Resolve("users"); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	err := c.Destroy()
+	if err == nil || !strings.Contains(err.Error(), "failed to destroy db: db failed") {
+		t.Errorf("Destroy() error = %v, want the db failure", err)
+	}
+
+	expected := "init:db,init:cache,init:users,destroy:users,destroy:cache,destroy:db"
+	if got := strings.Join(events, ","); got != expected {
+		t.Errorf("lifecycle order = %s, want %s", got, expected)
+	}
+
+	if err := c.Destroy(); err != nil {
+		t.Errorf("second Destroy() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,344 @@
+// Package logger provides the default implementation of core.Logger.
+//
+// Records are written as JSON objects, one per line, or in a human-readable
+// console format. Child loggers created with Named and With share the output
+// and the level of their parent, so SetLevel on any of them applies to all:
+//
+//	log := logger.New(logger.Options{Level: core.LogLevelInfo, Format: logger.FormatJSON})
+//	users := log.Named("UsersModule")
+//	users.Info("user created", "id", 42)
+//	// {"time":"...","level":"INFO","context":"UsersModule","msg":"user created","id":42}
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+)
+
+// Format selects how records are written.
+type Format int
+
+const (
+	// FormatConsole writes human-readable lines such as
+	// "2025-01-02T15:04:05.000Z INFO  [UsersModule] user created id=42".
+	FormatConsole Format = iota
+	// FormatJSON writes one JSON object per line.
+	FormatJSON
+)
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case FormatConsole:
+		return "console"
+	case FormatJSON:
+		return "json"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultTimeFormat is the layout of record timestamps.
+const DefaultTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// badKey is the key of a value passed without a key.
+const badKey = "!BADKEY"
+
+// Options configures a Logger.
+type Options struct {
+	// Level is the minimum level of the written records
+	Level core.LogLevel
+	// Format selects JSON or console output
+	Format Format
+	// Output receives the records; nil writes to os.Stderr
+	Output io.Writer
+	// Name is the context of the records of the root logger
+	Name string
+	// Color highlights levels with ANSI colors in the console format
+	Color bool
+	// TimeFormat is the layout of timestamps; empty selects DefaultTimeFormat
+	TimeFormat string
+}
+
+// exit terminates the process after a fatal record; replaced in tests.
+var exit = os.Exit
+
+// sink is the output and level shared by a logger and its children.
+type sink struct {
+	// mu serializes writes to out
+	mu sync.Mutex
+
+	// out, format, color and timeFormat are the output settings of Options
+	out        io.Writer
+	format     Format
+	color      bool
+	timeFormat string
+
+	// level is the minimum core.LogLevel of written records
+	level atomic.Int32
+
+	// now returns the time of a record; replaced in tests
+	now func() time.Time
+}
+
+// field is a key-value pair of a record.
+type field struct {
+	key   string
+	value interface{}
+}
+
+// Logger is the default implementation of core.Logger. It is safe for concurrent use.
+type Logger struct {
+	// sink is the output shared with the parent and children of the logger
+	sink *sink
+
+	// name is the context of the records
+	name string
+
+	// fields are added to every record
+	fields []field
+}
+
+// Ensure Logger implements core.Logger.
+var _ core.Logger = (*Logger)(nil)
+
+// New creates a root Logger.
+func New(opts Options) *Logger {
+	s := &sink{
+		out:        opts.Output,
+		format:     opts.Format,
+		color:      opts.Color,
+		timeFormat: opts.TimeFormat,
+		now:        time.Now,
+	}
+	if s.out == nil {
+		s.out = os.Stderr
+	}
+	if s.timeFormat == "" {
+		s.timeFormat = DefaultTimeFormat
+	}
+	s.level.Store(int32(opts.Level))
+	return &Logger{sink: s, name: opts.Name}
+}
+
+// ParseLevel parses a level name such as "debug" or "WARN". "warning" is
+// accepted as an alias of "warn".
+func ParseLevel(s string) (core.LogLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "DEBUG":
+		return core.LogLevelDebug, nil
+	case "INFO":
+		return core.LogLevelInfo, nil
+	case "WARN", "WARNING":
+		return core.LogLevelWarn, nil
+	case "ERROR":
+		return core.LogLevelError, nil
+	case "FATAL":
+		return core.LogLevelFatal, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+}
+
+// Debug logs a message at core.LogLevelDebug.
+func (l *Logger) Debug(msg string, keyvals ...interface{}) {
+	l.Log(core.LogLevelDebug, msg, keyvals...)
+}
+
+// Info logs a message at core.LogLevelInfo.
+func (l *Logger) Info(msg string, keyvals ...interface{}) {
+	l.Log(core.LogLevelInfo, msg, keyvals...)
+}
+
+// Warn logs a message at core.LogLevelWarn.
+func (l *Logger) Warn(msg string, keyvals ...interface{}) {
+	l.Log(core.LogLevelWarn, msg, keyvals...)
+}
+
+// Error logs a message at core.LogLevelError.
+func (l *Logger) Error(msg string, keyvals ...interface{}) {
+	l.Log(core.LogLevelError, msg, keyvals...)
+}
+
+// Fatal logs a message at core.LogLevelFatal, then exits the process with status 1.
+func (l *Logger) Fatal(msg string, keyvals ...interface{}) {
+	l.Log(core.LogLevelFatal, msg, keyvals...)
+	exit(1)
+}
+
+// Log logs a message at the given level. Write errors are ignored.
+func (l *Logger) Log(level core.LogLevel, msg string, keyvals ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	fields := append(append([]field(nil), l.fields...), toFields(keyvals)...)
+
+	var buf bytes.Buffer
+	t := l.sink.now()
+	if l.sink.format == FormatJSON {
+		writeJSON(&buf, t.Format(l.sink.timeFormat), level, l.name, msg, fields)
+	} else {
+		writeConsole(&buf, t.Format(l.sink.timeFormat), level, l.name, msg, fields, l.sink.color)
+	}
+
+	l.sink.mu.Lock()
+	defer l.sink.mu.Unlock()
+	_, _ = l.sink.out.Write(buf.Bytes())
+}
+
+// With returns a child logger adding keyvals to every record.
+func (l *Logger) With(keyvals ...interface{}) core.Logger {
+	return &Logger{
+		sink:   l.sink,
+		name:   l.name,
+		fields: append(append([]field(nil), l.fields...), toFields(keyvals)...),
+	}
+}
+
+// Named returns a child logger whose records carry name as their context.
+func (l *Logger) Named(name string) core.Logger {
+	if l.name != "" && name != "" {
+		name = l.name + "." + name
+	} else if name == "" {
+		name = l.name
+	}
+	return &Logger{sink: l.sink, name: name, fields: l.fields}
+}
+
+// Enabled reports whether records at level are written.
+func (l *Logger) Enabled(level core.LogLevel) bool {
+	return level >= l.Level()
+}
+
+// Level returns the minimum level of the written records.
+func (l *Logger) Level() core.LogLevel {
+	return core.LogLevel(l.sink.level.Load())
+}
+
+// SetLevel changes the minimum level of the logger, its parents and its children.
+func (l *Logger) SetLevel(level core.LogLevel) {
+	l.sink.level.Store(int32(level))
+}
+
+// toFields pairs keyvals into fields. Keys that are not strings are
+// formatted with fmt.Sprint, and a trailing value without a key gets the
+// key "!BADKEY".
+func toFields(keyvals []interface{}) []field {
+	fields := make([]field, 0, (len(keyvals)+1)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 == len(keyvals) {
+			fields = append(fields, field{key: badKey, value: keyvals[i]})
+			break
+		}
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyvals[i])
+		}
+		fields = append(fields, field{key: key, value: keyvals[i+1]})
+	}
+	return fields
+}
+
+// plainValue returns the representation of values that do not encode well as
+// JSON: errors become their message and durations their string form.
+func plainValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	default:
+		return v
+	}
+}
+
+// writeJSON writes a record as a JSON object followed by a newline.
+func writeJSON(buf *bytes.Buffer, t string, level core.LogLevel, name, msg string, fields []field) {
+	buf.WriteString(`{"time":`)
+	writeJSONValue(buf, t)
+	buf.WriteString(`,"level":`)
+	writeJSONValue(buf, level.String())
+	if name != "" {
+		buf.WriteString(`,"context":`)
+		writeJSONValue(buf, name)
+	}
+	buf.WriteString(`,"msg":`)
+	writeJSONValue(buf, msg)
+	for _, f := range fields {
+		buf.WriteByte(',')
+		writeJSONValue(buf, f.key)
+		buf.WriteByte(':')
+		writeJSONValue(buf, plainValue(f.value))
+	}
+	buf.WriteString("}\n")
+}
+
+// writeJSONValue writes v as JSON without HTML escaping. Values that cannot
+// be encoded are written as strings formatted with %+v.
+func writeJSONValue(buf *bytes.Buffer, v interface{}) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		b.Reset()
+		_ = enc.Encode(fmt.Sprintf("%+v", v))
+	}
+	buf.Write(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+}
+
+// levelColors are the ANSI colors of the levels in the console format.
+var levelColors = map[core.LogLevel]string{
+	core.LogLevelDebug: "\x1b[35m",
+	core.LogLevelInfo:  "\x1b[32m",
+	core.LogLevelWarn:  "\x1b[33m",
+	core.LogLevelError: "\x1b[31m",
+	core.LogLevelFatal: "\x1b[1;31m",
+}
+
+// writeConsole writes a record as a human-readable line.
+func writeConsole(buf *bytes.Buffer, t string, level core.LogLevel, name, msg string, fields []field, color bool) {
+	buf.WriteString(t)
+	buf.WriteByte(' ')
+	label := fmt.Sprintf("%-5s", level.String())
+	if c, ok := levelColors[level]; ok && color {
+		label = c + label + "\x1b[0m"
+	}
+	buf.WriteString(label)
+	if name != "" {
+		buf.WriteString(" [")
+		buf.WriteString(name)
+		buf.WriteByte(']')
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(msg)
+	for _, f := range fields {
+		buf.WriteByte(' ')
+		buf.WriteString(f.key)
+		buf.WriteByte('=')
+		buf.WriteString(consoleValue(plainValue(f.value)))
+	}
+	buf.WriteByte('\n')
+}
+
+// consoleValue formats a field value, quoting strings that are empty or
+// contain spaces, quotes, equal signs or control characters.
+func consoleValue(v interface{}) string {
+	s, ok := v.(string)
+	if !ok {
+		s = fmt.Sprintf("%+v", v)
+	}
+	if s == "" || strings.ContainsAny(s, " =") || strconv.Quote(s) != `"`+s+`"` {
+		return strconv.Quote(s)
+	}
+	return s
+}
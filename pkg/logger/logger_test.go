@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+)
+
+// newTestLogger returns a logger writing to buf at a fixed time.
+func newTestLogger(buf *bytes.Buffer, opts Options) *Logger {
+	opts.Output = buf
+	l := New(opts)
+	l.sink.now = func() time.Time { return time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC) }
+	return l
+}
+
+func TestLogger_JSON(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l core.Logger)
+		want string
+	}{
+		{"message", func(l core.Logger) { l.Info("started") },
+			`{"time":"2025-01-02T15:04:05.000Z","level":"INFO","msg":"started"}`},
+		{"fields", func(l core.Logger) { l.Warn("slow", "route", "/users", "took", 1500*time.Millisecond, "rows", 3) },
+			`{"time":"2025-01-02T15:04:05.000Z","level":"WARN","msg":"slow","route":"/users","took":"1.5s","rows":3}`},
+		{"error value", func(l core.Logger) { l.Error("failed", "err", errors.New("<boom>")) },
+			`{"time":"2025-01-02T15:04:05.000Z","level":"ERROR","msg":"failed","err":"<boom>"}`},
+		{"missing value", func(l core.Logger) { l.Info("odd", "orphan") },
+			`{"time":"2025-01-02T15:04:05.000Z","level":"INFO","msg":"odd","!BADKEY":"orphan"}`},
+		{"non-string key", func(l core.Logger) { l.Info("key", 7, true) },
+			`{"time":"2025-01-02T15:04:05.000Z","level":"INFO","msg":"key","7":true}`},
+		{"unencodable value", func(l core.Logger) { l.Info("chan", "c", make(chan int)) },
+			`"c":"0x`},
+		{"child", func(l core.Logger) { l.Named("UsersModule").With("request", "r1").Info("created", "id", 42) },
+			`{"time":"2025-01-02T15:04:05.000Z","level":"INFO","context":"UsersModule","msg":"created","request":"r1","id":42}`},
+		{"nested names", func(l core.Logger) { l.Named("Users").Named("Repository").Debug("query") },
+			`"context":"Users.Repository"`},
+		{"filtered", func(l core.Logger) { l.Debug("hidden") }, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			level := core.LogLevelInfo
+			if tt.name == "nested names" {
+				level = core.LogLevelDebug
+			}
+			tt.log(newTestLogger(&buf, Options{Level: level, Format: FormatJSON}))
+
+			got := strings.TrimSuffix(buf.String(), "\n")
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("record = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogger_Console(t *testing.T) {
+	tests := []struct {
+		name  string
+		color bool
+		log   func(l core.Logger)
+		want  string
+	}{
+		{"message", false, func(l core.Logger) { l.Info("started") },
+			"2025-01-02T15:04:05.000Z INFO  started\n"},
+		{"context and fields", false, func(l core.Logger) {
+			l.Named("UsersModule").Error("failed", "id", 42, "err", errors.New("not found"), "empty", "", "tab", "a\tb")
+		}, "2025-01-02T15:04:05.000Z ERROR [UsersModule] failed id=42 err=\"not found\" empty=\"\" tab=\"a\\tb\"\n"},
+		{"color", true, func(l core.Logger) { l.Warn("careful") },
+			"2025-01-02T15:04:05.000Z \x1b[33mWARN \x1b[0m careful\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(newTestLogger(&buf, Options{Color: tt.color}))
+			if buf.String() != tt.want {
+				t.Errorf("record = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLogger_SetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	root := newTestLogger(&buf, Options{Level: core.LogLevelWarn, Format: FormatJSON})
+	child := root.Named("Users")
+
+	child.Info("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("Info() below the level wrote %q", buf.String())
+	}
+
+	child.SetLevel(core.LogLevelDebug)
+	if root.Level() != core.LogLevelDebug {
+		t.Errorf("root Level() = %v, want %v", root.Level(), core.LogLevelDebug)
+	}
+	root.Debug("visible")
+	if !strings.Contains(buf.String(), `"msg":"visible"`) {
+		t.Errorf("Debug() after SetLevel wrote %q", buf.String())
+	}
+}
+
+func TestLogger_Fatal(t *testing.T) {
+	var code int
+	defer func(restore func(int)) { exit = restore }(exit)
+	exit = func(c int) { code = c }
+
+	var buf bytes.Buffer
+	newTestLogger(&buf, Options{Level: core.LogLevelError, Format: FormatJSON}).Fatal("bye")
+	if code != 1 || !strings.Contains(buf.String(), `"level":"FATAL"`) {
+		t.Errorf("Fatal() = exit %d, record %q, want exit 1 and a FATAL record", code, buf.String())
+	}
+}
+
+func TestLogger_Concurrent(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf, Options{Format: FormatJSON})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l.Named("worker").Info("tick", "n", i)
+		}(i)
+	}
+	wg.Wait()
+
+	if lines := strings.Count(buf.String(), "\n"); lines != 20 {
+		t.Errorf("concurrent records = %d lines, want 20", lines)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    core.LogLevel
+		wantErr bool
+	}{
+		{"debug", core.LogLevelDebug, false},
+		{" INFO ", core.LogLevelInfo, false},
+		{"warning", core.LogLevelWarn, false},
+		{"Error", core.LogLevelError, false},
+		{"fatal", core.LogLevelFatal, false},
+		{"verbose", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLevel(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
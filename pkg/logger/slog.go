@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+)
+
+// slogHandler is a slog.Handler writing to a core.Logger.
+type slogHandler struct {
+	// logger receives the records
+	logger core.Logger
+
+	// group prefixes the keys of the attributes, ending with a dot when set
+	group string
+}
+
+// NewSlogHandler returns a slog.Handler writing records to l, so that
+// libraries logging through log/slog share the output of the application.
+// slog levels map to the closest core.LogLevel at or below them, attribute
+// groups become dotted key prefixes and the record time is replaced by the
+// time the record is written.
+//
+// Example:
+//
+//	slog.SetDefault(slog.New(logger.NewSlogHandler(log.Named("db"))))
+func NewSlogHandler(l core.Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+// Slog returns a slog.Logger writing to l.
+func (l *Logger) Slog() *slog.Logger {
+	return slog.New(NewSlogHandler(l))
+}
+
+// Enabled reports whether records at level are written.
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Enabled(fromSlogLevel(level))
+}
+
+// Handle writes r to the logger.
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	keyvals := make([]interface{}, 0, 2*r.NumAttrs())
+	r.Attrs(func(attr slog.Attr) bool {
+		keyvals = appendAttr(keyvals, h.group, attr)
+		return true
+	})
+	h.logger.Log(fromSlogLevel(r.Level), r.Message, keyvals...)
+	return nil
+}
+
+// WithAttrs returns a handler adding attrs to every record.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var keyvals []interface{}
+	for _, attr := range attrs {
+		keyvals = appendAttr(keyvals, h.group, attr)
+	}
+	return &slogHandler{logger: h.logger.With(keyvals...), group: h.group}
+}
+
+// WithGroup returns a handler prefixing the keys of later attributes with name.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{logger: h.logger, group: h.group + name + "."}
+}
+
+// appendAttr appends the key-value pairs of attr to keyvals, flattening
+// groups into dotted keys and dropping empty attributes.
+func appendAttr(keyvals []interface{}, prefix string, attr slog.Attr) []interface{} {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return keyvals
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, a := range attr.Value.Group() {
+			keyvals = appendAttr(keyvals, prefix, a)
+		}
+		return keyvals
+	}
+	return append(keyvals, prefix+attr.Key, attr.Value.Any())
+}
+
+// fromSlogLevel maps a slog level to the closest core.LogLevel at or below it.
+func fromSlogLevel(level slog.Level) core.LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return core.LogLevelDebug
+	case level < slog.LevelWarn:
+		return core.LogLevelInfo
+	case level < slog.LevelError:
+		return core.LogLevelWarn
+	default:
+		return core.LogLevelError
+	}
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+)
+
+func TestSlogHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(l *slog.Logger)
+		want string
+	}{
+		{"message", func(l *slog.Logger) { l.Info("connected", "pool", 4) },
+			`"level":"INFO","context":"db","msg":"connected","pool":4}`},
+		{"levels", func(l *slog.Logger) { l.Log(context.Background(), slog.LevelWarn+2, "retrying") },
+			`"level":"WARN","context":"db","msg":"retrying"}`},
+		{"attrs and groups", func(l *slog.Logger) {
+			l.With("driver", "pg").WithGroup("query").Error("failed", "sql", "SELECT 1", slog.Group("args", "n", 1))
+		}, `"msg":"failed","driver":"pg","query.sql":"SELECT 1","query.args.n":1}`},
+		{"empty group", func(l *slog.Logger) { l.WithGroup("").Info("plain", slog.Attr{}, "k", "v") },
+			`"msg":"plain","k":"v"}`},
+		{"filtered", func(l *slog.Logger) { l.Debug("hidden") }, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			root := newTestLogger(&buf, Options{Level: core.LogLevelInfo, Format: FormatJSON})
+			tt.log(slog.New(NewSlogHandler(root.Named("db"))))
+
+			got := strings.TrimSuffix(buf.String(), "\n")
+			if tt.want == "" && got != "" || !strings.HasSuffix(got, tt.want) {
+				t.Errorf("record = %s, want suffix %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogger_Slog(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf, Options{Level: core.LogLevelDebug})
+	l.Slog().Debug("shared", "sink", true)
+
+	if buf.String() != "2025-01-02T15:04:05.000Z DEBUG shared sink=true\n" {
+		t.Errorf("Slog() record = %q", buf.String())
+	}
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+	"github.com/gsoares85/goaegis/pkg/logger"
+)
+
+// DefaultRequestIDHeader is the header carrying the ID of a request.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// AccessLogOptions configures the AccessLog middleware.
+type AccessLogOptions struct {
+	// Logger receives the records; nil selects the application logger named "HTTP",
+	// or a console logger named "HTTP" outside an application
+	Logger core.Logger
+	// SkipPaths lists the request paths that are not logged, such as health checks
+	SkipPaths []string
+	// Skip reports whether a request is not logged, in addition to SkipPaths
+	Skip func(ctx core.Context) bool
+	// RequestIDHeader is the header carrying the request ID when no ID was set with
+	// Context.SetRequestID, read from the response first and then from the request;
+	// empty selects DefaultRequestIDHeader
+	RequestIDHeader string
+}
+
+// AccessLog returns a middleware logging one record per request with its
+// method, path, status, response size, latency, client IP, user agent and
+// request ID. Responses with a 5xx status are logged at error level, 4xx at
+// warn level and others at info level.
+//
+// Errors returned by the rest of the chain are handed to
+// core.ResponseContext.HandleError before logging, so that the record
+// carries the status of the error response; the middleware then returns nil.
+// Panics are logged with a 500 status and propagated to the Recover
+// middleware. Contexts not implementing core.ResponseContext are passed
+// through without being logged.
+//
+// Example:
+//
+//	app.Use(middleware.AccessLog(middleware.AccessLogOptions{
+//	    SkipPaths: []string{"/healthz"},
+//	}))
+func AccessLog(opts AccessLogOptions) core.Middleware {
+	var fallback core.Logger
+	if opts.Logger == nil {
+		fallback = logger.New(logger.Options{Level: core.LogLevelInfo}).Named("HTTP")
+	}
+	header := opts.RequestIDHeader
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+	skipPaths := make(map[string]bool, len(opts.SkipPaths))
+	for _, path := range opts.SkipPaths {
+		skipPaths[path] = true
+	}
+
+	return func(ctx core.Context, next core.HandlerFunc) error {
+		rc, ok := ctx.(core.ResponseContext)
+		if !ok || skipPaths[ctx.Path()] || opts.Skip != nil && opts.Skip(ctx) {
+			return next(ctx)
+		}
+
+		log := opts.Logger
+		if log == nil {
+			if log = core.LoggerFrom(ctx); log != nil {
+				log = log.Named("HTTP")
+			} else {
+				log = fallback
+			}
+		}
+
+		start := time.Now()
+		writer := &statusWriter{ResponseWriter: ctx.Response()}
+		rc.SetResponse(writer)
+
+		var err error
+		defer func() {
+			r := recover()
+			status := writer.Status()
+			if r != nil && writer.status == 0 {
+				status = http.StatusInternalServerError
+			}
+
+			keyvals := []interface{}{
+				"method", ctx.Method(),
+				"path", ctx.Path(),
+				"status", status,
+				"bytes", writer.size,
+				"latency", time.Since(start),
+				"ip", ctx.ClientIP(),
+				"user_agent", ctx.UserAgent(),
+			}
+			requestID := ctx.RequestID()
+			if requestID == "" {
+				requestID = writer.Header().Get(header)
+			}
+			if requestID == "" {
+				requestID = ctx.GetHeader(header)
+			}
+			if requestID != "" {
+				keyvals = append(keyvals, "request_id", requestID)
+			}
+			if err != nil {
+				keyvals = append(keyvals, "error", err)
+			}
+			if p, ok := r.(*core.PanicError); ok {
+				keyvals = append(keyvals, "panic", fmt.Sprint(p.Value))
+			} else if r != nil {
+				keyvals = append(keyvals, "panic", fmt.Sprint(r))
+			}
+			log.Log(statusLevel(status), "request completed", keyvals...)
+
+			if r != nil {
+				panic(r)
+			}
+		}()
+
+		if err = next(ctx); err != nil {
+			rc.HandleError(err)
+		}
+		return nil
+	}
+}
+
+// statusLevel returns the log level of a response status.
+func statusLevel(status int) core.LogLevel {
+	switch {
+	case status >= 500:
+		return core.LogLevelError
+	case status >= 400:
+		return core.LogLevelWarn
+	default:
+		return core.LogLevelInfo
+	}
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+	"github.com/gsoares85/goaegis/pkg/logger"
+	"github.com/gsoares85/goaegis/pkg/router"
+)
+
+// accessLogRouter returns a router logging to buf, with routes answering with several statuses.
+func accessLogRouter(buf *bytes.Buffer, opts AccessLogOptions) *router.Router {
+	opts.Logger = logger.New(logger.Options{Output: buf, Format: logger.FormatJSON})
+	r := router.NewRouter()
+	r.Use(AccessLog(opts))
+	r.GET("/users", func(ctx core.Context) error {
+		return ctx.String(http.StatusOK, "alice,bob")
+	})
+	r.POST("/users", func(ctx core.Context) error {
+		return core.BadRequest("Invalid user")
+	})
+	r.GET("/crash", func(ctx core.Context) error {
+		return errors.New("database down")
+	})
+	r.GET("/healthz", func(ctx core.Context) error {
+		return ctx.NoContent(http.StatusNoContent)
+	})
+	return r
+}
+
+func TestAccessLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		level  string
+		status float64
+		bytes  float64
+		err    string
+	}{
+		{"success", "GET", "/users", "INFO", 200, 9, ""},
+		{"client error", "POST", "/users", "WARN", 400, -1, "400 Bad Request: Invalid user"},
+		{"server error", "GET", "/crash", "ERROR", 500, -1, "database down"},
+		{"not found", "GET", "/missing", "WARN", 404, -1, "404 Not Found: Cannot GET /missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := accessLogRouter(&buf, AccessLogOptions{})
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.RemoteAddr = "192.0.2.7:4321"
+			req.Header.Set("User-Agent", "curl/8.0")
+			req.Header.Set("X-Request-ID", "req-1")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			var record map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("record %q: %v", buf.String(), err)
+			}
+			if record["level"] != tt.level || record["status"] != tt.status || w.Code != int(tt.status) {
+				t.Errorf("record level %v status %v, response %d, want %s %v", record["level"], record["status"], w.Code, tt.level, tt.status)
+			}
+			wantBytes := tt.bytes
+			if wantBytes < 0 {
+				wantBytes = float64(w.Body.Len())
+			}
+			if record["bytes"] != wantBytes {
+				t.Errorf("record bytes = %v, want %v", record["bytes"], wantBytes)
+			}
+			want := map[string]interface{}{
+				"msg": "request completed", "method": tt.method, "path": tt.path,
+				"ip": "192.0.2.7", "user_agent": "curl/8.0", "request_id": "req-1",
+			}
+			for key, value := range want {
+				if record[key] != value {
+					t.Errorf("record %s = %v, want %v", key, record[key], value)
+				}
+			}
+			if _, ok := record["latency"].(string); !ok {
+				t.Errorf("record latency = %v, want a duration", record["latency"])
+			}
+			if got, _ := record["error"].(string); got != tt.err {
+				t.Errorf("record error = %q, want %q", got, tt.err)
+			}
+		})
+	}
+}
+
+func TestAccessLog_Skip(t *testing.T) {
+	var buf bytes.Buffer
+	r := accessLogRouter(&buf, AccessLogOptions{
+		SkipPaths: []string{"/healthz"},
+		Skip:      func(ctx core.Context) bool { return ctx.GetHeader("X-Synthetic") != "" },
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+	logged := buf.Len()
+	if logged == 0 {
+		t.Fatal("GET /users was not logged")
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/healthz", nil))
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("X-Synthetic", "1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if w.Code != http.StatusNoContent || buf.Len() != logged {
+		t.Errorf("skipped requests logged %q", buf.String()[logged:])
+	}
+}
+
+func TestAccessLog_ResponseRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	r := accessLogRouter(&buf, AccessLogOptions{RequestIDHeader: "X-Trace"})
+	r.GET("/traced", func(ctx core.Context) error {
+		ctx.SetHeader("X-Trace", "generated")
+		return ctx.NoContent(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest("GET", "/traced", nil)
+	req.Header.Set("X-Trace", "incoming")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]interface{}
+	_ = json.Unmarshal(buf.Bytes(), &record)
+	if record["request_id"] != "generated" {
+		t.Errorf("record request_id = %v, want %q", record["request_id"], "generated")
+	}
+}
+
+func TestAccessLog_ApplicationLogger(t *testing.T) {
+	var buf bytes.Buffer
+	r := router.NewRouter()
+	r.SetLogger(logger.New(logger.Options{Output: &buf, Format: logger.FormatJSON, Level: core.LogLevelWarn}))
+	r.Use(AccessLog(AccessLogOptions{}))
+	r.GET("/users", func(ctx core.Context) error {
+		return ctx.String(http.StatusOK, "alice,bob")
+	})
+	r.GET("/missing", func(ctx core.Context) error {
+		return core.NotFound("No such user")
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+	if buf.Len() != 0 {
+		t.Errorf("GET /users logged %q below the application level", buf.String())
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("record %q is not JSON: %v", buf.String(), err)
+	}
+	if record["context"] != "HTTP" || record["status"] != float64(http.StatusNotFound) {
+		t.Errorf("record = %v, want context HTTP and status 404", record)
+	}
+}
@@ -0,0 +1,263 @@
+// Package middleware provides the built-in middleware of GoAegis.
+//
+// Every constructor returns a core.Middleware, which can be installed on the
+// application, a router group, a module or a single route:
+//
+//	app.Use(middleware.CORS(core.CORSOptions{
+//	    AllowOrigins: []string{"https://app.example.com"},
+//	}))
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+)
+
+// defaultCORSMethods are the methods allowed when CORSOptions.AllowMethods is empty.
+var defaultCORSMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete,
+}
+
+// cors is the compiled form of core.CORSOptions.
+type cors struct {
+	// opts are the options the middleware was created with
+	opts core.CORSOptions
+
+	// anyOrigin allows every origin
+	anyOrigin bool
+
+	// origins holds the exact allowed origins, lowercased
+	origins map[string]bool
+
+	// wildcards holds the prefix and suffix around the "*" of wildcard origins
+	wildcards [][2]string
+
+	// methods holds the allowed methods, uppercased
+	methods map[string]bool
+
+	// anyHeader allows every requested header
+	anyHeader bool
+
+	// headers holds the allowed request headers in canonical form
+	headers map[string]bool
+
+	// allowMethods, exposeHeaders and maxAge are the preformatted header values
+	allowMethods  string
+	exposeHeaders string
+	maxAge        string
+}
+
+// errCORSCredentials is returned for CORS options allowing credentials from any origin.
+var errCORSCredentials = errors.New("middleware: CORS AllowCredentials requires AllowOrigins without \"*\", AllowOriginPatterns or AllowOriginFunc")
+
+// CORS returns a middleware implementing Cross-Origin Resource Sharing.
+// It panics on invalid options, see NewCORS.
+//
+// Preflight requests, OPTIONS requests carrying Origin and
+// Access-Control-Request-Method, are answered with a 204 response without
+// reaching the router, so routes registered for GET or POST only need no
+// OPTIONS handler. The middleware must therefore be installed on the
+// application or the root router; on a group, preflight requests for its
+// routes end in a 405 response. Requests from disallowed origins get no
+// Access-Control-* headers and are left for the browser to block.
+//
+// Example:
+//
+//	app.Use(middleware.CORS(core.CORSOptions{
+//	    AllowOrigins:     []string{"https://*.example.com"},
+//	    AllowCredentials: true,
+//	    MaxAge:           600,
+//	}))
+func CORS(opts core.CORSOptions) core.Middleware {
+	mw, err := NewCORS(opts)
+	if err != nil {
+		panic(err)
+	}
+	return mw
+}
+
+// NewCORS is like CORS but returns an error on invalid options. Allowing
+// credentials requires an explicit origin restriction: with any origin
+// allowed, every site could make authenticated requests on behalf of the
+// user.
+func NewCORS(opts core.CORSOptions) (core.Middleware, error) {
+	c := &cors{
+		opts:    opts,
+		origins: make(map[string]bool),
+		methods: make(map[string]bool),
+		headers: make(map[string]bool),
+	}
+
+	c.anyOrigin = len(opts.AllowOrigins) == 0 && len(opts.AllowOriginPatterns) == 0 && opts.AllowOriginFunc == nil
+	for _, origin := range opts.AllowOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "*":
+			c.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			c.wildcards = append(c.wildcards, [2]string{prefix, suffix})
+		default:
+			c.origins[origin] = true
+		}
+	}
+
+	methods := opts.AllowMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	var allowMethods []string
+	for _, method := range methods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		allowMethods = append(allowMethods, method)
+		c.methods[method] = true
+	}
+	c.allowMethods = strings.Join(allowMethods, ", ")
+
+	c.anyHeader = len(opts.AllowHeaders) == 0
+	for _, header := range opts.AllowHeaders {
+		if header = strings.TrimSpace(header); header == "*" {
+			c.anyHeader = true
+		} else {
+			c.headers[http.CanonicalHeaderKey(header)] = true
+		}
+	}
+
+	if opts.AllowCredentials && c.anyOrigin {
+		return nil, errCORSCredentials
+	}
+
+	c.exposeHeaders = strings.Join(opts.ExposeHeaders, ", ")
+	if opts.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(opts.MaxAge)
+	}
+
+	return c.handle, nil
+}
+
+// handle is the middleware function of c.
+func (c *cors) handle(ctx core.Context, next core.HandlerFunc) error {
+	header := ctx.Response().Header()
+	origin := ctx.GetHeader("Origin")
+	preflight := ctx.Method() == http.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != ""
+
+	if c.varyByOrigin() {
+		addVary(header, "Origin")
+	}
+	if preflight {
+		addVary(header, "Access-Control-Request-Method", "Access-Control-Request-Headers")
+	}
+
+	if origin == "" {
+		return next(ctx)
+	}
+	allowed := c.allowOrigin(origin)
+
+	if preflight {
+		if allowed {
+			c.preflight(ctx, header, origin)
+		}
+		return ctx.NoContent(http.StatusNoContent)
+	}
+
+	if allowed {
+		c.setOrigin(header, origin)
+		if c.exposeHeaders != "" {
+			header.Set("Access-Control-Expose-Headers", c.exposeHeaders)
+		}
+	}
+	return next(ctx)
+}
+
+// preflight sets the headers of an allowed preflight response, unless the
+// requested method or headers are not allowed.
+func (c *cors) preflight(ctx core.Context, header http.Header, origin string) {
+	method := strings.ToUpper(ctx.GetHeader("Access-Control-Request-Method"))
+	if !c.methods[method] {
+		return
+	}
+
+	requested := ctx.Request().Header.Values("Access-Control-Request-Headers")
+	var headers []string
+	for _, value := range requested {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if !c.anyHeader && !c.headers[http.CanonicalHeaderKey(name)] {
+				return
+			}
+			headers = append(headers, name)
+		}
+	}
+
+	c.setOrigin(header, origin)
+	header.Set("Access-Control-Allow-Methods", c.allowMethods)
+	if len(headers) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	if c.maxAge != "" {
+		header.Set("Access-Control-Max-Age", c.maxAge)
+	}
+}
+
+// setOrigin sets the Access-Control-Allow-Origin and -Credentials headers.
+func (c *cors) setOrigin(header http.Header, origin string) {
+	if c.varyByOrigin() {
+		header.Set("Access-Control-Allow-Origin", origin)
+	} else {
+		header.Set("Access-Control-Allow-Origin", "*")
+	}
+	if c.opts.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// varyByOrigin reports whether responses depend on the Origin request
+// header, which is the case unless every origin is answered with "*".
+func (c *cors) varyByOrigin() bool {
+	return !c.anyOrigin || c.opts.AllowCredentials
+}
+
+// allowOrigin reports whether origin is allowed.
+func (c *cors) allowOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if c.origins[lower] {
+		return true
+	}
+	for _, w := range c.wildcards {
+		if len(lower) > len(w[0])+len(w[1]) && strings.HasPrefix(lower, w[0]) && strings.HasSuffix(lower, w[1]) {
+			return true
+		}
+	}
+	for _, pattern := range c.opts.AllowOriginPatterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return c.opts.AllowOriginFunc != nil && c.opts.AllowOriginFunc(origin)
+}
+
+// addVary adds values to the Vary header, skipping those already listed.
+func addVary(header http.Header, values ...string) {
+	present := make(map[string]bool)
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			present[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+		}
+	}
+	for _, value := range values {
+		if !present[http.CanonicalHeaderKey(value)] {
+			header.Add("Vary", value)
+			present[http.CanonicalHeaderKey(value)] = true
+		}
+	}
+}
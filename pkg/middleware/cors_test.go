@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+	"github.com/gsoares85/goaegis/pkg/router"
+)
+
+// corsRouter returns a router using the CORS middleware with GET and POST routes only.
+func corsRouter(opts core.CORSOptions) *router.Router {
+	r := router.NewRouter()
+	r.Use(CORS(opts))
+	r.GET("/users", func(ctx core.Context) error {
+		return ctx.String(http.StatusOK, "users")
+	})
+	r.POST("/users", func(ctx core.Context) error {
+		return ctx.String(http.StatusCreated, "created")
+	})
+	return r
+}
+
+func serveCORS(r http.Handler, method, origin string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/users", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS_AllowOrigin(t *testing.T) {
+	restricted := core.CORSOptions{
+		AllowOrigins:        []string{"https://app.example.com", "https://*.example.org"},
+		AllowOriginPatterns: []*regexp.Regexp{regexp.MustCompile(`^https://pr-\d+\.preview\.dev$`)},
+		AllowOriginFunc:     func(origin string) bool { return origin == "https://partner.test" },
+	}
+
+	tests := []struct {
+		name   string
+		opts   core.CORSOptions
+		origin string
+		want   string
+		vary   string
+	}{
+		{"any origin", core.CORSOptions{}, "https://site.test", "*", ""},
+		{"exact", restricted, "https://app.example.com", "https://app.example.com", "Origin"},
+		{"exact case-insensitive", restricted, "HTTPS://APP.EXAMPLE.COM", "HTTPS://APP.EXAMPLE.COM", "Origin"},
+		{"wildcard subdomain", restricted, "https://api.eu.example.org", "https://api.eu.example.org", "Origin"},
+		{"wildcard apex", restricted, "https://example.org", "", "Origin"},
+		{"wildcard lookalike", restricted, "https://evilexample.org", "", "Origin"},
+		{"pattern", restricted, "https://pr-42.preview.dev", "https://pr-42.preview.dev", "Origin"},
+		{"func", restricted, "https://partner.test", "https://partner.test", "Origin"},
+		{"disallowed", restricted, "https://evil.test", "", "Origin"},
+		{"same origin request", restricted, "", "", "Origin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveCORS(corsRouter(tt.opts), "GET", tt.origin, nil)
+			if w.Code != http.StatusOK {
+				t.Errorf("GET /users status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.want)
+			}
+			if got := w.Header().Get("Vary"); got != tt.vary {
+				t.Errorf("Vary = %q, want %q", got, tt.vary)
+			}
+		})
+	}
+}
+
+func TestCORS_ActualRequestHeaders(t *testing.T) {
+	r := corsRouter(core.CORSOptions{
+		AllowOrigins:     []string{"https://app.example.com"},
+		ExposeHeaders:    []string{"X-Total-Count", "ETag"},
+		AllowCredentials: true,
+	})
+
+	w := serveCORS(r, "POST", "https://app.example.com", nil)
+	if w.Code != http.StatusCreated {
+		t.Errorf("POST /users status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, "true")
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "X-Total-Count, ETag" {
+		t.Errorf("Access-Control-Expose-Headers = %q, want %q", got, "X-Total-Count, ETag")
+	}
+
+	// Error responses keep the CORS headers so that clients can read them.
+	req := httptest.NewRequest("GET", "/missing", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("GET /missing = %d with origin %q, want 404 with the CORS headers", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	opts := core.CORSOptions{
+		AllowOrigins: []string{"https://app.example.com"},
+		AllowMethods: []string{"get", "post", "delete"},
+		AllowHeaders: []string{"content-type", "Authorization"},
+		MaxAge:       600,
+	}
+
+	tests := []struct {
+		name    string
+		origin  string
+		method  string
+		headers string
+		methods string
+		allowed string
+	}{
+		{"allowed", "https://app.example.com", "DELETE", "Content-Type, authorization", "GET, POST, DELETE", "Content-Type, authorization"},
+		{"no requested headers", "https://app.example.com", "POST", "", "GET, POST, DELETE", ""},
+		{"disallowed origin", "https://evil.test", "POST", "", "", ""},
+		{"disallowed method", "https://app.example.com", "PUT", "", "", ""},
+		{"disallowed header", "https://app.example.com", "POST", "X-Secret", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{"Access-Control-Request-Method": {tt.method}}
+			if tt.headers != "" {
+				header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			w := serveCORS(corsRouter(opts), "OPTIONS", tt.origin, header)
+
+			if w.Code != http.StatusNoContent {
+				t.Errorf("OPTIONS /users status = %d, want %d", w.Code, http.StatusNoContent)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Methods"); got != tt.methods {
+				t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, tt.methods)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Headers"); got != tt.allowed {
+				t.Errorf("Access-Control-Allow-Headers = %q, want %q", got, tt.allowed)
+			}
+			wantOrigin, wantMaxAge := "", ""
+			if tt.methods != "" {
+				wantOrigin, wantMaxAge = tt.origin, "600"
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Max-Age"); got != wantMaxAge {
+				t.Errorf("Access-Control-Max-Age = %q, want %q", got, wantMaxAge)
+			}
+			if got := strings.Join(w.Header().Values("Vary"), ", "); got != "Origin, Access-Control-Request-Method, Access-Control-Request-Headers" {
+				t.Errorf("Vary = %q, want Origin and the preflight request headers", got)
+			}
+		})
+	}
+}
+
+func TestNewCORS_Credentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    core.CORSOptions
+		wantErr bool
+	}{
+		{"any origin", core.CORSOptions{AllowCredentials: true}, true},
+		{"star", core.CORSOptions{AllowOrigins: []string{"*"}, AllowCredentials: true}, true},
+		{"star among origins", core.CORSOptions{AllowOrigins: []string{"https://app.example.com", "*"}, AllowCredentials: true}, true},
+		{"origins", core.CORSOptions{AllowOrigins: []string{"https://app.example.com"}, AllowCredentials: true}, false},
+		{"patterns", core.CORSOptions{AllowOriginPatterns: []*regexp.Regexp{regexp.MustCompile(`^https://app\.example\.com$`)}, AllowCredentials: true}, false},
+		{"func", core.CORSOptions{AllowOriginFunc: func(string) bool { return true }, AllowCredentials: true}, false},
+		{"star without credentials", core.CORSOptions{AllowOrigins: []string{"*"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCORS(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewCORS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("CORS() should panic when credentials are allowed from any origin")
+		}
+	}()
+	CORS(core.CORSOptions{AllowCredentials: true})
+}
+
+func TestCORS_PlainOptions(t *testing.T) {
+	// OPTIONS requests that are not preflight requests still reach the router.
+	w := serveCORS(corsRouter(core.CORSOptions{}), "OPTIONS", "https://app.example.com", nil)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("OPTIONS /users status = %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestAddVary(t *testing.T) {
+	header := http.Header{"Vary": {"Accept, origin"}}
+	addVary(header, "Origin", "Accept-Encoding", "accept-encoding")
+
+	if got := strings.Join(header.Values("Vary"), ", "); got != "Accept, origin, Accept-Encoding" {
+		t.Errorf("addVary() Vary = %q, want %q", got, "Accept, origin, Accept-Encoding")
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+	"github.com/gsoares85/goaegis/pkg/logger"
+)
+
+// RecoverOptions configures the Recover middleware.
+type RecoverOptions struct {
+	// Logger receives the panics; nil selects a console logger named "Recovery"
+	Logger core.Logger
+	// ExposeStack adds the panic value and stack trace to the details of the error
+	// response; it must only be enabled in development
+	ExposeStack bool
+}
+
+// Recover returns a middleware converting panics of the rest of the chain
+// into a 500 core.HTTPException whose cause is a *core.PanicError, so that
+// they are rendered by the exception filters instead of dropping the
+// connection. Each panic is logged at error level with its stack trace and
+// the request ID.
+//
+// When the response was already written, the panic is only logged. Panics
+// with http.ErrAbortHandler are propagated to abort the response.
+//
+// NewApplication installs Recover ahead of every other middleware, exposing
+// the stack when ConfigOptions.Environment is "development".
+func Recover(opts RecoverOptions) core.Middleware {
+	log := opts.Logger
+	if log == nil {
+		log = logger.New(logger.Options{Level: core.LogLevelInfo}).Named("Recovery")
+	}
+
+	return func(ctx core.Context, next core.HandlerFunc) (err error) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			if r == http.ErrAbortHandler {
+				panic(r)
+			}
+
+			// Panics re-raised by core.Timeout already carry the stack of the handler.
+			cause, ok := r.(*core.PanicError)
+			if !ok {
+				cause = &core.PanicError{Value: r, Stack: debug.Stack()}
+			}
+			core.RequestLogger(log, ctx.Context()).Error("panic recovered",
+				"panic", fmt.Sprint(cause.Value),
+				"method", ctx.Method(),
+				"path", ctx.Path(),
+				"stack", string(cause.Stack),
+			)
+			if ctx.IsWritten() {
+				err = nil
+				return
+			}
+
+			exception := core.Internal("Internal server error").WithCause(cause)
+			if opts.ExposeStack {
+				exception = exception.WithDetails(map[string]interface{}{
+					"panic": fmt.Sprint(cause.Value),
+					"stack": strings.Split(strings.TrimSpace(string(cause.Stack)), "\n"),
+				})
+			}
+			err = exception
+		}()
+		return next(ctx)
+	}
+}
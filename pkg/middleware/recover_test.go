@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+	"github.com/gsoares85/goaegis/pkg/logger"
+	"github.com/gsoares85/goaegis/pkg/router"
+)
+
+// errDisk is the error value of a panicking handler.
+var errDisk = errors.New("disk full")
+
+// recoverRouter returns a router recovering panics, logging to buf.
+func recoverRouter(buf *bytes.Buffer, exposeStack bool) *router.Router {
+	r := router.NewRouter()
+	r.Use(RequestID(RequestIDOptions{Generator: func() string { return "req-7" }}))
+	r.Use(Recover(RecoverOptions{
+		Logger:      logger.New(logger.Options{Output: buf, Format: logger.FormatJSON}),
+		ExposeStack: exposeStack,
+	}))
+	r.GET("/panic", func(ctx core.Context) error {
+		panic("nil map")
+	})
+	r.GET("/panic-error", func(ctx core.Context) error {
+		panic(errDisk)
+	})
+	r.GET("/written", func(ctx core.Context) error {
+		_ = ctx.String(http.StatusAccepted, "partial")
+		panic("late")
+	})
+	r.GET("/abort", func(ctx core.Context) error {
+		panic(http.ErrAbortHandler)
+	})
+	return r
+}
+
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		exposeStack bool
+		panic       string
+	}{
+		{"production", "/panic", false, "nil map"},
+		{"development", "/panic", true, "nil map"},
+		{"error value", "/panic-error", true, "disk full"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			r := recoverRouter(&buf, tt.exposeStack)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			var response core.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("response %q: %v", w.Body.String(), err)
+			}
+			if w.Code != http.StatusInternalServerError || response.Message != "Internal server error" || response.RequestID != "req-7" {
+				t.Errorf("response = %d %+v, want a 500 ErrorResponse for request req-7", w.Code, response)
+			}
+			details, _ := response.Details.(map[string]interface{})
+			if tt.exposeStack != (details != nil) {
+				t.Errorf("response details = %v, want the stack exposed %v", response.Details, tt.exposeStack)
+			}
+			if tt.exposeStack && (details["panic"] != tt.panic || !strings.Contains(strings.Join(toStrings(details["stack"]), "\n"), "recover_test.go")) {
+				t.Errorf("response details = %v, want panic %q and its stack", details, tt.panic)
+			}
+
+			var record map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("record %q: %v", buf.String(), err)
+			}
+			if record["level"] != "ERROR" || record["panic"] != tt.panic || record["request_id"] != "req-7" {
+				t.Errorf("record = %v, want an error with the panic and request ID", record)
+			}
+			if stack, _ := record["stack"].(string); !strings.Contains(stack, "recover_test.go") {
+				t.Errorf("record stack = %q, want the panicking frame", stack)
+			}
+		})
+	}
+}
+
+func toStrings(v interface{}) []string {
+	var s []string
+	values, _ := v.([]interface{})
+	for _, value := range values {
+		line, _ := value.(string)
+		s = append(s, line)
+	}
+	return s
+}
+
+func TestRecover_Cause(t *testing.T) {
+	mw := Recover(RecoverOptions{Logger: logger.New(logger.Options{Output: &bytes.Buffer{}})})
+	ctx := core.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	err := mw(ctx, func(core.Context) error { panic(errDisk) })
+	var panicErr *core.PanicError
+	if !errors.Is(err, core.ErrInternal) || !errors.As(err, &panicErr) || !errors.Is(err, errDisk) {
+		t.Errorf("Recover() error = %v, want an internal exception caused by the panic", err)
+	}
+}
+
+func TestRecover_Timeout(t *testing.T) {
+	var buf bytes.Buffer
+	r := recoverRouter(&buf, false)
+	r.HandleWithOptions("GET", "/slow", func(ctx core.Context) error {
+		panic("nil map")
+	}, core.RouteOptions{Timeout: time.Second})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("GET /slow status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("record %q is not JSON: %v", buf.String(), err)
+	}
+	stack, _ := record["stack"].(string)
+	if record["panic"] != "nil map" || !strings.Contains(stack, "TestRecover_Timeout.func1") {
+		t.Errorf("record = %v, want the panic and the stack of the handler", record)
+	}
+}
+
+func TestRecover_Written(t *testing.T) {
+	var buf bytes.Buffer
+	w := httptest.NewRecorder()
+	recoverRouter(&buf, true).ServeHTTP(w, httptest.NewRequest("GET", "/written", nil))
+
+	if w.Code != http.StatusAccepted || w.Body.String() != "partial" {
+		t.Errorf("response = %d %q, want the partial response only", w.Code, w.Body.String())
+	}
+	if !strings.Contains(buf.String(), `"panic":"late"`) {
+		t.Errorf("record = %s, want the panic logged", buf.String())
+	}
+}
+
+func TestRecover_Abort(t *testing.T) {
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("recover() = %v, want %v", r, http.ErrAbortHandler)
+		}
+	}()
+	recoverRouter(&bytes.Buffer{}, false).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+}
+
+func TestAccessLog_Panic(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(logger.Options{Output: &buf, Format: logger.FormatJSON})
+	r := router.NewRouter()
+	r.Use(Recover(RecoverOptions{Logger: log.Named("Recovery")}))
+	r.Use(AccessLog(AccessLogOptions{Logger: log.Named("HTTP")}))
+	r.GET("/panic", func(ctx core.Context) error {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("GET /panic status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if !strings.Contains(buf.String(), `"context":"HTTP","msg":"request completed","method":"GET","path":"/panic","status":500`) {
+		t.Errorf("access log = %s, want the panicking request with status 500", buf.String())
+	}
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+)
+
+// DefaultRequestIDMaxLength is the maximum length of accepted incoming request IDs.
+const DefaultRequestIDMaxLength = 128
+
+// RequestIDOptions configures the RequestID middleware.
+type RequestIDOptions struct {
+	// Header is the request and response header carrying the ID; empty selects
+	// DefaultRequestIDHeader
+	Header string
+	// MaxLength is the maximum length of accepted incoming IDs; zero selects
+	// DefaultRequestIDMaxLength
+	MaxLength int
+	// IgnoreIncoming always generates a new ID, for services exposed to untrusted clients
+	IgnoreIncoming bool
+	// Generator returns new IDs; nil selects NewRequestID
+	Generator func() string
+}
+
+// RequestID returns a middleware assigning an ID to every request. A valid
+// incoming ID is kept so that a request can be traced across services;
+// otherwise a new one is generated. Incoming IDs are valid when they are no
+// longer than MaxLength and only contain ASCII letters, digits and the
+// characters "-_.:+/=".
+//
+// The ID is stored with Context.SetRequestID, so that it is available to
+// handlers, to core.RequestLogger and in the requestId field of
+// core.ErrorResponse, and echoed in the response header.
+//
+// Example:
+//
+//	app.Use(middleware.RequestID(middleware.RequestIDOptions{}))
+//	app.Use(middleware.AccessLog(middleware.AccessLogOptions{Logger: log}))
+func RequestID(opts RequestIDOptions) core.Middleware {
+	header := opts.Header
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+	maxLength := opts.MaxLength
+	if maxLength <= 0 {
+		maxLength = DefaultRequestIDMaxLength
+	}
+	generate := opts.Generator
+	if generate == nil {
+		generate = NewRequestID
+	}
+
+	return func(ctx core.Context, next core.HandlerFunc) error {
+		id := ctx.GetHeader(header)
+		if opts.IgnoreIncoming || !validRequestID(id, maxLength) {
+			id = generate()
+		}
+		ctx.SetRequestID(id)
+		ctx.SetHeader(header, id)
+		return next(ctx)
+	}
+}
+
+// validRequestID reports whether an incoming ID is safe to log and echo.
+func validRequestID(id string, maxLength int) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '+', c == '/', c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewRequestID returns a random UUID version 7, such as
+// "01941f29-7c3a-7b5e-9d2f-3a6c1e0b4f81". Its 74 random bits make collisions
+// negligible, and its leading timestamp sorts IDs by creation time.
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[6:])
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(time.Now().UnixMilli()))
+	copy(b[:6], ms[2:])
+	b[6] = b[6]&0x0f | 0x70
+	b[8] = b[8]&0x3f | 0x80
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
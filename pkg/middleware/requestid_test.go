@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+	"github.com/gsoares85/goaegis/pkg/router"
+)
+
+// uuidV7 matches the IDs of NewRequestID.
+var uuidV7 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     RequestIDOptions
+		incoming string
+		want     string
+	}{
+		{"generated", RequestIDOptions{}, "", "uuid"},
+		{"incoming kept", RequestIDOptions{}, "edge-7f3a:42", "edge-7f3a:42"},
+		{"invalid charset", RequestIDOptions{}, "id with spaces", "uuid"},
+		{"log injection", RequestIDOptions{}, "abc\ninjected", "uuid"},
+		{"too long", RequestIDOptions{MaxLength: 8}, "123456789", "uuid"},
+		{"incoming ignored", RequestIDOptions{IgnoreIncoming: true}, "edge-1", "uuid"},
+		{"custom generator", RequestIDOptions{Generator: func() string { return "fixed" }}, "", "fixed"},
+		{"custom header", RequestIDOptions{Header: "X-Correlation-ID"}, "corr-1", "corr-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.opts.Header
+			if header == "" {
+				header = DefaultRequestIDHeader
+			}
+			var seen, fromContext string
+			r := router.NewRouter()
+			r.Use(RequestID(tt.opts))
+			r.GET("/", func(ctx core.Context) error {
+				seen, fromContext = ctx.RequestID(), core.RequestIDFromContext(ctx.Context())
+				return ctx.NoContent(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(header, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if tt.want == "uuid" && !uuidV7.MatchString(seen) || tt.want != "uuid" && seen != tt.want {
+				t.Errorf("RequestID() = %q, want %s", seen, tt.want)
+			}
+			if fromContext != seen || w.Header().Get(header) != seen {
+				t.Errorf("request ID in context %q, header %q, want %q", fromContext, w.Header().Get(header), seen)
+			}
+		})
+	}
+}
+
+func TestRequestID_ErrorResponseAndAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	r := accessLogRouter(&buf, AccessLogOptions{})
+	r.Use(RequestID(RequestIDOptions{Generator: func() string { return "req-42" }}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/users", nil))
+
+	var response core.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.RequestID != "req-42" {
+		t.Errorf("ErrorResponse = %s, want requestId %q", w.Body.String(), "req-42")
+	}
+	if !strings.Contains(buf.String(), `"request_id":"req-42"`) {
+		t.Errorf("access log = %s, want request_id %q", buf.String(), "req-42")
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	seen := make(map[string]bool)
+	previous := ""
+	for i := 0; i < 1000; i++ {
+		id := NewRequestID()
+		if !uuidV7.MatchString(id) {
+			t.Fatalf("NewRequestID() = %q, want a UUID version 7", id)
+		}
+		if seen[id] {
+			t.Fatalf("NewRequestID() returned %q twice", id)
+		}
+		if id[:8] < previous[:min(8, len(previous))] {
+			t.Errorf("NewRequestID() = %q sorts before %q", id, previous)
+		}
+		seen[id], previous = true, id
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// statusWriter wraps an http.ResponseWriter to record the status code and
+// the number of body bytes written.
+type statusWriter struct {
+	http.ResponseWriter
+
+	// status is the final status code, zero until the header is written
+	status int
+
+	// size is the number of body bytes written
+	size int64
+}
+
+// WriteHeader records the final status code and forwards it. Informational
+// 1xx responses are forwarded without being recorded.
+func (w *statusWriter) WriteHeader(statusCode int) {
+	if w.status == 0 && statusCode >= http.StatusOK {
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write writes body bytes, implying a 200 status if none was written.
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Hijack takes over the connection, as WebSocket upgrades do, recording a
+// 101 status.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil && w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status returns the status code of the response, 200 if nothing was written.
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		write  func(w http.ResponseWriter)
+		status int
+		size   int64
+	}{
+		{"nothing written", func(w http.ResponseWriter) {}, http.StatusOK, 0},
+		{"implicit status", func(w http.ResponseWriter) { _, _ = w.Write([]byte("hello")) }, http.StatusOK, 5},
+		{"explicit status", func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("{}"))
+			_, _ = w.Write([]byte("\n"))
+		}, http.StatusCreated, 3},
+		{"informational", func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusEarlyHints)
+			w.WriteHeader(http.StatusAccepted)
+		}, http.StatusAccepted, 0},
+		{"flush", func(w http.ResponseWriter) {
+			_, _ = w.Write([]byte("chunk"))
+			_ = http.NewResponseController(w).Flush()
+		}, http.StatusOK, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &statusWriter{ResponseWriter: httptest.NewRecorder()}
+			tt.write(writer)
+			if writer.Status() != tt.status || writer.size != tt.size {
+				t.Errorf("statusWriter = status %d, %d bytes, want %d, %d bytes", writer.Status(), writer.size, tt.status, tt.size)
+			}
+		})
+	}
+}
+
+func TestStatusWriter_Hijack(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writer := &statusWriter{ResponseWriter: w}
+		conn, _, err := writer.Hijack()
+		if err != nil || writer.Status() != http.StatusSwitchingProtocols {
+			t.Errorf("Hijack() error = %v, status %d, want %d", err, writer.Status(), http.StatusSwitchingProtocols)
+			return
+		}
+		_, _ = conn.Write([]byte("HTTP/1.1 204 No Content\r\n\r\n"))
+		conn.Close()
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	resp.Body.Close()
+
+	writer := &statusWriter{ResponseWriter: httptest.NewRecorder()}
+	if _, _, err := writer.Hijack(); err == nil || writer.status != 0 {
+		t.Errorf("Hijack() on a recorder error = %v, status %d, want an error", err, writer.status)
+	}
+}
@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultShards is the number of shards of a MemoryStore.
+const DefaultShards = 32
+
+// DefaultCleanupInterval is the minimum time between two evictions of the
+// expired keys of a MemoryStore shard.
+const DefaultCleanupInterval = time.Minute
+
+// MemoryStoreOptions configures a MemoryStore.
+type MemoryStoreOptions struct {
+	// Shards is the number of independently locked partitions of the keys;
+	// zero selects DefaultShards
+	Shards int
+	// CleanupInterval is the minimum time between two evictions of the expired
+	// keys of a shard; zero selects DefaultCleanupInterval
+	CleanupInterval time.Duration
+}
+
+// MemoryStore is a Store keeping the state of every key in memory. Keys are
+// spread over shards locked independently, and keys whose state went back
+// to a fresh one are evicted while taking requests from their shard, so the
+// store needs no background goroutine.
+//
+// A MemoryStore only limits the requests of one process; instances behind a
+// load balancer need a Store shared between them.
+type MemoryStore struct {
+	// shards hold the keys, partitioned by hash
+	shards []*shard
+
+	// cleanupInterval is the minimum time between two evictions of a shard
+	cleanupInterval time.Duration
+
+	// now returns the current time; replaced in tests
+	now func() time.Time
+}
+
+// shard is a partition of the keys of a MemoryStore.
+type shard struct {
+	// mu guards entries and nextCleanup
+	mu sync.Mutex
+
+	// entries holds the state of each key
+	entries map[string]*entry
+
+	// nextCleanup is the earliest time of the next eviction
+	nextCleanup time.Time
+}
+
+// entry is the state of a key and the time it expires.
+type entry struct {
+	state   State
+	expires time.Time
+}
+
+// Ensure MemoryStore implements Store.
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore(opts MemoryStoreOptions) *MemoryStore {
+	if opts.Shards <= 0 {
+		opts.Shards = DefaultShards
+	}
+	if opts.CleanupInterval <= 0 {
+		opts.CleanupInterval = DefaultCleanupInterval
+	}
+
+	s := &MemoryStore{
+		shards:          make([]*shard, opts.Shards),
+		cleanupInterval: opts.CleanupInterval,
+		now:             time.Now,
+	}
+	for i := range s.shards {
+		s.shards[i] = &shard{entries: make(map[string]*entry)}
+	}
+	return s
+}
+
+// Take records a request for key under limit and reports whether it is allowed.
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	now := s.now()
+	sh := s.shard(key)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if !now.Before(sh.nextCleanup) {
+		sh.evict(now)
+		sh.nextCleanup = now.Add(s.cleanupInterval)
+	}
+
+	e, ok := sh.entries[key]
+	if !ok || !now.Before(e.expires) {
+		e = &entry{}
+		sh.entries[key] = e
+	}
+	result := limit.Apply(&e.state, now)
+	e.expires = now.Add(limit.ttl(&e.state, now))
+	return result, nil
+}
+
+// Len returns the number of keys in the store, including expired keys not yet evicted.
+func (s *MemoryStore) Len() int {
+	n := 0
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		n += len(sh.entries)
+		sh.mu.Unlock()
+	}
+	return n
+}
+
+// shard returns the shard of key, selected by its FNV-1a hash.
+func (s *MemoryStore) shard(key string) *shard {
+	hash := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+	return s.shards[hash%uint32(len(s.shards))]
+}
+
+// evict removes the keys expired at now. The caller must hold sh.mu.
+func (sh *shard) evict(now time.Time) {
+	for key, e := range sh.entries {
+		if !now.Before(e.expires) {
+			delete(sh.entries, key)
+		}
+	}
+}
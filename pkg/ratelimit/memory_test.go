@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	store := NewMemoryStore(MemoryStoreOptions{})
+	limit := Limit{Requests: 2, Period: time.Minute}
+
+	tests := []struct {
+		key     string
+		allowed bool
+	}{
+		{"ip:203.0.113.9", true},
+		{"ip:203.0.113.9", true},
+		{"ip:203.0.113.9", false},
+		{"ip:198.51.100.7", true},
+	}
+
+	for i, test := range tests {
+		result, err := store.Take(context.Background(), test.key, limit)
+		if err != nil {
+			t.Fatalf("Take(%q) error = %v", test.key, err)
+		}
+		if result.Allowed != test.allowed {
+			t.Errorf("request %d: Take(%q).Allowed = %v, want %v", i, test.key, result.Allowed, test.allowed)
+		}
+	}
+	if store.Len() != 2 {
+		t.Errorf("Len() = %v, want %v", store.Len(), 2)
+	}
+}
+
+func TestMemoryStore_Evict(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := NewMemoryStore(MemoryStoreOptions{Shards: 1, CleanupInterval: time.Second})
+	store.now = func() time.Time { return now }
+	limit := Limit{Algorithm: SlidingWindow, Requests: 1, Period: time.Second}
+
+	for i := 0; i < 3; i++ {
+		_, _ = store.Take(context.Background(), fmt.Sprintf("key-%d", i), limit)
+	}
+	if result, _ := store.Take(context.Background(), "key-0", limit); result.Allowed {
+		t.Errorf("Take(key-0).Allowed = true, want false")
+	}
+
+	now = now.Add(3 * time.Second)
+	if result, _ := store.Take(context.Background(), "key-0", limit); !result.Allowed {
+		t.Errorf("Take(key-0) after expiry Allowed = false, want true")
+	}
+	if store.Len() != 1 {
+		t.Errorf("Len() = %v, want %v", store.Len(), 1)
+	}
+}
+
+func TestMemoryStore_Concurrent(t *testing.T) {
+	store := NewMemoryStore(MemoryStoreOptions{Shards: 4})
+	limit := Limit{Requests: 100, Period: time.Hour}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, _ := store.Take(context.Background(), "shared", limit)
+			if result.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 100 {
+		t.Errorf("allowed = %v, want %v", allowed, 100)
+	}
+}
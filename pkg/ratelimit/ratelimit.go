@@ -0,0 +1,163 @@
+// Package ratelimit provides a guard limiting the rate of requests per client.
+//
+// A Guard counts the requests of each key, by default the client IP, with a
+// token bucket or a sliding window, and denies requests over the limit with a
+// 429 core.HTTPException. Every response carries the RateLimit-Limit,
+// RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers, and
+// denied responses a Retry-After header:
+//
+//	limiter := ratelimit.New(ratelimit.Options{Requests: 100, Period: time.Minute})
+//	r.HandleWithOptions("POST", "/login", login, core.RouteOptions{
+//	    Guards: []core.Guard{limiter},
+//	})
+//
+// The counts are kept in a Store, an in-memory MemoryStore unless another
+// implementation, such as one backed by Redis, is shared between instances.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+)
+
+// KeyFunc returns the key whose requests are counted together. An empty key
+// falls back to the client IP.
+type KeyFunc func(ctx core.Context) string
+
+// ByClientIP returns a KeyFunc keying requests by core.Context.ClientIP.
+func ByClientIP() KeyFunc {
+	return func(ctx core.Context) string {
+		return "ip:" + ctx.ClientIP()
+	}
+}
+
+// ByHeader returns a KeyFunc keying requests by the value of a request
+// header, such as an API key. Requests without the header are keyed by
+// client IP.
+func ByHeader(name string) KeyFunc {
+	return func(ctx core.Context) string {
+		if value := ctx.GetHeader(name); value != "" {
+			return "header:" + name + ":" + value
+		}
+		return ""
+	}
+}
+
+// ByValue returns a KeyFunc keying requests by a context value, such as the
+// principal stored by an authentication guard. The value is formatted with
+// fmt.Sprint, so it should be an identifier or implement fmt.Stringer.
+// Requests without the value are keyed by client IP.
+func ByValue(key string) KeyFunc {
+	return func(ctx core.Context) string {
+		value := ctx.GetValue(key)
+		if value == nil {
+			return ""
+		}
+		if s := fmt.Sprint(value); s != "" {
+			return "value:" + key + ":" + s
+		}
+		return ""
+	}
+}
+
+// Options configures a Guard.
+type Options struct {
+	// Algorithm selects token bucket or sliding window counting
+	Algorithm Algorithm
+	// Requests is the number of requests allowed per Period; it must be positive
+	Requests int
+	// Period is the duration Requests applies to; it must be positive
+	Period time.Duration
+	// Burst is the capacity of the token bucket; zero selects Requests
+	Burst int
+	// Key selects the key of a request; nil selects ByClientIP
+	Key KeyFunc
+	// Store keeps the counts; nil selects a new MemoryStore
+	Store Store
+	// Prefix is prepended to the keys, so that guards sharing a Store count separately
+	Prefix string
+	// Message is the message of the 429 response; empty selects "Too many requests"
+	Message string
+	// DisableHeaders omits the RateLimit-* headers; Retry-After is always sent
+	DisableHeaders bool
+}
+
+// Guard is a core.Guard limiting the rate of requests per key.
+type Guard struct {
+	// opts are the options the guard was created with, with defaults applied
+	opts Options
+
+	// limit is the limit applied to every key
+	limit Limit
+
+	// policy is the value of the RateLimit-Policy header
+	policy string
+}
+
+// Ensure Guard implements core.Guard.
+var _ core.Guard = (*Guard)(nil)
+
+// New creates a Guard. It panics if Requests or Period is not positive.
+func New(opts Options) *Guard {
+	if opts.Requests <= 0 {
+		panic("ratelimit: Requests must be positive")
+	}
+	if opts.Period <= 0 {
+		panic("ratelimit: Period must be positive")
+	}
+	if opts.Key == nil {
+		opts.Key = ByClientIP()
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore(MemoryStoreOptions{})
+	}
+	if opts.Message == "" {
+		opts.Message = "Too many requests"
+	}
+
+	limit := Limit{Algorithm: opts.Algorithm, Requests: opts.Requests, Period: opts.Period, Burst: opts.Burst}
+	return &Guard{
+		opts:   opts,
+		limit:  limit,
+		policy: fmt.Sprintf("%d;w=%d", limit.Requests, seconds(limit.Period)),
+	}
+}
+
+// CanActivate takes a request from the limit of its key. Requests over the
+// limit are denied with a 429 exception, and errors of the Store are
+// returned as is.
+func (g *Guard) CanActivate(ctx core.Context) (bool, error) {
+	key := g.opts.Key(ctx)
+	if key == "" {
+		key = "ip:" + ctx.ClientIP()
+	}
+
+	result, err := g.opts.Store.Take(ctx.Context(), g.opts.Prefix+key, g.limit)
+	if err != nil {
+		return false, err
+	}
+
+	if !g.opts.DisableHeaders {
+		ctx.SetHeader("RateLimit-Limit", strconv.Itoa(result.Limit))
+		ctx.SetHeader("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.SetHeader("RateLimit-Reset", strconv.FormatInt(seconds(result.Reset), 10))
+		ctx.SetHeader("RateLimit-Policy", g.policy)
+	}
+	if !result.Allowed {
+		ctx.SetHeader("Retry-After", strconv.FormatInt(seconds(result.RetryAfter), 10))
+		return false, core.TooManyRequests("%s", g.opts.Message)
+	}
+	return true, nil
+}
+
+// seconds rounds d up to whole seconds, as header values are.
+func seconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+	"github.com/gsoares85/goaegis/pkg/router"
+)
+
+// limitedRouter serves GET /limited behind a guard created with opts.
+func limitedRouter(opts Options) *router.Router {
+	r := router.NewRouter()
+	r.HandleWithOptions("GET", "/limited", func(ctx core.Context) error {
+		return ctx.NoContent(http.StatusNoContent)
+	}, core.RouteOptions{Guards: []core.Guard{New(opts)}})
+	return r
+}
+
+func serveFrom(r http.Handler, remoteAddr string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/limited", nil)
+	req.RemoteAddr = remoteAddr
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGuard(t *testing.T) {
+	r := limitedRouter(Options{Requests: 2, Period: time.Minute})
+
+	tests := []struct {
+		remoteAddr string
+		code       int
+		remaining  string
+	}{
+		{"203.0.113.9:1234", http.StatusNoContent, "1"},
+		{"203.0.113.9:1234", http.StatusNoContent, "0"},
+		{"203.0.113.9:1234", http.StatusTooManyRequests, "0"},
+		{"198.51.100.7:1234", http.StatusNoContent, "1"},
+	}
+
+	for i, test := range tests {
+		w := serveFrom(r, test.remoteAddr, nil)
+		if w.Code != test.code {
+			t.Errorf("request %d from %s = %d, want %d", i, test.remoteAddr, w.Code, test.code)
+		}
+		if got := w.Header().Get("RateLimit-Remaining"); got != test.remaining {
+			t.Errorf("request %d: RateLimit-Remaining = %q, want %q", i, got, test.remaining)
+		}
+		if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d: RateLimit-Limit = %q, want %q", i, got, "2")
+		}
+		if got := w.Header().Get("RateLimit-Policy"); got != "2;w=60" {
+			t.Errorf("request %d: RateLimit-Policy = %q, want %q", i, got, "2;w=60")
+		}
+		if got := w.Header().Get("Retry-After"); (got != "") != (test.code == http.StatusTooManyRequests) {
+			t.Errorf("request %d: Retry-After = %q", i, got)
+		}
+	}
+}
+
+func TestGuard_TooManyRequests(t *testing.T) {
+	r := limitedRouter(Options{Algorithm: SlidingWindow, Requests: 1, Period: time.Minute, Message: "Slow down", DisableHeaders: true})
+	serveFrom(r, "203.0.113.9:1234", nil)
+	w := serveFrom(r, "203.0.113.9:1234", nil)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Code = %v, want %v", w.Code, http.StatusTooManyRequests)
+	}
+	if got := w.Body.String(); !strings.Contains(got, `"message":"Slow down"`) {
+		t.Errorf("Body = %s, want the message %q", got, "Slow down")
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Errorf("Retry-After is empty, want it set")
+	}
+	if got := w.Header().Get("RateLimit-Limit"); got != "" {
+		t.Errorf("RateLimit-Limit = %q, want it unset", got)
+	}
+}
+
+func TestKeyFuncs(t *testing.T) {
+	ctx := core.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/limited", nil))
+	ctx.Request().RemoteAddr = "203.0.113.9:1234"
+	ctx.Request().Header.Set("X-API-Key", "k1")
+	ctx.SetValue("user", 42)
+
+	tests := []struct {
+		name string
+		key  KeyFunc
+		want string
+	}{
+		{"client IP", ByClientIP(), "ip:203.0.113.9"},
+		{"header", ByHeader("X-API-Key"), "header:X-API-Key:k1"},
+		{"missing header", ByHeader("Authorization"), ""},
+		{"value", ByValue("user"), "value:user:42"},
+		{"missing value", ByValue("tenant"), ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.key(ctx); got != test.want {
+				t.Errorf("key() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGuard_KeyFallback(t *testing.T) {
+	r := limitedRouter(Options{Requests: 1, Period: time.Minute, Key: ByHeader("X-API-Key")})
+
+	tests := []struct {
+		remoteAddr string
+		apiKey     string
+		code       int
+	}{
+		{"203.0.113.9:1234", "k1", http.StatusNoContent},
+		{"198.51.100.7:1234", "k1", http.StatusTooManyRequests},
+		{"198.51.100.7:1234", "k2", http.StatusNoContent},
+		{"198.51.100.7:1234", "", http.StatusNoContent},
+		{"198.51.100.7:1234", "", http.StatusTooManyRequests},
+	}
+
+	for i, test := range tests {
+		header := http.Header{}
+		if test.apiKey != "" {
+			header.Set("X-API-Key", test.apiKey)
+		}
+		if w := serveFrom(r, test.remoteAddr, header); w.Code != test.code {
+			t.Errorf("request %d from %s with key %q = %d, want %d", i, test.remoteAddr, test.apiKey, w.Code, test.code)
+		}
+	}
+}
+
+// failingStore is a Store failing every request.
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit) (Result, error) {
+	return Result{}, errors.New("store unavailable")
+}
+
+func TestGuard_StoreError(t *testing.T) {
+	r := limitedRouter(Options{Requests: 1, Period: time.Minute, Store: failingStore{}})
+	if w := serveFrom(r, "203.0.113.9:1234", nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("Code = %v, want %v", w.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"no requests", Options{Period: time.Minute}},
+		{"no period", Options{Requests: 10}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("New() did not panic")
+				}
+			}()
+			New(test.opts)
+		})
+	}
+}
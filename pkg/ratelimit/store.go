@@ -0,0 +1,183 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Algorithm selects how a Limit counts requests.
+type Algorithm int
+
+const (
+	// TokenBucket refills Requests tokens per Period into a bucket holding up
+	// to Burst tokens; each request takes one. It allows short bursts while
+	// enforcing the average rate.
+	TokenBucket Algorithm = iota
+	// SlidingWindow allows Requests per Period over a window sliding with the
+	// request time. The window count weighs the previous fixed window by its
+	// overlap with the sliding one, which bounds bursts at window boundaries
+	// with two counters per key.
+	SlidingWindow
+)
+
+// String returns the name of the algorithm.
+func (a Algorithm) String() string {
+	switch a {
+	case TokenBucket:
+		return "token-bucket"
+	case SlidingWindow:
+		return "sliding-window"
+	default:
+		return "unknown"
+	}
+}
+
+// Limit describes the requests allowed for a key.
+type Limit struct {
+	// Algorithm selects token bucket or sliding window counting
+	Algorithm Algorithm
+	// Requests is the number of requests allowed per Period
+	Requests int
+	// Period is the duration Requests applies to
+	Period time.Duration
+	// Burst is the capacity of the token bucket; zero selects Requests
+	Burst int
+}
+
+// Result is the outcome of taking a request from a Limit.
+type Result struct {
+	// Allowed reports whether the request is within the limit
+	Allowed bool
+	// Limit is the number of requests available when the quota is full
+	Limit int
+	// Remaining is the number of requests still available
+	Remaining int
+	// Reset is the time until the quota is replenished, sent as RateLimit-Reset
+	Reset time.Duration
+	// RetryAfter is the time until a denied request would be allowed; zero when allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the state of the limits of every key. Implementations must be
+// safe for concurrent use and apply Take atomically per key; stores backed by
+// an external service, such as Redis, typically run the algorithm on the
+// server for that reason.
+type Store interface {
+	// Take records a request for key under limit and reports whether it is allowed.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// State is the counting state of a key. Stores keeping states themselves can
+// update them with Limit.Apply.
+type State struct {
+	// Tokens is the number of tokens left in the bucket at Last
+	Tokens float64
+	// Last is the time of the last token bucket update
+	Last time.Time
+
+	// Window is the start of the current sliding window period
+	Window time.Time
+	// Current is the number of requests counted in the current period
+	Current int
+	// Previous is the number of requests counted in the period before Window
+	Previous int
+}
+
+// Apply records a request made at now in s and returns its outcome. A zero
+// State is a key without previous requests.
+func (l Limit) Apply(s *State, now time.Time) Result {
+	if l.Algorithm == SlidingWindow {
+		return l.applySlidingWindow(s, now)
+	}
+	return l.applyTokenBucket(s, now)
+}
+
+// capacity returns the size of the token bucket.
+func (l Limit) capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// applyTokenBucket refills the bucket for the time elapsed since the last
+// request, then takes a token if one is left.
+func (l Limit) applyTokenBucket(s *State, now time.Time) Result {
+	capacity := float64(l.capacity())
+	perToken := float64(l.Period) / float64(l.Requests)
+
+	tokens := capacity
+	if !s.Last.IsZero() {
+		elapsed := float64(now.Sub(s.Last))
+		tokens = math.Min(capacity, s.Tokens+math.Max(0, elapsed)/perToken)
+	}
+
+	result := Result{Limit: l.capacity()}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - tokens) * perToken))
+	}
+	s.Tokens, s.Last = tokens, now
+
+	result.Remaining = int(tokens)
+	result.Reset = time.Duration(math.Ceil((capacity - tokens) * perToken))
+	return result
+}
+
+// applySlidingWindow counts the request in the current period if the
+// weighted count of the sliding window leaves room for it.
+func (l Limit) applySlidingWindow(s *State, now time.Time) Result {
+	start := now.Truncate(l.Period)
+	switch {
+	case s.Window.IsZero() || start.Sub(s.Window) >= 2*l.Period:
+		s.Window, s.Previous, s.Current = start, 0, 0
+	case start.After(s.Window):
+		s.Window, s.Previous, s.Current = start, s.Current, 0
+	}
+
+	elapsed := now.Sub(s.Window)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	limit := float64(l.Requests)
+	count := float64(s.Previous)*(1-float64(elapsed)/float64(l.Period)) + float64(s.Current)
+
+	result := Result{Limit: l.Requests, Reset: l.Period - elapsed}
+	if count+1 <= limit {
+		s.Current++
+		count++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.slidingRetryAfter(s, elapsed)
+	}
+	result.Remaining = int(math.Max(0, limit-math.Ceil(count)))
+	return result
+}
+
+// slidingRetryAfter returns the time until the weighted count of the sliding
+// window leaves room for one more request.
+func (l Limit) slidingRetryAfter(s *State, elapsed time.Duration) time.Duration {
+	limit, period := float64(l.Requests), float64(l.Period)
+	current, previous := float64(s.Current), float64(s.Previous)
+
+	// The previous period decays enough before the current one ends.
+	if current+1 <= limit && previous > 0 {
+		wait := period*(1-(limit-current-1)/previous) - float64(elapsed)
+		return time.Duration(math.Ceil(math.Max(wait, 1)))
+	}
+	// The current period becomes the previous one and has to decay.
+	decay := math.Max(0, 1-(limit-1)/current)
+	return l.Period - elapsed + time.Duration(math.Ceil(period*decay))
+}
+
+// ttl returns how long s remains different from a zero State after a request at now.
+func (l Limit) ttl(s *State, now time.Time) time.Duration {
+	if l.Algorithm == SlidingWindow {
+		return s.Window.Add(2 * l.Period).Sub(now)
+	}
+	perToken := float64(l.Period) / float64(l.Requests)
+	return time.Duration(math.Ceil((float64(l.capacity()) - s.Tokens) * perToken))
+}
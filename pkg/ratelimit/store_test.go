@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlgorithm_String(t *testing.T) {
+	tests := []struct {
+		algorithm Algorithm
+		want      string
+	}{
+		{TokenBucket, "token-bucket"},
+		{SlidingWindow, "sliding-window"},
+		{Algorithm(9), "unknown"},
+	}
+
+	for _, test := range tests {
+		if got := test.algorithm.String(); got != test.want {
+			t.Errorf("String() = %v, want %v", got, test.want)
+		}
+	}
+}
+
+// step is a request made at an offset from the start of a test, and its expected outcome.
+type step struct {
+	at         time.Duration
+	allowed    bool
+	remaining  int
+	retryAfter time.Duration
+}
+
+func TestLimit_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit Limit
+		steps []step
+	}{
+		{
+			name:  "token bucket",
+			limit: Limit{Algorithm: TokenBucket, Requests: 2, Period: time.Second},
+			steps: []step{
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{0, false, 0, 500 * time.Millisecond},
+				{250 * time.Millisecond, false, 0, 250 * time.Millisecond},
+				{500 * time.Millisecond, true, 0, 0},
+				{2 * time.Second, true, 1, 0},
+			},
+		},
+		{
+			name:  "token bucket burst",
+			limit: Limit{Algorithm: TokenBucket, Requests: 1, Period: time.Second, Burst: 3},
+			steps: []step{
+				{0, true, 2, 0},
+				{0, true, 1, 0},
+				{0, true, 0, 0},
+				{0, false, 0, time.Second},
+				{time.Second, true, 0, 0},
+			},
+		},
+		{
+			name:  "sliding window",
+			limit: Limit{Algorithm: SlidingWindow, Requests: 2, Period: time.Second},
+			steps: []step{
+				{0, true, 1, 0},
+				{100 * time.Millisecond, true, 0, 0},
+				{200 * time.Millisecond, false, 0, 1300 * time.Millisecond},
+				// 2 requests weighted by 0.5 leave room for one more.
+				{1500 * time.Millisecond, true, 0, 0},
+				{1600 * time.Millisecond, false, 0, 400 * time.Millisecond},
+				{2000 * time.Millisecond, true, 0, 0},
+				{4000 * time.Millisecond, true, 1, 0},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start := time.Unix(1700000000, 0)
+			var state State
+			for i, s := range test.steps {
+				result := test.limit.Apply(&state, start.Add(s.at))
+				if result.Allowed != s.allowed {
+					t.Errorf("step %d: Allowed = %v, want %v", i, result.Allowed, s.allowed)
+				}
+				if result.Remaining != s.remaining {
+					t.Errorf("step %d: Remaining = %v, want %v", i, result.Remaining, s.remaining)
+				}
+				if result.RetryAfter != s.retryAfter {
+					t.Errorf("step %d: RetryAfter = %v, want %v", i, result.RetryAfter, s.retryAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestLimit_ApplyReset(t *testing.T) {
+	now := time.Unix(1700000000, 0).Add(250 * time.Millisecond)
+
+	var state State
+	bucket := Limit{Algorithm: TokenBucket, Requests: 4, Period: time.Second}
+	if result := bucket.Apply(&state, now); result.Reset != 250*time.Millisecond || result.Limit != 4 {
+		t.Errorf("token bucket Reset, Limit = %v, %v, want %v, %v", result.Reset, result.Limit, 250*time.Millisecond, 4)
+	}
+
+	state = State{}
+	window := Limit{Algorithm: SlidingWindow, Requests: 4, Period: time.Second}
+	if result := window.Apply(&state, now); result.Reset != 750*time.Millisecond || result.Limit != 4 {
+		t.Errorf("sliding window Reset, Limit = %v, %v, want %v, %v", result.Reset, result.Limit, 750*time.Millisecond, 4)
+	}
+}
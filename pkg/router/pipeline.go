@@ -0,0 +1,70 @@
+package router
+
+import (
+	"reflect"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+)
+
+// pipeline wraps handler with the request pipeline stages that run after
+// middleware: guards are evaluated in order and the handler only runs if all
+// of them allow the request. A denying guard results in a 403 exception and
+// guard errors are returned as is, so both reach the exception filters.
+//
+// The handler then runs inside the interceptors, outermost first, right after
+// the route pipes resolved their bound values. The result the interceptors
+// return is serialized unless a response was already written.
+func pipeline(handler core.HandlerFunc, guards []core.Guard, interceptors []core.Interceptor, pipes []core.Pipe) core.HandlerFunc {
+	return func(ctx core.Context) error {
+		for _, guard := range guards {
+			allowed, err := guard.CanActivate(ctx)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				return core.Forbidden("Forbidden resource")
+			}
+		}
+
+		result, err := intercept(ctx, handler, interceptors, pipes)
+		if err != nil {
+			return err
+		}
+		if isNil(result) || ctx.IsWritten() {
+			return nil
+		}
+		return core.WriteResult(ctx, result)
+	}
+}
+
+// isNil reports whether result is nil or a nil pointer, such as the zero
+// value returned by a core.Typed handler of *T. Those results are treated as
+// no result instead of being serialized as null. Nil maps and slices are
+// results, such as an empty query result, and are serialized.
+func isNil(result interface{}) bool {
+	if result == nil {
+		return true
+	}
+	v := reflect.ValueOf(result)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// intercept runs pipes and handler wrapped by interceptors and returns the final result.
+func intercept(ctx core.Context, handler core.HandlerFunc, interceptors []core.Interceptor, pipes []core.Pipe) (interface{}, error) {
+	call := core.CallHandler(func() (interface{}, error) {
+		if err := core.ApplyPipes(ctx, pipes); err != nil {
+			return nil, err
+		}
+		if err := handler(ctx); err != nil {
+			return nil, err
+		}
+		return core.Result(ctx), nil
+	})
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], call
+		call = func() (interface{}, error) {
+			return interceptor.Intercept(ctx, next)
+		}
+	}
+	return call()
+}
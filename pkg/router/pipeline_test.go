@@ -0,0 +1,553 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+)
+
+func recordingGuard(name string, allow bool, order *[]string) core.Guard {
+	return core.GuardFunc(func(ctx core.Context) (bool, error) {
+		*order = append(*order, name)
+		return allow, nil
+	})
+}
+
+func TestPipeline_GuardOrder(t *testing.T) {
+	var order []string
+	r := NewRouter()
+	r.UseGuards(recordingGuard("root", true, &order))
+
+	r.Mount(core.ControllerMetadata{
+		Prefix: "/admin",
+		Guards: []core.Guard{recordingGuard("controller", true, &order)},
+		Routes: []core.RouteMetadata{{
+			Method: core.MethodGET,
+			Path:   "/stats",
+			Guards: []core.Guard{recordingGuard("route", true, &order)},
+			Handler: func(ctx core.Context) error {
+				order = append(order, "handler")
+				return ctx.NoContent(http.StatusNoContent)
+			},
+		}},
+	})
+
+	w := serve(r, "GET", "/admin/stats")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Status code = %d, want 204", w.Code)
+	}
+
+	expected := []string{"root", "controller", "route", "handler"}
+	if len(order) != len(expected) {
+		t.Fatalf("Execution order = %v, want %v", order, expected)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("Execution order[%d] = %v, want %v", i, order[i], expected[i])
+		}
+	}
+}
+
+func TestPipeline_GuardDenies(t *testing.T) {
+	var order []string
+	r := NewRouter()
+	api := r.Group("/api")
+	api.UseGuards(recordingGuard("controller", false, &order))
+	api.HandleWithOptions("GET", "/secret", func(ctx core.Context) error {
+		order = append(order, "handler")
+		return nil
+	}, core.RouteOptions{Guards: []core.Guard{recordingGuard("route", true, &order)}})
+
+	w := serve(r, "GET", "/api/secret")
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Status code = %d, want 403", w.Code)
+	}
+
+	var body core.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if body.StatusCode != http.StatusForbidden || body.Path != "/api/secret" || body.Timestamp == "" {
+		t.Errorf("ErrorResponse = %+v, want 403 for /api/secret with a timestamp", body)
+	}
+
+	if len(order) != 1 || order[0] != "controller" {
+		t.Errorf("Execution order = %v, want only the denying guard", order)
+	}
+}
+
+func TestPipeline_GuardError(t *testing.T) {
+	r := NewRouter()
+	handlerCalled := false
+	r.HandleWithOptions("GET", "/", func(ctx core.Context) error {
+		handlerCalled = true
+		return nil
+	}, core.RouteOptions{Guards: []core.Guard{core.GuardFunc(func(ctx core.Context) (bool, error) {
+		return false, errors.New("token store unavailable")
+	})}})
+
+	if w := serve(r, "GET", "/"); w.Code != http.StatusInternalServerError {
+		t.Errorf("Status code = %d, want 500", w.Code)
+	}
+	if handlerCalled {
+		t.Error("handler should not run when a guard fails")
+	}
+}
+
+func TestPipeline_GuardPrincipal(t *testing.T) {
+	r := NewRouter()
+	auth := core.GuardFunc(func(ctx core.Context) (bool, error) {
+		if ctx.GetHeader("Authorization") != "Bearer alice" {
+			return false, nil
+		}
+		ctx.SetValue(core.PrincipalKey, "alice")
+		return true, nil
+	})
+	r.HandleWithOptions("GET", "/me", func(ctx core.Context) error {
+		return ctx.String(http.StatusOK, "%v", ctx.GetValue(core.PrincipalKey))
+	}, core.RouteOptions{Guards: []core.Guard{auth}})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/me", nil)
+	req.Header.Set("Authorization", "Bearer alice")
+	r.ServeHTTP(w, req)
+	if w.Body.String() != "alice" {
+		t.Errorf("Response body = %q, want %q", w.Body.String(), "alice")
+	}
+
+	if w := serve(r, "GET", "/me"); w.Code != http.StatusForbidden {
+		t.Errorf("Status code without credentials = %d, want 403", w.Code)
+	}
+}
+
+func TestPipeline_GuardsSkipUnmatchedRoutes(t *testing.T) {
+	var order []string
+	r := NewRouter()
+	r.UseGuards(recordingGuard("root", false, &order))
+
+	if w := serve(r, "GET", "/missing"); w.Code != http.StatusNotFound {
+		t.Errorf("Status code = %d, want 404", w.Code)
+	}
+	if len(order) != 0 {
+		t.Errorf("guards should not run for unmatched routes, ran %v", order)
+	}
+}
+
+func TestPipeline_RouteMiddleware(t *testing.T) {
+	r := NewRouter()
+	r.HandleWithOptions("GET", "/", func(ctx core.Context) error {
+		return ctx.NoContent(http.StatusNoContent)
+	}, core.RouteOptions{Middleware: []core.Middleware{func(ctx core.Context, next core.HandlerFunc) error {
+		ctx.SetHeader("X-Route", "yes")
+		return next(ctx)
+	}}})
+
+	if w := serve(r, "GET", "/"); w.Header().Get("X-Route") != "yes" {
+		t.Error("route middleware should run")
+	}
+}
+
+func recordingFilter(name string, handle bool, order *[]string) core.Filter {
+	return core.FilterFunc(func(err error, ctx core.Context) error {
+		*order = append(*order, name)
+		if !handle {
+			return err
+		}
+		return ctx.String(http.StatusTeapot, name)
+	})
+}
+
+func TestPipeline_FilterOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		handleAt string
+		expected []string
+	}{
+		{"route handles", "route", []string{"route"}},
+		{"controller handles", "controller", []string{"route", "controller"}},
+		{"global handles", "global", []string{"route", "controller", "global"}},
+		{"default filter", "", []string{"route", "controller", "global"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var order []string
+			r := NewRouter()
+			r.UseFilters(recordingFilter("global", test.handleAt == "global", &order))
+			r.Mount(core.ControllerMetadata{
+				Prefix:  "/users",
+				Filters: []core.Filter{recordingFilter("controller", test.handleAt == "controller", &order)},
+				Routes: []core.RouteMetadata{{
+					Method:  core.MethodGET,
+					Path:    "/",
+					Filters: []core.Filter{recordingFilter("route", test.handleAt == "route", &order)},
+					Handler: func(ctx core.Context) error {
+						return errors.New("boom")
+					},
+				}},
+			})
+
+			w := serve(r, "GET", "/users")
+			if test.handleAt == "" {
+				if w.Code != http.StatusInternalServerError {
+					t.Errorf("Status code = %d, want 500", w.Code)
+				}
+			} else if w.Code != http.StatusTeapot || w.Body.String() != test.handleAt {
+				t.Errorf("response = %d %q, want 418 %q", w.Code, w.Body.String(), test.handleAt)
+			}
+
+			if len(order) != len(test.expected) {
+				t.Fatalf("Filter order = %v, want %v", order, test.expected)
+			}
+			for i := range test.expected {
+				if order[i] != test.expected[i] {
+					t.Errorf("Filter order[%d] = %v, want %v", i, order[i], test.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPipeline_FilterReplacesError(t *testing.T) {
+	r := NewRouter()
+	r.UseFilters(core.FilterFunc(func(err error, ctx core.Context) error {
+		return core.Conflict("translated: %v", err)
+	}))
+	r.GET("/", func(ctx core.Context) error {
+		return errors.New("duplicate key")
+	})
+
+	w := serve(r, "GET", "/")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Status code = %d, want 409", w.Code)
+	}
+
+	var body core.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if body.Message != "translated: duplicate key" {
+		t.Errorf("Message = %v, want %v", body.Message, "translated: duplicate key")
+	}
+}
+
+func TestPipeline_DefaultFilter(t *testing.T) {
+	r := NewRouter()
+	r.GET("/validate", func(ctx core.Context) error {
+		return core.ValidationErrors{{Field: "email", Message: "email is required"}}
+	})
+	r.GET("/exception", func(ctx core.Context) error {
+		return core.NotFound("user %d not found", 7)
+	})
+	r.GET("/internal", func(ctx core.Context) error {
+		return errors.New("connection refused")
+	})
+
+	tests := []struct {
+		path       string
+		statusCode int
+		message    string
+		errors     int
+	}{
+		{"/validate", http.StatusBadRequest, "Validation failed", 1},
+		{"/exception", http.StatusNotFound, "user 7 not found", 0},
+		{"/internal", http.StatusInternalServerError, "Internal server error", 0},
+		{"/missing", http.StatusNotFound, "Cannot GET /missing", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			w := serve(r, "GET", test.path)
+			if w.Code != test.statusCode {
+				t.Errorf("Status code = %d, want %d", w.Code, test.statusCode)
+			}
+
+			var body core.ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if body.StatusCode != test.statusCode || body.Message != test.message {
+				t.Errorf("ErrorResponse = %+v, want %d %q", body, test.statusCode, test.message)
+			}
+			if body.Path != test.path || body.Timestamp == "" {
+				t.Errorf("ErrorResponse = %+v, want path %s with a timestamp", body, test.path)
+			}
+			if len(body.Errors) != test.errors {
+				t.Errorf("len(Errors) = %d, want %d", len(body.Errors), test.errors)
+			}
+		})
+	}
+}
+
+func TestPipeline_Negotiation(t *testing.T) {
+	type user struct {
+		ID   int    `json:"id" xml:"id"`
+		Name string `json:"name" xml:"name"`
+	}
+	r := NewRouter()
+	r.GET("/users/1", core.Typed(func(ctx core.Context) (user, error) {
+		return user{ID: 1, Name: "alice"}, nil
+	}))
+
+	tests := []struct {
+		name        string
+		path        string
+		accept      string
+		statusCode  int
+		contentType string
+		body        string
+	}{
+		{"result as YAML", "/users/1", "application/yaml", http.StatusOK, "application/yaml", "id: 1\nname: alice"},
+		{"result not acceptable", "/users/1", "image/png", http.StatusNotAcceptable, "application/json", `"code":"NOT_ACCEPTABLE"`},
+		{"error as XML", "/missing", "application/xml, application/json;q=0.5", http.StatusNotFound, "application/xml", "<message>Cannot GET /missing</message>"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", test.path, nil)
+			req.Header.Set("Accept", test.accept)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != test.statusCode {
+				t.Errorf("Status code = %d, want %d", w.Code, test.statusCode)
+			}
+			if got := w.Header().Get("Content-Type"); got != test.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, test.contentType)
+			}
+			if !strings.Contains(w.Body.String(), test.body) {
+				t.Errorf("Body = %q, want it to contain %q", w.Body.String(), test.body)
+			}
+		})
+	}
+}
+
+func TestPipeline_GlobalFilterCatchesNotFound(t *testing.T) {
+	var order []string
+	r := NewRouter()
+	r.UseFilters(recordingFilter("global", true, &order))
+	r.GET("/users", func(ctx core.Context) error { return nil })
+
+	if w := serve(r, "GET", "/missing"); w.Code != http.StatusTeapot {
+		t.Errorf("Status code for 404 = %d, want 418", w.Code)
+	}
+	if w := serve(r, "POST", "/users"); w.Code != http.StatusTeapot {
+		t.Errorf("Status code for 405 = %d, want 418", w.Code)
+	}
+	if len(order) != 2 {
+		t.Errorf("global filter calls = %v, want 2", order)
+	}
+}
+
+func recordingInterceptor(name string, order *[]string) core.Interceptor {
+	return core.InterceptorFunc(func(ctx core.Context, next core.CallHandler) (interface{}, error) {
+		*order = append(*order, name+":before")
+		result, err := next()
+		*order = append(*order, name+":after")
+		return result, err
+	})
+}
+
+func TestPipeline_InterceptorOrder(t *testing.T) {
+	var order []string
+	r := NewRouter()
+	r.UseInterceptors(recordingInterceptor("global", &order))
+	r.Mount(core.ControllerMetadata{
+		Prefix:       "/users",
+		Interceptors: []core.Interceptor{recordingInterceptor("controller", &order)},
+		Routes: []core.RouteMetadata{{
+			Method:       core.MethodGET,
+			Path:         "/",
+			Interceptors: []core.Interceptor{recordingInterceptor("route", &order)},
+			Handler: core.Typed(func(ctx core.Context) ([]string, error) {
+				order = append(order, "handler")
+				return []string{"alice"}, nil
+			}),
+		}},
+	})
+
+	w := serve(r, "GET", "/users")
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `["alice"]` {
+		t.Errorf("response = %d %q, want 200 %q", w.Code, w.Body.String(), `["alice"]`)
+	}
+
+	expected := []string{
+		"global:before", "controller:before", "route:before", "handler",
+		"route:after", "controller:after", "global:after",
+	}
+	if len(order) != len(expected) {
+		t.Fatalf("Execution order = %v, want %v", order, expected)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("Execution order[%d] = %v, want %v", i, order[i], expected[i])
+		}
+	}
+}
+
+func TestPipeline_InterceptorTransform(t *testing.T) {
+	wrap := core.InterceptorFunc(func(ctx core.Context, next core.CallHandler) (interface{}, error) {
+		result, err := next()
+		if err != nil {
+			return nil, err
+		}
+		return core.SuccessResponse{StatusCode: http.StatusCreated, Message: "created", Data: result}, nil
+	})
+
+	r := NewRouter()
+	r.HandleWithOptions("POST", "/users", core.Typed(func(ctx core.Context) (map[string]int, error) {
+		return map[string]int{"id": 7}, nil
+	}), core.RouteOptions{Interceptors: []core.Interceptor{wrap}})
+
+	w := serve(r, "POST", "/users")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Status code = %d, want 201", w.Code)
+	}
+
+	var body struct {
+		Message string         `json:"message"`
+		Data    map[string]int `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if body.Message != "created" || body.Data["id"] != 7 {
+		t.Errorf("response = %+v, want the wrapped handler result", body)
+	}
+}
+
+func TestPipeline_InterceptorShortCircuit(t *testing.T) {
+	handlerCalled := false
+	cache := core.InterceptorFunc(func(ctx core.Context, next core.CallHandler) (interface{}, error) {
+		return "cached", nil
+	})
+
+	r := NewRouter()
+	r.HandleWithOptions("GET", "/", core.Typed(func(ctx core.Context) (string, error) {
+		handlerCalled = true
+		return "fresh", nil
+	}), core.RouteOptions{Interceptors: []core.Interceptor{cache}})
+
+	if w := serve(r, "GET", "/"); strings.TrimSpace(w.Body.String()) != `"cached"` {
+		t.Errorf("Response body = %q, want %q", w.Body.String(), `"cached"`)
+	}
+	if handlerCalled {
+		t.Error("handler should not run when an interceptor short-circuits")
+	}
+}
+
+func TestPipeline_InterceptorErrors(t *testing.T) {
+	fallback := core.InterceptorFunc(func(ctx core.Context, next core.CallHandler) (interface{}, error) {
+		result, err := next()
+		if errors.Is(err, core.ErrNotFound) {
+			return []string{}, nil
+		}
+		return result, err
+	})
+
+	r := NewRouter()
+	r.UseInterceptors(fallback)
+	r.GET("/empty", core.Typed(func(ctx core.Context) ([]string, error) {
+		return nil, core.NotFound("no users")
+	}))
+	r.GET("/fail", core.Typed(func(ctx core.Context) ([]string, error) {
+		return nil, core.Conflict("locked")
+	}))
+
+	if w := serve(r, "GET", "/empty"); w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("response = %d %q, want 200 %q", w.Code, w.Body.String(), "[]")
+	}
+	if w := serve(r, "GET", "/fail"); w.Code != http.StatusConflict {
+		t.Errorf("Status code = %d, want 409", w.Code)
+	}
+}
+
+func TestPipeline_NilResult(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	r := NewRouter()
+	r.GET("/pointer", core.Typed(func(ctx core.Context) (*user, error) {
+		return nil, nil
+	}))
+	r.GET("/map", core.Typed(func(ctx core.Context) (map[string]int, error) {
+		return nil, nil
+	}))
+	r.GET("/slice", core.Typed(func(ctx core.Context) ([]string, error) {
+		return nil, nil
+	}))
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/pointer", ""},
+		{"/map", "null"},
+		{"/slice", "null"},
+	}
+
+	for _, tt := range tests {
+		w := serve(r, "GET", tt.path)
+		if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != tt.body {
+			t.Errorf("GET %s = %d %q, want 200 with body %q", tt.path, w.Code, w.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestPipeline_InterceptorWrittenResponse(t *testing.T) {
+	var seen []interface{}
+	observe := core.InterceptorFunc(func(ctx core.Context, next core.CallHandler) (interface{}, error) {
+		result, err := next()
+		seen = append(seen, result)
+		return result, err
+	})
+
+	r := NewRouter()
+	r.UseInterceptors(observe)
+	r.GET("/", func(ctx core.Context) error {
+		return ctx.String(http.StatusOK, "plain")
+	})
+
+	if w := serve(r, "GET", "/"); w.Body.String() != "plain" {
+		t.Errorf("Response body = %q, want %q", w.Body.String(), "plain")
+	}
+	if len(seen) != 1 || seen[0] != nil {
+		t.Errorf("interceptor results = %v, want a single nil result", seen)
+	}
+}
+
+func TestPipeline_Pipes(t *testing.T) {
+	r := NewRouter()
+	r.HandleWithOptions("GET", "/users/:id", func(ctx core.Context) error {
+		return ctx.String(http.StatusOK, "%d/%d", core.ParamAs[int](ctx, "id"), core.QueryAs[int](ctx, "page"))
+	}, core.RouteOptions{Pipes: []core.Pipe{
+		core.Param("id", core.ParseInt()),
+		core.Query("page", core.DefaultValue("1"), core.ParseInt()),
+	}})
+
+	if w := serve(r, "GET", "/users/42?page=3"); w.Body.String() != "42/3" {
+		t.Errorf("Response body = %q, want %q", w.Body.String(), "42/3")
+	}
+	if w := serve(r, "GET", "/users/42"); w.Body.String() != "42/1" {
+		t.Errorf("Response body = %q, want %q", w.Body.String(), "42/1")
+	}
+
+	w := serve(r, "GET", "/users/abc?page=x")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Status code = %d, want 400", w.Code)
+	}
+
+	var body core.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(body.Errors) != 2 || body.Errors[0].Field != "id" || body.Errors[1].Field != "page" {
+		t.Errorf("Errors = %+v, want failures for id and page", body.Errors)
+	}
+}
@@ -0,0 +1,568 @@
+// Package router provides the radix-tree implementation of core.Router.
+//
+// Routes are matched segment by segment: static segments win over ":name"
+// parameters, which win over "*name" catch-alls. Requests whose path matches a
+// route registered for a different method receive a 405 response with an
+// Allow header; all other unmatched requests receive a 404.
+//
+// Errors returned by the handler chain are passed to the exception filters of
+// the route, then of its enclosing groups from the innermost out, and finally
+// to core.DefaultFilter, which renders them as a core.ErrorResponse.
+//
+//	r := router.NewRouter()
+//	r.GET("/users/:id", getUser)
+//	r.GET("/static/*filepath", serveStatic)
+//
+//	api := r.Group("/api", authMiddleware)
+//	api.POST("/users", createUser)
+//
+//	http.ListenAndServe(":3000", r)
+package router
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+)
+
+// maxParams is the number of path parameters captured without allocating.
+const maxParams = 8
+
+// Router is the default implementation of core.Router.
+//
+// The Router returned by NewRouter is the root of a route tree; Group returns
+// child Routers that share the same tree and add a path prefix and middleware
+// of their own. Middleware is resolved lazily when a route is first served, so
+// Use affects every route of the router or group it is called on, regardless
+// of registration order.
+type Router struct {
+	// engine holds the state shared by the root router and all of its groups
+	engine *engine
+
+	// parent is the enclosing group, nil for the root router
+	parent *Router
+
+	// prefix is the full path prefix of this group
+	prefix string
+
+	// middleware is applied to every route registered on this group or its children
+	middleware []core.Middleware
+
+	// guards are evaluated before every route of this group or its children
+	guards []core.Guard
+
+	// filters handle the errors of every route of this group or its children
+	filters []core.Filter
+
+	// interceptors wrap the handler of every route of this group or its children
+	interceptors []core.Interceptor
+}
+
+// engine is the state shared by a root Router and its groups.
+type engine struct {
+	// mu protects the tree, the registered methods and compiled handler chains
+	mu sync.RWMutex
+
+	// tree is the root of the radix tree
+	tree *node
+
+	// methods lists every HTTP method with at least one registered route
+	methods []string
+
+	// generation is bumped whenever group middleware, guards, filters or interceptors change, invalidating compiled chains
+	generation uint64
+
+	// notFound is the fallback route for unmatched paths
+	notFound *route
+
+	// methodNotAllowed is the fallback route for paths matched by other methods
+	methodNotAllowed *route
+
+	// body holds the default request body options, overridden per route
+	body core.BodyOptions
+
+	// json is the codec applied to every request; nil keeps the context default
+	json core.JSONCodec
+
+	// proxies lists the proxies whose forwarding headers are trusted
+	proxies core.TrustedProxies
+
+	// proxyHeaders selects the forwarding headers read from trusted proxies
+	proxyHeaders core.ProxyHeaders
+
+	// logger is the application logger set on every request; nil leaves it unset
+	logger core.Logger
+
+	// pool recycles AppContext instances between requests
+	pool sync.Pool
+}
+
+// route is a handler registered on the tree together with the group that owns it.
+type route struct {
+	// metadata describes the route as registered
+	metadata core.RouteMetadata
+
+	// group is the router group the route was registered on
+	group *Router
+
+	// fallback marks the 404 and 405 routes, which only run root middleware
+	fallback bool
+
+	// handlers is the compiled middleware and handler chain
+	handlers []core.HandlerFunc
+
+	// filters is the compiled list of exception filters, innermost first
+	filters []core.Filter
+
+	// generation is the engine generation handlers was compiled for
+	generation uint64
+}
+
+// Ensure Router implements core.Router.
+var _ core.Router = (*Router)(nil)
+
+// NewRouter creates an empty root Router.
+func NewRouter() *Router {
+	e := &engine{
+		tree:       &node{},
+		generation: 1,
+	}
+	e.pool.New = func() interface{} {
+		return core.NewContext(nil, nil)
+	}
+
+	r := &Router{engine: e}
+	e.notFound = &route{
+		metadata: core.RouteMetadata{Handler: notFoundHandler},
+		group:    r,
+		fallback: true,
+	}
+	e.methodNotAllowed = &route{
+		metadata: core.RouteMetadata{Handler: methodNotAllowedHandler},
+		group:    r,
+		fallback: true,
+	}
+	return r
+}
+
+// GET registers a route for HTTP GET requests.
+func (r *Router) GET(path string, handler core.HandlerFunc) core.Router {
+	return r.Handle(http.MethodGet, path, handler)
+}
+
+// POST registers a route for HTTP POST requests.
+func (r *Router) POST(path string, handler core.HandlerFunc) core.Router {
+	return r.Handle(http.MethodPost, path, handler)
+}
+
+// PUT registers a route for HTTP PUT requests.
+func (r *Router) PUT(path string, handler core.HandlerFunc) core.Router {
+	return r.Handle(http.MethodPut, path, handler)
+}
+
+// DELETE registers a route for HTTP DELETE requests.
+func (r *Router) DELETE(path string, handler core.HandlerFunc) core.Router {
+	return r.Handle(http.MethodDelete, path, handler)
+}
+
+// PATCH registers a route for HTTP PATCH requests.
+func (r *Router) PATCH(path string, handler core.HandlerFunc) core.Router {
+	return r.Handle(http.MethodPatch, path, handler)
+}
+
+// OPTIONS registers a route for HTTP OPTIONS requests.
+func (r *Router) OPTIONS(path string, handler core.HandlerFunc) core.Router {
+	return r.Handle(http.MethodOptions, path, handler)
+}
+
+// HEAD registers a route for HTTP HEAD requests.
+func (r *Router) HEAD(path string, handler core.HandlerFunc) core.Router {
+	return r.Handle(http.MethodHead, path, handler)
+}
+
+// Handle registers a route for the given HTTP method.
+// The path is relative to the group prefix and may contain ":name" parameters
+// and a trailing "*name" catch-all. Handle panics on malformed or conflicting
+// routes.
+//
+// Example:
+//
+//	r.Handle("PROPFIND", "/files/*path", handler)
+func (r *Router) Handle(method, path string, handler core.HandlerFunc) core.Router {
+	return r.Route(core.RouteMetadata{
+		Method:  core.HTTPMethod(method),
+		Path:    path,
+		Handler: handler,
+	})
+}
+
+// HandleWithOptions registers a route for the given HTTP method together with
+// route-specific middleware, guards, pipes, filters, interceptors and body options.
+//
+// Example:
+//
+//	r.HandleWithOptions("DELETE", "/users/:id", deleteUser, core.RouteOptions{
+//	    Guards: []core.Guard{adminGuard},
+//	})
+func (r *Router) HandleWithOptions(method, path string, handler core.HandlerFunc, opts core.RouteOptions) core.Router {
+	return r.Route(core.RouteMetadata{
+		Method:       core.HTTPMethod(method),
+		Path:         path,
+		Handler:      handler,
+		Middleware:   opts.Middleware,
+		Guards:       opts.Guards,
+		Pipes:        opts.Pipes,
+		Filters:      opts.Filters,
+		Interceptors: opts.Interceptors,
+		MaxBodyBytes: opts.MaxBodyBytes,
+		StrictJSON:   opts.StrictJSON,
+		Timeout:      opts.Timeout,
+	})
+}
+
+// Route registers a route described by its metadata.
+// The metadata path is relative to the group prefix.
+func (r *Router) Route(metadata core.RouteMetadata) core.Router {
+	if metadata.Method == "" {
+		panic("router: HTTP method must not be empty")
+	}
+	if metadata.Handler == nil {
+		panic("router: handler must not be nil")
+	}
+
+	metadata.Method = core.HTTPMethod(strings.ToUpper(metadata.Method.String()))
+	metadata.Path = joinPaths(r.prefix, metadata.Path)
+	r.addRoute(&route{
+		metadata: metadata,
+		group:    r,
+	})
+	return r
+}
+
+// Mount registers the routes of a controller on a group scoped to the
+// controller prefix, carrying the controller middleware, guards, filters and
+// interceptors.
+// It returns the controller's group so that more routes can be added to it.
+//
+// Example:
+//
+//	r.Mount(core.ControllerMetadata{
+//	    Prefix: "/admin",
+//	    Guards: []core.Guard{adminGuard},
+//	    Routes: []core.RouteMetadata{
+//	        {Method: core.MethodGET, Path: "/stats", Handler: stats},
+//	    },
+//	})
+func (r *Router) Mount(controller core.ControllerMetadata) core.Router {
+	group := r.Group(controller.Prefix, controller.Middleware...)
+	group.UseGuards(controller.Guards...)
+	group.UseFilters(controller.Filters...)
+	group.UseInterceptors(controller.Interceptors...)
+	for _, rt := range controller.Routes {
+		group.Route(rt)
+	}
+	return group
+}
+
+// Group creates a route group with a common prefix and optional middleware.
+// Routes registered on the group inherit the middleware of every enclosing group.
+//
+// Example:
+//
+//	v1 := r.Group("/v1", authMiddleware)
+//	v1.GET("/users", listUsers) // GET /v1/users
+func (r *Router) Group(prefix string, middleware ...core.Middleware) core.Router {
+	return &Router{
+		engine:     r.engine,
+		parent:     r,
+		prefix:     joinPaths(r.prefix, prefix),
+		middleware: append([]core.Middleware(nil), middleware...),
+	}
+}
+
+// Use adds middleware to the router.
+// On the root router the middleware applies to every request, including
+// requests that end in a 404 or 405 response.
+func (r *Router) Use(middleware ...core.Middleware) core.Router {
+	r.engine.mu.Lock()
+	defer r.engine.mu.Unlock()
+
+	r.middleware = append(r.middleware, middleware...)
+	r.engine.generation++
+	return r
+}
+
+// UseGuards adds guards evaluated before every route of the router.
+// Guards run after middleware, from the root group down to the route's own
+// guards; the first guard denying access ends the request with a 403 response.
+func (r *Router) UseGuards(guards ...core.Guard) core.Router {
+	r.engine.mu.Lock()
+	defer r.engine.mu.Unlock()
+
+	r.guards = append(r.guards, guards...)
+	r.engine.generation++
+	return r
+}
+
+// UseFilters adds exception filters for the errors of every route of the router.
+// Route filters are consulted first, then the filters of each enclosing group
+// from the innermost out; filters added to the root router are global and also
+// handle 404 and 405 errors.
+func (r *Router) UseFilters(filters ...core.Filter) core.Router {
+	r.engine.mu.Lock()
+	defer r.engine.mu.Unlock()
+
+	r.filters = append(r.filters, filters...)
+	r.engine.generation++
+	return r
+}
+
+// UseInterceptors adds interceptors wrapping the handler of every route of the
+// router. Interceptors of the root group are outermost, followed by those of
+// each nested group and finally the route's own interceptors.
+func (r *Router) UseInterceptors(interceptors ...core.Interceptor) core.Router {
+	r.engine.mu.Lock()
+	defer r.engine.mu.Unlock()
+
+	r.interceptors = append(r.interceptors, interceptors...)
+	r.engine.generation++
+	return r
+}
+
+// SetBodyOptions sets the default body options of every route. Routes
+// override the size limit with a non-zero MaxBodyBytes and enable strict JSON
+// decoding with StrictJSON.
+func (r *Router) SetBodyOptions(opts core.BodyOptions) {
+	r.engine.mu.Lock()
+	defer r.engine.mu.Unlock()
+	r.engine.body = opts
+}
+
+// SetJSONCodec sets the codec encoding JSON responses and decoding JSON
+// request bodies of every route.
+func (r *Router) SetJSONCodec(codec core.JSONCodec) {
+	r.engine.mu.Lock()
+	defer r.engine.mu.Unlock()
+	r.engine.json = codec
+}
+
+// SetTrustedProxies sets the proxies whose forwarding headers are honored
+// by Context.ClientIP, Scheme and Host.
+func (r *Router) SetTrustedProxies(proxies core.TrustedProxies) {
+	r.engine.mu.Lock()
+	defer r.engine.mu.Unlock()
+	r.engine.proxies = proxies
+}
+
+// SetProxyHeaders selects the forwarding headers read from trusted proxies
+// by Context.ClientIP, Scheme and Host.
+func (r *Router) SetProxyHeaders(headers core.ProxyHeaders) {
+	r.engine.mu.Lock()
+	defer r.engine.mu.Unlock()
+	r.engine.proxyHeaders = headers
+}
+
+// SetLogger sets the application logger returned by core.LoggerFrom for
+// every request, which middleware such as the access log default to.
+func (r *Router) SetLogger(log core.Logger) {
+	r.engine.mu.Lock()
+	defer r.engine.mu.Unlock()
+	r.engine.logger = log
+}
+
+// ServeHTTP implements the http.Handler interface.
+// It acquires a pooled AppContext, matches the request against the tree and
+// runs the resulting handler chain.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	e := r.engine
+
+	ctx := e.pool.Get().(*core.AppContext)
+	ctx.Reset(w, req)
+	defer e.pool.Put(ctx)
+
+	path := req.URL.Path
+	var buf [maxParams]param
+
+	e.mu.RLock()
+	rt, params := e.tree.lookup(req.Method, path, buf[:0])
+	var allowed []string
+	if rt == nil {
+		allowed = e.allowedMethods(path)
+	}
+	body, codec, proxies, proxyHeaders, log := e.body, e.json, e.proxies, e.proxyHeaders, e.logger
+	e.mu.RUnlock()
+
+	if codec != nil {
+		ctx.SetJSONCodec(codec)
+	}
+	if len(proxies) > 0 {
+		ctx.SetTrustedProxies(proxies)
+		ctx.SetProxyHeaders(proxyHeaders)
+	}
+	if log != nil {
+		ctx.SetLogger(log)
+	}
+
+	switch {
+	case rt != nil:
+		for _, p := range params {
+			ctx.SetParam(p.name, p.value)
+		}
+		if rt.metadata.MaxBodyBytes != 0 {
+			body.MaxBytes = rt.metadata.MaxBodyBytes
+		}
+		body.StrictJSON = body.StrictJSON || rt.metadata.StrictJSON
+		if body != (core.BodyOptions{}) {
+			ctx.SetBodyOptions(body)
+		}
+	case len(allowed) > 0:
+		ctx.SetHeader("Allow", strings.Join(allowed, ", "))
+		rt = e.methodNotAllowed
+	default:
+		rt = e.notFound
+	}
+
+	handlers, filters := e.compiled(rt)
+	ctx.SetHandlers(handlers)
+	ctx.SetFilters(filters)
+	if err := ctx.Next(); err != nil {
+		ctx.HandleError(err)
+	}
+}
+
+// addRoute inserts rt into the tree.
+func (r *Router) addRoute(rt *route) {
+	e := r.engine
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	method := rt.metadata.Method.String()
+	e.tree.insert(method, rt.metadata.Path, rt)
+
+	for _, m := range e.methods {
+		if m == method {
+			return
+		}
+	}
+	e.methods = append(e.methods, method)
+	sort.Strings(e.methods)
+}
+
+// allowedMethods returns the methods that have a route matching path.
+// The caller must hold e.mu.
+func (e *engine) allowedMethods(path string) []string {
+	var allowed []string
+	var buf [maxParams]param
+	for _, method := range e.methods {
+		if rt, _ := e.tree.lookup(method, path, buf[:0]); rt != nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// compiled returns the handler chain and exception filters of rt, compiling
+// them when the group configuration changed since the last compilation.
+func (e *engine) compiled(rt *route) ([]core.HandlerFunc, []core.Filter) {
+	e.mu.RLock()
+	if rt.generation == e.generation {
+		handlers, filters := rt.handlers, rt.filters
+		e.mu.RUnlock()
+		return handlers, filters
+	}
+	e.mu.RUnlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if rt.generation != e.generation {
+		rt.handlers, rt.filters = compile(rt)
+		rt.generation = e.generation
+	}
+	return rt.handlers, rt.filters
+}
+
+// compile builds the handler chain of rt: the middleware of every enclosing
+// group from the root down, then the route's timeout, then the route's own
+// middleware, then the handler wrapped in the route pipeline. Fallback
+// routes skip the pipeline.
+// It also returns the exception filters of rt, from the route's own outwards.
+func compile(rt *route) ([]core.HandlerFunc, []core.Filter) {
+	var groups []*Router
+	filters := append([]core.Filter(nil), rt.metadata.Filters...)
+	for g := rt.group; g != nil; g = g.parent {
+		groups = append(groups, g)
+		filters = append(filters, g.filters...)
+	}
+
+	var handlers []core.HandlerFunc
+	var guards []core.Guard
+	var interceptors []core.Interceptor
+	for i := len(groups) - 1; i >= 0; i-- {
+		for _, mw := range groups[i].middleware {
+			handlers = append(handlers, adaptMiddleware(mw))
+		}
+		guards = append(guards, groups[i].guards...)
+		interceptors = append(interceptors, groups[i].interceptors...)
+	}
+	if rt.metadata.Timeout > 0 {
+		handlers = append(handlers, adaptMiddleware(core.Timeout(core.TimeoutOptions{Timeout: rt.metadata.Timeout})))
+	}
+	for _, mw := range rt.metadata.Middleware {
+		handlers = append(handlers, adaptMiddleware(mw))
+	}
+	guards = append(guards, rt.metadata.Guards...)
+	interceptors = append(interceptors, rt.metadata.Interceptors...)
+
+	if rt.fallback {
+		return append(handlers, rt.metadata.Handler), filters
+	}
+	return append(handlers, pipeline(rt.metadata.Handler, guards, interceptors, rt.metadata.Pipes)), filters
+}
+
+// adaptMiddleware turns a core.Middleware into a link of the context handler chain.
+func adaptMiddleware(mw core.Middleware) core.HandlerFunc {
+	return func(ctx core.Context) error {
+		return mw(ctx, next)
+	}
+}
+
+// next advances ctx to the following link of its handler chain.
+func next(ctx core.Context) error {
+	return ctx.Next()
+}
+
+// notFoundHandler responds to requests that match no route.
+func notFoundHandler(ctx core.Context) error {
+	return core.NotFound("Cannot %s %s", ctx.Method(), ctx.Path())
+}
+
+// methodNotAllowedHandler responds to requests whose path matches a route
+// registered for other methods only.
+func methodNotAllowedHandler(ctx core.Context) error {
+	return core.MethodNotAllowed("Cannot %s %s", ctx.Method(), ctx.Path())
+}
+
+// joinPaths appends path to prefix, normalizing the slash between them.
+// A path of "/" registered on a group maps to the group prefix itself.
+func joinPaths(prefix, path string) string {
+	if path != "" && path[0] != '/' {
+		path = "/" + path
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix != "" && prefix[0] != '/' {
+		prefix = "/" + prefix
+	}
+
+	switch {
+	case path == "" || path == "/":
+		if prefix == "" {
+			return "/"
+		}
+		return prefix
+	default:
+		return prefix + path
+	}
+}
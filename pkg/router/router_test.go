@@ -0,0 +1,315 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gsoares85/goaegis/pkg/core"
+)
+
+func serve(r http.Handler, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestRouter_Methods(t *testing.T) {
+	r := NewRouter()
+	handler := func(ctx core.Context) error {
+		return ctx.String(http.StatusOK, "%s", ctx.Method())
+	}
+
+	r.GET("/res", handler)
+	r.POST("/res", handler)
+	r.PUT("/res", handler)
+	r.DELETE("/res", handler)
+	r.PATCH("/res", handler)
+	r.OPTIONS("/res", handler)
+	r.HEAD("/res", handler)
+	r.Handle("propfind", "/res", handler)
+
+	methods := []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS", "PROPFIND"}
+	for _, method := range methods {
+		t.Run(method, func(t *testing.T) {
+			w := serve(r, method, "/res")
+			if w.Code != http.StatusOK {
+				t.Fatalf("Status code = %d, want 200", w.Code)
+			}
+			if w.Body.String() != method {
+				t.Errorf("Response body = %q, want %q", w.Body.String(), method)
+			}
+		})
+	}
+
+	if w := serve(r, "HEAD", "/res"); w.Code != http.StatusOK {
+		t.Errorf("HEAD status code = %d, want 200", w.Code)
+	}
+}
+
+func TestRouter_Params(t *testing.T) {
+	r := NewRouter()
+	r.GET("/users/:id/files/*path", func(ctx core.Context) error {
+		return ctx.String(http.StatusOK, "%s:%s", ctx.Param("id"), ctx.Param("path"))
+	})
+
+	w := serve(r, "GET", "/users/42/files/docs/readme.md")
+	if w.Body.String() != "42:docs/readme.md" {
+		t.Errorf("Response body = %q, want %q", w.Body.String(), "42:docs/readme.md")
+	}
+
+	// Params must not leak into the next request served by a pooled context.
+	r.GET("/about", func(ctx core.Context) error {
+		return ctx.String(http.StatusOK, "[%s]", ctx.Param("id"))
+	})
+	if w := serve(r, "GET", "/about"); w.Body.String() != "[]" {
+		t.Errorf("Response body = %q, want %q", w.Body.String(), "[]")
+	}
+}
+
+func TestRouter_NotFound(t *testing.T) {
+	r := NewRouter()
+	r.GET("/users", func(ctx core.Context) error { return ctx.NoContent(http.StatusNoContent) })
+
+	w := serve(r, "GET", "/missing")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Status code = %d, want 404", w.Code)
+	}
+	if w.Header().Get("Allow") != "" {
+		t.Errorf("Allow header = %q, want empty", w.Header().Get("Allow"))
+	}
+}
+
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	r := NewRouter()
+	noop := func(ctx core.Context) error { return ctx.NoContent(http.StatusNoContent) }
+	r.GET("/users/:id", noop)
+	r.DELETE("/users/:id", noop)
+	r.POST("/users", noop)
+
+	w := serve(r, "PUT", "/users/1")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("Status code = %d, want 405", w.Code)
+	}
+	if got := w.Header().Get("Allow"); got != "DELETE, GET" {
+		t.Errorf("Allow header = %q, want %q", got, "DELETE, GET")
+	}
+}
+
+func TestRouter_Group(t *testing.T) {
+	r := NewRouter()
+	var order []string
+	tag := func(name string) core.Middleware {
+		return func(ctx core.Context, next core.HandlerFunc) error {
+			order = append(order, name)
+			return next(ctx)
+		}
+	}
+
+	r.Use(tag("root"))
+	api := r.Group("/api", tag("api"))
+	users := api.Group("users", tag("users"))
+	users.GET("/", func(ctx core.Context) error {
+		order = append(order, "handler")
+		return ctx.String(http.StatusOK, "list")
+	})
+	users.GET("/:id", func(ctx core.Context) error {
+		return ctx.String(http.StatusOK, "%s", ctx.Param("id"))
+	})
+
+	w := serve(r, "GET", "/api/users")
+	if w.Code != http.StatusOK || w.Body.String() != "list" {
+		t.Fatalf("GET /api/users = %d %q, want 200 %q", w.Code, w.Body.String(), "list")
+	}
+
+	expected := []string{"root", "api", "users", "handler"}
+	if len(order) != len(expected) {
+		t.Fatalf("Execution order = %v, want %v", order, expected)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("Execution order[%d] = %v, want %v", i, order[i], expected[i])
+		}
+	}
+
+	if w := serve(r, "GET", "/api/users/7"); w.Body.String() != "7" {
+		t.Errorf("Response body = %q, want %q", w.Body.String(), "7")
+	}
+}
+
+func TestRouter_UseAfterRegistration(t *testing.T) {
+	r := NewRouter()
+	r.GET("/ping", func(ctx core.Context) error { return ctx.String(http.StatusOK, "pong") })
+
+	r.Use(func(ctx core.Context, next core.HandlerFunc) error {
+		ctx.SetHeader("X-Global", "yes")
+		return next(ctx)
+	})
+
+	if w := serve(r, "GET", "/ping"); w.Header().Get("X-Global") != "yes" {
+		t.Error("middleware added after registration should apply to existing routes")
+	}
+	if w := serve(r, "GET", "/missing"); w.Header().Get("X-Global") != "yes" {
+		t.Error("root middleware should run for unmatched requests")
+	}
+}
+
+func TestRouter_MiddlewareShortCircuit(t *testing.T) {
+	r := NewRouter()
+	called := false
+	r.Use(func(ctx core.Context, next core.HandlerFunc) error {
+		return ctx.String(http.StatusUnauthorized, "denied")
+	})
+	r.GET("/secret", func(ctx core.Context) error {
+		called = true
+		return nil
+	})
+
+	w := serve(r, "GET", "/secret")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Status code = %d, want 401", w.Code)
+	}
+	if called {
+		t.Error("handler should not run when middleware does not call next")
+	}
+}
+
+func TestRouter_HandlerError(t *testing.T) {
+	r := NewRouter()
+	r.GET("/fail", func(ctx core.Context) error { return errors.New("boom") })
+
+	if w := serve(r, "GET", "/fail"); w.Code != http.StatusInternalServerError {
+		t.Errorf("Status code = %d, want 500", w.Code)
+	}
+}
+
+func TestJoinPaths(t *testing.T) {
+	tests := []struct {
+		prefix, path, want string
+	}{
+		{"", "/users", "/users"},
+		{"", "", "/"},
+		{"/api", "/", "/api"},
+		{"/api/", "/users", "/api/users"},
+		{"api", "users", "/api/users"},
+		{"/api", ":id", "/api/:id"},
+	}
+
+	for _, tt := range tests {
+		if got := joinPaths(tt.prefix, tt.path); got != tt.want {
+			t.Errorf("joinPaths(%q, %q) = %q, want %q", tt.prefix, tt.path, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkRouter_ServeHTTP(b *testing.B) {
+	r := NewRouter()
+	r.GET("/users/:id/posts/:postId", func(ctx core.Context) error {
+		return ctx.NoContent(http.StatusNoContent)
+	})
+	req := httptest.NewRequest("GET", "/users/42/posts/7", nil)
+	w := httptest.NewRecorder()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.ServeHTTP(w, req)
+	}
+}
+
+func TestRouter_BodyOptions(t *testing.T) {
+	r := NewRouter()
+	r.SetBodyOptions(core.BodyOptions{MaxBytes: 16})
+
+	bind := func(ctx core.Context) error {
+		var body map[string]interface{}
+		if err := ctx.Body(&body); err != nil {
+			return err
+		}
+		return ctx.NoContent(http.StatusNoContent)
+	}
+	r.POST("/default", bind)
+	r.HandleWithOptions("POST", "/large", bind, core.RouteOptions{MaxBodyBytes: 1024})
+	r.HandleWithOptions("POST", "/unlimited", bind, core.RouteOptions{MaxBodyBytes: -1})
+	r.HandleWithOptions("POST", "/strict", bind, core.RouteOptions{StrictJSON: true})
+
+	large := `{"name":"` + strings.Repeat("a", 64) + `"}`
+	tests := []struct {
+		path string
+		body string
+		code int
+	}{
+		{"/default", `{"a":1}`, http.StatusNoContent},
+		{"/default", large, http.StatusRequestEntityTooLarge},
+		{"/large", large, http.StatusNoContent},
+		{"/unlimited", large, http.StatusNoContent},
+		{"/strict", `{"a":1} {}`, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", test.path, strings.NewReader(test.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != test.code {
+			t.Errorf("POST %s with %d bytes = %d, want %d", test.path, len(test.body), w.Code, test.code)
+		}
+	}
+}
+
+func TestRouter_SetJSONCodec(t *testing.T) {
+	r := NewRouter()
+	r.SetJSONCodec(core.StdJSONCodec{Indent: "\t"})
+	r.GET("/user", func(ctx core.Context) error {
+		return ctx.JSON(http.StatusOK, map[string]int{"id": 1})
+	})
+
+	if w := serve(r, "GET", "/user"); w.Body.String() != "{\n\t\"id\": 1\n}\n" {
+		t.Errorf("GET /user body = %q, want it indented with tabs", w.Body.String())
+	}
+	if w := serve(r, "GET", "/missing"); !strings.HasPrefix(w.Body.String(), "{\n\t\"statusCode\": 404") {
+		t.Errorf("GET /missing body = %q, want it indented with tabs", w.Body.String())
+	}
+}
+
+func TestRouter_SetTrustedProxies(t *testing.T) {
+	r := NewRouter()
+	r.SetTrustedProxies(core.MustParseTrustedProxies("10.0.0.0/8"))
+	r.GET("/ip", func(ctx core.Context) error {
+		return ctx.String(http.StatusOK, "%s %s", ctx.ClientIP(), ctx.Scheme())
+	})
+
+	req := httptest.NewRequest("GET", "/ip", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Body.String() != "203.0.113.9 https" {
+		t.Errorf("GET /ip body = %q, want %q", w.Body.String(), "203.0.113.9 https")
+	}
+}
+
+func TestRouter_RouteTimeout(t *testing.T) {
+	r := NewRouter()
+	slow := func(ctx core.Context) error {
+		<-ctx.Context().Done()
+		return ctx.String(http.StatusOK, "late")
+	}
+	r.HandleWithOptions("GET", "/slow", slow, core.RouteOptions{Timeout: 10 * time.Millisecond})
+	r.GET("/deadline", func(ctx core.Context) error {
+		if _, ok := ctx.Context().Deadline(); ok {
+			return ctx.String(http.StatusOK, "deadline")
+		}
+		return ctx.String(http.StatusOK, "none")
+	})
+
+	if w := serve(r, "GET", "/slow"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /slow = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if w := serve(r, "GET", "/deadline"); w.Body.String() != "none" {
+		t.Errorf("GET /deadline body = %q, want %q", w.Body.String(), "none")
+	}
+}
@@ -0,0 +1,223 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+)
+
+// param is a single path parameter captured while walking the tree.
+type param struct {
+	name  string
+	value string
+}
+
+// node is a node of the radix tree used to match request paths.
+//
+// Static path fragments are compressed into prefix, so "/users" and "/uploads"
+// share a "/u" parent. Parameter segments (":id") and catch-all segments
+// ("*filepath") are kept in dedicated children because they match by
+// position rather than by content.
+type node struct {
+	// prefix is the static path fragment matched by this node
+	prefix string
+
+	// indices holds the first byte of every static child, in the same order as children
+	indices string
+
+	// children are the static children of this node
+	children []*node
+
+	// paramChild matches a single ":name" segment
+	paramChild *node
+
+	// paramName is the name of the parameter captured by paramChild
+	paramName string
+
+	// catchAll matches the remainder of the path for a "*name" segment
+	catchAll *node
+
+	// catchAllName is the name of the parameter captured by catchAll
+	catchAllName string
+
+	// routes holds the routes registered on this node, keyed by HTTP method
+	routes map[string]*route
+
+	// pattern is the full route pattern that ends at this node
+	pattern string
+}
+
+// insert adds the route for method and pattern to the tree.
+// It panics if the pattern is malformed or conflicts with an existing route,
+// since both are programming errors detected at startup.
+func (n *node) insert(method, pattern string, rt *route) {
+	if pattern == "" || pattern[0] != '/' {
+		panic(fmt.Sprintf("router: path %q must begin with '/'", pattern))
+	}
+
+	current := n
+	path := pattern
+	for {
+		wildcard := strings.IndexAny(path, ":*")
+		if wildcard < 0 {
+			current = current.insertStatic(path)
+			break
+		}
+
+		if wildcard > 0 && path[wildcard-1] != '/' {
+			panic(fmt.Sprintf("router: wildcard in %q must follow a '/'", pattern))
+		}
+		if wildcard > 0 {
+			current = current.insertStatic(path[:wildcard])
+		}
+
+		end := strings.IndexByte(path[wildcard:], '/')
+		if end < 0 {
+			end = len(path)
+		} else {
+			end += wildcard
+		}
+		name := path[wildcard+1 : end]
+		if name == "" {
+			panic(fmt.Sprintf("router: wildcard in %q must be named", pattern))
+		}
+		if strings.ContainsAny(name, ":*") {
+			panic(fmt.Sprintf("router: only one wildcard per segment is allowed in %q", pattern))
+		}
+
+		if path[wildcard] == '*' {
+			if end != len(path) {
+				panic(fmt.Sprintf("router: catch-all must be the last segment in %q", pattern))
+			}
+			if current.catchAll == nil {
+				current.catchAll = &node{}
+				current.catchAllName = name
+			} else if current.catchAllName != name {
+				panic(fmt.Sprintf("router: catch-all %q in %q conflicts with existing %q",
+					name, pattern, current.catchAllName))
+			}
+			current = current.catchAll
+			break
+		}
+
+		if current.paramChild == nil {
+			current.paramChild = &node{}
+			current.paramName = name
+		} else if current.paramName != name {
+			panic(fmt.Sprintf("router: parameter %q in %q conflicts with existing %q",
+				name, pattern, current.paramName))
+		}
+		current = current.paramChild
+
+		path = path[end:]
+		if path == "" {
+			break
+		}
+	}
+
+	if current.routes == nil {
+		current.routes = make(map[string]*route)
+	}
+	if _, exists := current.routes[method]; exists {
+		panic(fmt.Sprintf("router: route %s %s is already registered", method, pattern))
+	}
+	current.routes[method] = rt
+	current.pattern = pattern
+}
+
+// insertStatic walks or creates the static nodes for path and returns the node
+// where path ends, splitting existing nodes when only a prefix is shared.
+func (n *node) insertStatic(path string) *node {
+	current := n
+	for path != "" {
+		idx := strings.IndexByte(current.indices, path[0])
+		if idx < 0 {
+			child := &node{prefix: path}
+			current.indices += string(path[0])
+			current.children = append(current.children, child)
+			return child
+		}
+
+		child := current.children[idx]
+		common := commonPrefix(path, child.prefix)
+		if common < len(child.prefix) {
+			// Split child so that the shared prefix becomes its own node.
+			split := &node{
+				prefix:       child.prefix[common:],
+				indices:      child.indices,
+				children:     child.children,
+				paramChild:   child.paramChild,
+				paramName:    child.paramName,
+				catchAll:     child.catchAll,
+				catchAllName: child.catchAllName,
+				routes:       child.routes,
+				pattern:      child.pattern,
+			}
+			*child = node{
+				prefix:   child.prefix[:common],
+				indices:  string(split.prefix[0]),
+				children: []*node{split},
+			}
+		}
+
+		current = child
+		path = path[common:]
+	}
+	return current
+}
+
+// lookup finds the route registered for method that matches path.
+// Static children take precedence over parameters, and parameters take
+// precedence over catch-alls; the search backtracks when a more specific
+// branch does not lead to a match. Captured parameters are appended to params.
+func (n *node) lookup(method, path string, params []param) (*route, []param) {
+	if path == "" {
+		if rt := n.routes[method]; rt != nil {
+			return rt, params
+		}
+	}
+
+	if path != "" {
+		if idx := strings.IndexByte(n.indices, path[0]); idx >= 0 {
+			child := n.children[idx]
+			if strings.HasPrefix(path, child.prefix) {
+				if rt, ps := child.lookup(method, path[len(child.prefix):], params); rt != nil {
+					return rt, ps
+				}
+			}
+		}
+
+		if n.paramChild != nil {
+			end := strings.IndexByte(path, '/')
+			if end < 0 {
+				end = len(path)
+			}
+			if end > 0 {
+				captured := append(params, param{name: n.paramName, value: path[:end]})
+				if rt, ps := n.paramChild.lookup(method, path[end:], captured); rt != nil {
+					return rt, ps
+				}
+			}
+		}
+	}
+
+	if n.catchAll != nil {
+		if rt := n.catchAll.routes[method]; rt != nil {
+			return rt, append(params, param{name: n.catchAllName, value: path})
+		}
+	}
+
+	return nil, params
+}
+
+// commonPrefix returns the length of the longest common prefix of a and b.
+func commonPrefix(a, b string) int {
+	limit := len(a)
+	if len(b) < limit {
+		limit = len(b)
+	}
+	i := 0
+	for i < limit && a[i] == b[i] {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,115 @@
+package router
+
+import (
+	"testing"
+)
+
+func TestNode_Lookup(t *testing.T) {
+	root := &node{}
+	patterns := []string{
+		"/",
+		"/users",
+		"/users/new",
+		"/users/:id",
+		"/users/:id/posts/:postId",
+		"/uploads",
+		"/static/*filepath",
+		"/files/:name/*rest",
+	}
+	for _, p := range patterns {
+		root.insert("GET", p, &route{})
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		wantPattern string
+		wantParams  map[string]string
+	}{
+		{"Root", "/", "/", nil},
+		{"Static", "/users", "/users", nil},
+		{"Shared prefix", "/uploads", "/uploads", nil},
+		{"Static wins over param", "/users/new", "/users/new", nil},
+		{"Param", "/users/42", "/users/:id", map[string]string{"id": "42"}},
+		{"Nested params", "/users/42/posts/7", "/users/:id/posts/:postId", map[string]string{"id": "42", "postId": "7"}},
+		{"Catch-all", "/static/css/site.css", "/static/*filepath", map[string]string{"filepath": "css/site.css"}},
+		{"Empty catch-all", "/static/", "/static/*filepath", map[string]string{"filepath": ""}},
+		{"Param then catch-all", "/files/a/b/c", "/files/:name/*rest", map[string]string{"name": "a", "rest": "b/c"}},
+		{"Backtrack from static", "/users/newer", "/users/:id", map[string]string{"id": "newer"}},
+		{"No match", "/missing", "", nil},
+		{"Empty param segment", "/users//posts/1", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt, params := root.lookup("GET", tt.path, nil)
+			if tt.wantPattern == "" {
+				if rt != nil {
+					t.Fatalf("lookup(%q) matched, want no match", tt.path)
+				}
+				return
+			}
+			if rt == nil {
+				t.Fatalf("lookup(%q) found no route, want %q", tt.path, tt.wantPattern)
+			}
+
+			if len(params) != len(tt.wantParams) {
+				t.Fatalf("lookup(%q) params = %v, want %v", tt.path, params, tt.wantParams)
+			}
+			for _, p := range params {
+				if tt.wantParams[p.name] != p.value {
+					t.Errorf("param %q = %q, want %q", p.name, p.value, tt.wantParams[p.name])
+				}
+			}
+		})
+	}
+}
+
+func TestNode_LookupByMethod(t *testing.T) {
+	root := &node{}
+	getRoute := &route{}
+	postRoute := &route{}
+	root.insert("GET", "/users/new", getRoute)
+	root.insert("POST", "/users/:id", postRoute)
+
+	if rt, _ := root.lookup("POST", "/users/new", nil); rt != postRoute {
+		t.Error("POST /users/new should fall back to the parameter route")
+	}
+	if rt, _ := root.lookup("GET", "/users/new", nil); rt != getRoute {
+		t.Error("GET /users/new should match the static route")
+	}
+	if rt, _ := root.lookup("DELETE", "/users/new", nil); rt != nil {
+		t.Error("DELETE /users/new should not match")
+	}
+}
+
+func TestNode_InsertPanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		pattern  string
+	}{
+		{"Missing leading slash", "", "users"},
+		{"Unnamed param", "", "/users/:"},
+		{"Catch-all not last", "", "/static/*path/more"},
+		{"Wildcard mid-segment", "", "/users/a:id"},
+		{"Conflicting param names", "/users/:id", "/users/:name"},
+		{"Duplicate route", "/users", "/users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := &node{}
+			if tt.existing != "" {
+				root.insert("GET", tt.existing, &route{})
+			}
+
+			defer func() {
+				if recover() == nil {
+					t.Errorf("insert(%q) should panic", tt.pattern)
+				}
+			}()
+			root.insert("GET", tt.pattern, &route{})
+		})
+	}
+}